@@ -0,0 +1,198 @@
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+// AttachmentOptions contains options for AddAttachment and UpdateAttachment
+type AttachmentOptions struct {
+	// ContentType is the media type of the attachment. It defaults to
+	// application/octet-stream.
+	ContentType string
+	// Filename is the file name of the attachment.
+	Filename string
+	// RecurrenceIDs restricts the operation to specific instances of a
+	// recurring event. "M" designates the master component. When empty, all
+	// instances are affected.
+	RecurrenceIDs []string
+	// IfMatch is the expected ETag of the calendar object.
+	IfMatch string
+}
+
+// RemoveAttachmentOptions contains options for RemoveAttachment
+type RemoveAttachmentOptions struct {
+	// RecurrenceIDs restricts the operation to specific instances of a
+	// recurring event, see AttachmentOptions.
+	RecurrenceIDs []string
+	// IfMatch is the expected ETag of the calendar object.
+	IfMatch string
+}
+
+// ManagedAttachment is the result of AddAttachment and UpdateAttachment
+type ManagedAttachment struct {
+	// ManagedID identifies the attachment on the server. It changes when
+	// the attachment is updated.
+	ManagedID string
+	// Object is the updated calendar object. Its Data is only set if the
+	// server returned the new calendar data.
+	Object *CalendarObject
+}
+
+// GetCalendarHome fetches the properties of a calendar home collection.
+func (c *Client) GetCalendarHome(ctx context.Context, path string) (*CalendarHome, error) {
+	resp, err := c.ic.PropFindFlat(ctx, path, calendarHomePropFind)
+	if err != nil {
+		return nil, err
+	}
+
+	home := &CalendarHome{Path: path}
+	if p, err := resp.Path(); err == nil {
+		home.Path = p
+	}
+
+	var serverURL managedAttachmentsServerURL
+	if err := resp.DecodeProp(&serverURL); err == nil {
+		if serverURL.Href != nil && serverURL.Href.String() != "" {
+			u := (*url.URL)(serverURL.Href)
+			home.ManagedAttachmentsServerURL = c.ic.ResolveHref("/").ResolveReference(u).String()
+		} else {
+			// An empty href designates the CalDAV server itself
+			home.ManagedAttachmentsServerURL = c.ic.ResolveHref("/").String()
+		}
+	} else if !internal.IsNotFound(err) {
+		return nil, err
+	}
+
+	var maxSize maxAttachmentSize
+	if err := resp.DecodeProp(&maxSize); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	home.MaxAttachmentSize = maxSize.Size
+
+	var maxPerResource maxAttachmentsPerResource
+	if err := resp.DecodeProp(&maxPerResource); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	home.MaxAttachmentsPerResource = maxPerResource.Max
+
+	var transports calendarServerPushTransports
+	if err := resp.DecodeProp(&transports); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	home.PushTransports = newCalendarServerPushTransports(&transports)
+
+	return home, nil
+}
+
+// AddAttachment adds a managed attachment to a calendar object, as defined in
+// RFC 8607. The attachment content is streamed from body.
+//
+// The server adds an ATTACH property to the calendar object and returns its
+// new ETag.
+func (c *Client) AddAttachment(ctx context.Context, path string, body io.Reader, opts *AttachmentOptions) (*ManagedAttachment, error) {
+	if opts == nil {
+		opts = new(AttachmentOptions)
+	}
+	return c.doAttachment(ctx, path, "attachment-add", "", body, opts)
+}
+
+// UpdateAttachment replaces the content of a managed attachment.
+//
+// The attachment gets a new managed ID, reported in the result.
+func (c *Client) UpdateAttachment(ctx context.Context, path, managedID string, body io.Reader, opts *AttachmentOptions) (*ManagedAttachment, error) {
+	if opts == nil {
+		opts = new(AttachmentOptions)
+	}
+	return c.doAttachment(ctx, path, "attachment-update", managedID, body, opts)
+}
+
+// RemoveAttachment removes a managed attachment from a calendar object. The
+// updated calendar object is returned.
+func (c *Client) RemoveAttachment(ctx context.Context, path, managedID string, opts *RemoveAttachmentOptions) (*CalendarObject, error) {
+	if opts == nil {
+		opts = new(RemoveAttachmentOptions)
+	}
+	result, err := c.doAttachment(ctx, path, "attachment-remove", managedID, nil, &AttachmentOptions{
+		RecurrenceIDs: opts.RecurrenceIDs,
+		IfMatch:       opts.IfMatch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Object, nil
+}
+
+func (c *Client) doAttachment(ctx context.Context, path, action, managedID string, body io.Reader, opts *AttachmentOptions) (*ManagedAttachment, error) {
+	req, err := c.ic.NewRequest(http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"action": {action}}
+	if managedID != "" {
+		query.Set("managed-id", managedID)
+	}
+	if len(opts.RecurrenceIDs) > 0 {
+		query.Set("rid", strings.Join(opts.RecurrenceIDs, ","))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	if body != nil {
+		contentType := opts.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		req.Header.Set("Content-Type", contentType)
+		if opts.Filename != "" {
+			req.Header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+				"filename": opts.Filename,
+			}))
+		}
+	}
+	if opts.IfMatch != "" {
+		req.Header.Set("If-Match", fmt.Sprintf(`"%s"`, opts.IfMatch))
+	}
+
+	resp, err := c.ic.Do(req.WithContext(ctx))
+	if err != nil {
+		if webdav.IsPreconditionFailed(err) {
+			return nil, newPreconditionFailedError(path, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	co := &CalendarObject{}
+	if err := populateCalendarObject(co, resp.Header); err != nil {
+		return nil, err
+	}
+	// The Location header, if any, doesn't designate the calendar object
+	co.Path = path
+	co.ContentLength = 0
+
+	if t, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && strings.EqualFold(t, MIMEType) {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			co.ContentType = t
+			co.Data = data
+			co.ContentLength = int64(len(data))
+		}
+	}
+
+	return &ManagedAttachment{
+		ManagedID: resp.Header.Get("Cal-Managed-ID"),
+		Object:    co,
+	}, nil
+}
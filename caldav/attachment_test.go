@@ -0,0 +1,129 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PROPFIND" {
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/home/</d:href>
+    <d:propstat>
+      <d:prop>
+        <cal:managed-attachments-server-URL><d:href>https://attachments.example.com/</d:href></cal:managed-attachments-server-URL>
+        <cal:max-attachment-size>1048576</cal:max-attachment-size>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop><cal:max-attachments-per-resource/></d:prop>
+      <d:status>HTTP/1.1 404 Not Found</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`))
+			return
+		}
+
+		if r.Method != http.MethodPost || r.URL.Path != "/cal/event.ics" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "attachment-add":
+			if got := r.Header.Get("Content-Type"); got != "text/plain" {
+				t.Errorf("got Content-Type %q", got)
+			}
+			if got := r.Header.Get("Content-Disposition"); got != `attachment; filename=notes.txt` {
+				t.Errorf("got Content-Disposition %q", got)
+			}
+			if got := r.Header.Get("If-Match"); got != `"1"` {
+				t.Errorf("got If-Match %q", got)
+			}
+			if got := q.Get("rid"); got != "M,20240101T100000Z" {
+				t.Errorf("got rid %q", got)
+			}
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != "hello" {
+				t.Errorf("got body %q", body)
+			}
+			w.Header().Set("Cal-Managed-ID", "m1")
+			w.Header().Set("ETag", `"2"`)
+			w.Header().Set("Location", "https://attachments.example.com/m1")
+			w.WriteHeader(http.StatusCreated)
+		case "attachment-update":
+			if got := q.Get("managed-id"); got != "m1" {
+				t.Errorf("got managed-id %q", got)
+			}
+			w.Header().Set("Cal-Managed-ID", "m2")
+			w.Header().Set("ETag", `"3"`)
+			w.WriteHeader(http.StatusNoContent)
+		case "attachment-remove":
+			if got := q.Get("managed-id"); got != "m2" {
+				t.Errorf("got managed-id %q", got)
+			}
+			w.Header().Set("ETag", `"4"`)
+			w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+			w.Write([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+		case "":
+			t.Errorf("missing action")
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	home, err := c.GetCalendarHome(ctx, "/home/")
+	if err != nil {
+		t.Fatalf("GetCalendarHome error: %v", err)
+	}
+	if home.ManagedAttachmentsServerURL != "https://attachments.example.com/" {
+		t.Errorf("got server URL %q", home.ManagedAttachmentsServerURL)
+	}
+	if home.MaxAttachmentSize != 1048576 || home.MaxAttachmentsPerResource != 0 {
+		t.Errorf("unexpected home: %+v", home)
+	}
+
+	added, err := c.AddAttachment(ctx, "/cal/event.ics", strings.NewReader("hello"), &AttachmentOptions{
+		ContentType:   "text/plain",
+		Filename:      "notes.txt",
+		RecurrenceIDs: []string{"M", "20240101T100000Z"},
+		IfMatch:       "1",
+	})
+	if err != nil {
+		t.Fatalf("AddAttachment error: %v", err)
+	}
+	if added.ManagedID != "m1" || added.Object.ETag != "2" || added.Object.Path != "/cal/event.ics" {
+		t.Errorf("unexpected result: %+v %+v", added, added.Object)
+	}
+
+	updated, err := c.UpdateAttachment(ctx, "/cal/event.ics", "m1", strings.NewReader("bye"), nil)
+	if err != nil {
+		t.Fatalf("UpdateAttachment error: %v", err)
+	}
+	if updated.ManagedID != "m2" || updated.Object.ETag != "3" {
+		t.Errorf("unexpected result: %+v %+v", updated, updated.Object)
+	}
+
+	co, err := c.RemoveAttachment(ctx, "/cal/event.ics", "m2", nil)
+	if err != nil {
+		t.Fatalf("RemoveAttachment error: %v", err)
+	}
+	if co.ETag != "4" || !strings.HasPrefix(string(co.Data), "BEGIN:VCALENDAR") {
+		t.Errorf("unexpected object: %+v", co)
+	}
+}
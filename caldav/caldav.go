@@ -1,7 +1,13 @@
 package caldav
 
 import (
+	"encoding/xml"
+	"io"
+	"strings"
 	"time"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
 )
 
 type Calendar struct {
@@ -10,15 +16,110 @@ type Calendar struct {
 	Description           string
 	MaxResourceSize       int64
 	SupportedComponentSet []string
+	SupportedCalendarData []CalendarDataType
 	Color                 string
-	Timezone              string
-	SyncToken             string
+	// Order is the position of the calendar in calendar lists, zero if
+	// unset.
+	Order                  int
+	Timezone               string
+	ScheduleCalendarTransp ScheduleTransp
+	SyncToken              string
+	// CTag is the CalendarServer collection tag, which changes whenever
+	// the calendar or its members change. It's empty if unsupported.
+	CTag                  string
 	CurrentUserPrivileges []string
+	// Quota is nil if the server doesn't report quota for the calendar, or if
+	// it wasn't requested, see GetCalendarOptions and FindCalendarsOptions.
+	Quota *webdav.Quota
+	// PushTransports lists the WebDAV-Push transports supported for the
+	// calendar, and PushTopic identifies the calendar in push messages.
+	// Both are empty if the server doesn't support WebDAV-Push.
+	PushTransports []PushTransport
+	PushTopic      string
+	// PushKey is the CalendarServer push key of the calendar, if any.
+	PushKey string
+	// DefaultAlarmVEventDateTime and DefaultAlarmVEventDate are the default
+	// alarms of timed and all-day events, from the
+	// default-alarm-vevent-datetime and default-alarm-vevent-date
+	// properties. They're empty if unset.
+	DefaultAlarmVEventDateTime []ical.Alarm
+	DefaultAlarmVEventDate     []ical.Alarm
+}
+
+// CalendarHome contains the properties of a calendar home collection.
+type CalendarHome struct {
+	Path string
+	// ManagedAttachmentsServerURL is the URL of the server storing managed
+	// attachments. It's empty if the server doesn't support managed
+	// attachments, and equal to the CalDAV server URL if the server didn't
+	// report a different one.
+	ManagedAttachmentsServerURL string
+	// MaxAttachmentSize is the maximum size of an attachment in bytes, zero
+	// if unknown.
+	MaxAttachmentSize int64
+	// MaxAttachmentsPerResource is the maximum number of attachments of a
+	// calendar object, zero if unknown.
+	MaxAttachmentsPerResource int64
+	// PushTransports lists the CalendarServer push transports advertised
+	// by the calendar home, if any.
+	PushTransports []PushTransport
+}
+
+// ScheduleTransp indicates whether the events of a calendar affect the owner's
+// busy time, as defined in RFC 6638 section 9.1.
+type ScheduleTransp string
+
+const (
+	ScheduleTranspOpaque      ScheduleTransp = "opaque"
+	ScheduleTranspTransparent ScheduleTransp = "transparent"
+)
+
+// CalendarDataType is a media type supported by a calendar collection for
+// calendar object resources, as advertised by supported-calendar-data.
+type CalendarDataType struct {
+	ContentType string
+	Version     string
+}
+
+// SupportsCalendarData reports whether the calendar accepts and returns
+// calendar data of the specified media type. Servers which don't advertise
+// supported-calendar-data only support iCalendar.
+func (c *Calendar) SupportsCalendarData(contentType string) bool {
+	if len(c.SupportedCalendarData) == 0 {
+		return strings.EqualFold(contentType, MIMEType)
+	}
+	for _, t := range c.SupportedCalendarData {
+		if strings.EqualFold(t.ContentType, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsComponent reports whether the calendar accepts calendar objects
+// containing components of the specified type, e.g. "VEVENT". Servers which
+// don't advertise supported-calendar-component-set accept all types.
+func (c *Calendar) SupportsComponent(name string) bool {
+	if len(c.SupportedComponentSet) == 0 {
+		return true
+	}
+	for _, comp := range c.SupportedComponentSet {
+		if strings.EqualFold(comp, name) {
+			return true
+		}
+	}
+	return false
 }
 
 type CalendarCompRequest struct {
 	Name string
 
+	// ContentType and Version request a specific calendar data media type,
+	// e.g. JCalMIMEType or XCalMIMEType. They're only honoured on the
+	// top-level request. When empty, the server returns iCalendar data.
+	ContentType string
+	Version     string
+
 	AllProps bool
 	Props    []string
 
@@ -70,7 +171,10 @@ type CalendarObject struct {
 	ModTime       time.Time
 	ContentLength int64
 	ETag          string
-	Data          []byte
+	// ContentType is the media type of Data, if known. An empty value
+	// usually means iCalendar.
+	ContentType string
+	Data        []byte
 }
 
 // SyncQuery is the query struct represents a sync-collection request
@@ -90,6 +194,10 @@ type SyncResponse struct {
 	Calendar  *Calendar // 集合本身的属性
 	Updated   []*CalendarObject
 	Deleted   []string
+	// Errors contains an entry for each resource the server reported a
+	// failure for, other than deleted resources, or which couldn't be
+	// decoded
+	Errors []*HrefError
 }
 
 // CalendarListSyncResult represents the result of a calendar list synchronization
@@ -102,6 +210,55 @@ type CalendarListSyncResult struct {
 	DeletedCalendars []string
 	// NextSyncToken is the sync token to use for the next synchronization
 	NextSyncToken string
+	// Errors contains an entry for each resource the server reported a
+	// failure for, other than deleted calendars
+	Errors []*HrefError
+}
+
+// GetCalendarOptions contains options for GetCalendarWithOptions
+type GetCalendarOptions struct {
+	// Quota requests the quota of the calendar, reported in Calendar.Quota.
+	// Some servers are slow to compute it.
+	Quota bool
+}
+
+// FindCalendarsOptions contains options for FindCalendarsWithOptions
+type FindCalendarsOptions struct {
+	// Quota requests the quota of the calendars, reported in Calendar.Quota.
+	// Some servers are slow to compute it.
+	Quota bool
+}
+
+// GetCalendarObjectOptions contains options for GetCalendarObjectWithOptions
+// and OpenCalendarObject
+type GetCalendarObjectOptions struct {
+	// IfNoneMatch is the ETag of a cached copy of the object. If the object
+	// still has this ETag, its data isn't transferred again.
+	IfNoneMatch string
+	// ContentType requests a specific calendar data media type, e.g.
+	// JCalMIMEType or XCalMIMEType. Servers which don't support it may still
+	// return iCalendar data, see CalendarObject.ContentType. When empty,
+	// iCalendar data is requested.
+	ContentType string
+}
+
+// GetCalendarObjectResult contains the outcome of
+// GetCalendarObjectWithOptions
+type GetCalendarObjectResult struct {
+	// NotModified is true if the object still matches the IfNoneMatch
+	// option. Object then only contains the path and ETag.
+	NotModified bool
+	Object      *CalendarObject
+}
+
+// CalendarObjectReader streams the data of a calendar object
+type CalendarObjectReader struct {
+	io.ReadCloser
+	// Object contains the object metadata. Its Data field is nil.
+	Object *CalendarObject
+	// NotModified is true if the object still matches the IfNoneMatch
+	// option. The reader doesn't return any data then.
+	NotModified bool
 }
 
 // PutCalendarObjectOptions contains options for PutCalendarObject
@@ -115,6 +272,24 @@ type PutCalendarObjectOptions struct {
 	// Used to prevent accidental overwrites when creating new resources.
 	// If specified as "*" and the resource exists, returns 412 Precondition Failed.
 	IfNoneMatch string
+
+	// If submits lock tokens held on the resource or its calendar collection.
+	If webdav.IfHeader
+
+	// Validate checks the body with ValidateCalendarData before upload. The
+	// body is read into memory, and a *ValidationError is returned without
+	// contacting the server if it's invalid.
+	Validate bool
+
+	// ScheduleReply sets the Schedule-Reply header (RFC 6638 section 8.1).
+	// When false, a server supporting scheduling doesn't deliver the iTIP
+	// reply triggered by an attendee's change. Nil omits the header, and the
+	// server delivers it.
+	ScheduleReply *bool
+
+	// FetchCurrent fetches the current version of the object when a
+	// conditional request fails, reported in PreconditionFailedError.Current.
+	FetchCurrent bool
 }
 
 // UpdateCalendarOptions contains options for updating Calendar properties
@@ -130,4 +305,42 @@ type UpdateCalendarOptions struct {
 
 	// Timezone updates the calendar timezone (calendar-timezone property)
 	Timezone *string
+
+	// Order updates the position of the calendar in calendar lists
+	// (calendar-order property)
+	Order *int
+
+	// SupportedComponentSet updates the component types the calendar accepts
+	// (supported-calendar-component-set property). Most servers only allow
+	// setting it when creating the calendar and reject the update.
+	SupportedComponentSet []string
+
+	// DefaultAlarmVEventDateTime and DefaultAlarmVEventDate update the
+	// default alarms of timed and all-day events
+	// (default-alarm-vevent-datetime and default-alarm-vevent-date
+	// properties). An empty slice removes the property.
+	DefaultAlarmVEventDateTime *[]ical.Alarm
+	DefaultAlarmVEventDate     *[]ical.Alarm
+
+	// ScheduleCalendarTransp updates whether the calendar's events affect the
+	// owner's busy time (schedule-calendar-transp property)
+	ScheduleCalendarTransp *ScheduleTransp
+}
+
+// UpdateCalendarResult contains the outcome of UpdateCalendarWithResult.
+// Properties are reported by name.
+type UpdateCalendarResult struct {
+	// Calendar is the calendar after the update.
+	Calendar *Calendar
+	// Applied contains the properties which were set or removed.
+	Applied []xml.Name
+	// Rejected contains the properties the server refused to update with
+	// 403 Forbidden, e.g. protected properties.
+	Rejected []xml.Name
+	// FailedDependency contains the properties which weren't updated with
+	// 424 Failed Dependency because another update failed.
+	FailedDependency []xml.Name
+	// Failed contains the properties the server reported other failures
+	// for.
+	Failed []webdav.Property
 }
@@ -2,7 +2,9 @@ package caldav
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"mime"
@@ -17,6 +19,12 @@ import (
 
 const MIMEType = "text/calendar"
 
+// JCalMIMEType is the media type of jCal calendar data, defined in RFC 7265.
+const JCalMIMEType = "application/calendar+json"
+
+// XCalMIMEType is the media type of xCal calendar data, defined in RFC 6321.
+const XCalMIMEType = "application/calendar+xml"
+
 // DiscoverContextURL performs a DNS-based CardDAV service discovery as
 // described in RFC 6352 section 11. It returns the URL to the CardDAV server.
 func DiscoverContextURL(ctx context.Context, domain string) (string, error) {
@@ -58,7 +66,20 @@ func (c *Client) FindCalendarHomeSet(ctx context.Context, principal string) (str
 }
 
 func (c *Client) FindCalendars(ctx context.Context, calendarHomeSet string) ([]Calendar, error) {
-	ms, err := c.ic.PropFind(ctx, calendarHomeSet, internal.DepthOne, calendarPropFind)
+	return c.FindCalendarsWithOptions(ctx, calendarHomeSet, nil)
+}
+
+// FindCalendarsWithOptions is like FindCalendars, but accepts options.
+func (c *Client) FindCalendarsWithOptions(ctx context.Context, calendarHomeSet string, opts *FindCalendarsOptions) ([]Calendar, error) {
+	if opts == nil {
+		opts = new(FindCalendarsOptions)
+	}
+
+	propfind := calendarPropFind
+	if opts.Quota {
+		propfind = calendarQuotaPropFind
+	}
+	ms, err := c.ic.PropFind(ctx, calendarHomeSet, internal.DepthOne, propfind)
 	if err != nil {
 		return nil, err
 	}
@@ -91,8 +112,21 @@ func (c *Client) FindCalendars(ctx context.Context, calendarHomeSet string) ([]C
 // The path parameter should be the full path to a calendar collection,
 // not a calendar home set path.
 func (c *Client) GetCalendar(ctx context.Context, path string) (*Calendar, error) {
+	return c.GetCalendarWithOptions(ctx, path, nil)
+}
+
+// GetCalendarWithOptions is like GetCalendar, but accepts options.
+func (c *Client) GetCalendarWithOptions(ctx context.Context, path string, opts *GetCalendarOptions) (*Calendar, error) {
+	if opts == nil {
+		opts = new(GetCalendarOptions)
+	}
+
+	propfind := calendarPropFind
+	if opts.Quota {
+		propfind = calendarQuotaPropFind
+	}
 	// Use DepthZero to query only the specified calendar collection
-	resp, err := c.ic.PropFindFlat(ctx, path, calendarPropFind)
+	resp, err := c.ic.PropFindFlat(ctx, path, propfind)
 	if err != nil {
 		return nil, fmt.Errorf("caldav: failed to get calendar properties: %w", err)
 	}
@@ -114,44 +148,115 @@ func (c *Client) GetCalendar(ctx context.Context, path string) (*Calendar, error
 	return calendar, nil
 }
 
+// GetCalendarObject fetches a calendar object.
 func (c *Client) GetCalendarObject(ctx context.Context, path string) (*CalendarObject, error) {
-	req, err := c.ic.NewRequest(http.MethodGet, path, nil)
+	result, err := c.GetCalendarObjectWithOptions(ctx, path, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", MIMEType)
+	return result.Object, nil
+}
 
-	resp, err := c.ic.Do(req.WithContext(ctx))
+// GetCalendarObjectWithOptions fetches a calendar object. When
+// opts.IfNoneMatch is set and the object wasn't modified, its data isn't
+// transferred and the result's NotModified field is set.
+func (c *Client) GetCalendarObjectWithOptions(ctx context.Context, path string, opts *GetCalendarObjectOptions) (*GetCalendarObjectResult, error) {
+	r, err := c.OpenCalendarObject(ctx, path, opts)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer r.Close()
 
-	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if r.NotModified {
+		return &GetCalendarObjectResult{NotModified: true, Object: r.Object}, nil
+	}
+
+	// 读取响应体数据
+	bodyData, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	if !strings.EqualFold(mediaType, MIMEType) {
-		return nil, fmt.Errorf("caldav: expected Content-Type %q, got %q", MIMEType, mediaType)
+
+	co := r.Object
+	co.Data = bodyData
+	return &GetCalendarObjectResult{Object: co}, nil
+}
+
+// OpenCalendarObject fetches a calendar object, streaming its data. The
+// caller must close the returned reader.
+//
+// When opts.IfNoneMatch is set and the object wasn't modified, the reader's
+// NotModified field is set and it doesn't return any data.
+func (c *Client) OpenCalendarObject(ctx context.Context, path string, opts *GetCalendarObjectOptions) (*CalendarObjectReader, error) {
+	if opts == nil {
+		opts = new(GetCalendarObjectOptions)
 	}
 
-	// 读取响应体数据
-	bodyData, err := io.ReadAll(resp.Body)
+	req, err := c.ic.NewRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return nil, err
 	}
+	contentType := MIMEType
+	if opts.ContentType != "" && !strings.EqualFold(opts.ContentType, MIMEType) {
+		contentType = opts.ContentType
+		// Fall back to iCalendar data if the server doesn't support the
+		// requested media type
+		req.Header.Set("Accept", contentType+", "+MIMEType+";q=0.5")
+	} else {
+		req.Header.Set("Accept", MIMEType)
+	}
+	if opts.IfNoneMatch != "" {
+		req.Header.Set("If-None-Match", fmt.Sprintf(`"%s"`, opts.IfNoneMatch))
+	}
+
+	resp, err := c.ic.Do(req.WithContext(ctx))
+	if webdav.StatusCode(err) == http.StatusNotModified {
+		return &CalendarObjectReader{
+			ReadCloser:  http.NoBody,
+			Object:      &CalendarObject{Path: path, ETag: opts.IfNoneMatch},
+			NotModified: true,
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if !strings.EqualFold(mediaType, contentType) && !strings.EqualFold(mediaType, MIMEType) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("caldav: expected Content-Type %q, got %q", contentType, mediaType)
+	}
 
 	co := &CalendarObject{
-		Path: resp.Request.URL.Path,
-		Data: bodyData,
+		Path:        resp.Request.URL.Path,
+		ContentType: mediaType,
 	}
 	if err := populateCalendarObject(co, resp.Header); err != nil {
+		resp.Body.Close()
 		return nil, err
 	}
-	return co, nil
+	return &CalendarObjectReader{ReadCloser: resp.Body, Object: co}, nil
 }
 
+// PutCalendarObject uploads a calendar object to the server.
+//
+// When a conditional request set via opts fails, a *PreconditionFailedError
+// is returned.
 func (c *Client) PutCalendarObject(ctx context.Context, path string, body io.Reader, opts *PutCalendarObjectOptions) (*CalendarObject, error) {
+	if opts != nil && opts.Validate {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		if diags := ValidateCalendarData(data); len(diags) > 0 {
+			return nil, &ValidationError{Diagnostics: diags}
+		}
+		body = bytes.NewReader(data)
+	}
+
 	req, err := c.ic.NewRequest(http.MethodPut, path, body)
 	if err != nil {
 		return nil, err
@@ -174,6 +279,20 @@ func (c *Client) PutCalendarObject(ctx context.Context, path string, body io.Rea
 				req.Header.Set("If-None-Match", fmt.Sprintf(`"%s"`, opts.IfNoneMatch))
 			}
 		}
+		if len(opts.If) > 0 {
+			v, err := opts.If.Format()
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("If", v)
+		}
+		if opts.ScheduleReply != nil {
+			if *opts.ScheduleReply {
+				req.Header.Set("Schedule-Reply", "T")
+			} else {
+				req.Header.Set("Schedule-Reply", "F")
+			}
+		}
 	}
 
 	resp, err := c.ic.Do(req.WithContext(ctx))
@@ -181,7 +300,17 @@ func (c *Client) PutCalendarObject(ctx context.Context, path string, body io.Rea
 		// internal.Client.Do returns *internal.HTTPError for non-2xx
 		if httpErr, ok := err.(*internal.HTTPError); ok {
 			if httpErr.Code == http.StatusPreconditionFailed {
-				return nil, fmt.Errorf("caldav: precondition failed - resource ETag mismatch or conflict")
+				pfErr := newPreconditionFailedError(path, httpErr)
+				if opts != nil && opts.FetchCurrent {
+					current, err := c.GetCalendarObject(ctx, path)
+					if err != nil && !webdav.IsNotFound(err) {
+						return nil, err
+					} else if err == nil {
+						pfErr.Current = current
+						pfErr.ETag = current.ETag
+					}
+				}
+				return nil, pfErr
 			}
 			return nil, httpErr
 		}
@@ -240,9 +369,9 @@ func (c *Client) DeleteCalendarObject(ctx context.Context, path string, opts *De
 		if httpErr, ok := err.(*internal.HTTPError); ok {
 			switch httpErr.Code {
 			case http.StatusPreconditionFailed:
-				return fmt.Errorf("caldav: precondition failed - resource ETag mismatch, resource may have been modified")
+				return fmt.Errorf("caldav: precondition failed - resource ETag mismatch, resource may have been modified: %w", httpErr)
 			case http.StatusNotFound:
-				return fmt.Errorf("caldav: calendar object not found at path: %s", path)
+				return fmt.Errorf("caldav: calendar object not found at path: %s: %w", path, httpErr)
 			default:
 				return httpErr
 			}
@@ -262,6 +391,9 @@ func (c *Client) DeleteCalendarObjectSimple(ctx context.Context, path string) er
 
 // SyncCalendar performs a collection synchronization operation on the
 // specified resource, as defined in RFC 6578.
+//
+// Failures for individual resources don't abort the synchronization: they're
+// reported in the response's Errors instead.
 func (c *Client) SyncCalendar(ctx context.Context, path string, query *SyncQuery) (*SyncResponse, error) {
 	if query == nil {
 		query = &SyncQuery{}
@@ -306,11 +438,12 @@ func (c *Client) SyncCalendar(ctx context.Context, path string, query *SyncQuery
 	for _, resp := range ms.Responses {
 		p, err := resp.Path()
 		if err != nil {
-			if err, ok := err.(*internal.HTTPError); ok && err.Code == http.StatusNotFound {
+			if err, ok := err.(*internal.HTTPError); ok && err.Code == http.StatusNotFound && len(resp.Hrefs) == 1 {
 				ret.Deleted = append(ret.Deleted, p)
 				continue
 			}
-			return nil, err
+			ret.Errors = append(ret.Errors, newResponseErrors(&resp, err)...)
+			continue
 		}
 
 		// 检查是否是集合本身
@@ -318,7 +451,8 @@ func (c *Client) SyncCalendar(ctx context.Context, path string, query *SyncQuery
 			// 解析集合属性
 			calendar, err := parseCalendarFromResponse(&resp)
 			if err != nil {
-				return nil, err
+				ret.Errors = append(ret.Errors, newHrefError(p, err))
+				continue
 			}
 			if calendar != nil {
 				ret.Calendar = calendar
@@ -329,7 +463,8 @@ func (c *Client) SyncCalendar(ctx context.Context, path string, query *SyncQuery
 		// 使用响应的实际路径而不是集合路径
 		co, err := decodeCalendarObject(resp, p)
 		if err != nil {
-			return nil, err
+			ret.Errors = append(ret.Errors, newHrefError(p, err))
+			continue
 		}
 
 		// When a start cutoff is provided, only surface items modified at or after that timestamp.
@@ -349,20 +484,30 @@ func (c *Client) SyncCalendar(ctx context.Context, path string, query *SyncQuery
 	}
 
 	if !startCutoff.IsZero() && len(pendingPaths) > 0 {
-		fetchedObjects, err := c.CalendarMultiget(ctx, pendingPaths, &standardCompRequest)
+		result, err := c.CalendarMultigetWithOptions(ctx, pendingPaths, &standardCompRequest, nil)
 		if err != nil {
 			return nil, err
 		}
-		fetchedByPath := make(map[string]*CalendarObject, len(fetchedObjects))
-		for _, fo := range fetchedObjects {
-			if fo == nil {
-				continue
-			}
-			fetchedByPath[fo.Path] = fo
+		fetchedByKey := make(map[string]*CalendarObject, len(result.Objects))
+		for _, fo := range result.Objects {
+			fetchedByKey[c.hrefKey(fo.Path)] = fo
+		}
+		failedByKey := make(map[string]*HrefError, len(result.Errors))
+		for _, hrefErr := range result.Errors {
+			failedByKey[c.hrefKey(hrefErr.Href)] = hrefErr
 		}
 		for _, path := range pendingPaths {
 			co := pendingObjects[path]
-			if fetched, ok := fetchedByPath[path]; ok {
+			if hrefErr, ok := failedByKey[c.hrefKey(path)]; ok {
+				// The object may have been deleted since the sync report
+				if hrefErr.StatusCode == http.StatusNotFound {
+					ret.Deleted = append(ret.Deleted, path)
+				} else {
+					ret.Errors = append(ret.Errors, hrefErr)
+				}
+				continue
+			}
+			if fetched, ok := fetchedByKey[c.hrefKey(path)]; ok {
 				co.Data = fetched.Data
 				if !fetched.ModTime.IsZero() {
 					co.ModTime = fetched.ModTime
@@ -728,172 +873,223 @@ func (c *Client) calendarQueryRangeOnce(ctx context.Context, path string, start,
 // Only non-nil fields in options will be updated. To remove a property,
 // pass an empty string pointer.
 //
-// Returns the updated Calendar object on success.
+// Returns the updated Calendar object on success. If the server didn't apply
+// all updates, an error is returned; use UpdateCalendarWithResult to find out
+// which properties were rejected.
 func (c *Client) UpdateCalendar(ctx context.Context, path string, options *UpdateCalendarOptions) (*Calendar, error) {
+	result, err := c.UpdateCalendarWithResult(ctx, path, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []webdav.Property
+	for _, name := range result.Rejected {
+		failed = append(failed, webdav.Property{Name: name, Status: http.StatusForbidden})
+	}
+	failed = append(failed, result.Failed...)
+	for _, name := range result.FailedDependency {
+		failed = append(failed, webdav.Property{Name: name, Status: http.StatusFailedDependency})
+	}
+	if len(failed) > 0 {
+		prop := failed[0]
+		return nil, fmt.Errorf("caldav: property update failed: property <%v %v>: %w", prop.Name.Space, prop.Name.Local, prop.Err())
+	}
+
+	return result.Calendar, nil
+}
+
+// UpdateCalendarWithResult is like UpdateCalendar, but reports the status of
+// each updated property instead of failing when the server doesn't apply the
+// updates.
+//
+// Servers apply either all updates or none of them: when a property is
+// rejected, the other ones are usually reported as FailedDependency.
+func (c *Client) UpdateCalendarWithResult(ctx context.Context, path string, options *UpdateCalendarOptions) (*UpdateCalendarResult, error) {
 	if options == nil {
 		return nil, fmt.Errorf("caldav: UpdateCalendarOptions cannot be nil")
 	}
 
-	// Build PropertyUpdate request
-	var propertyUpdate internal.PropertyUpdate
-	var hasUpdates bool
-
-	// Prepare Set operations for non-nil properties
-	var setProp internal.Prop
+	var (
+		set    []*webdav.RawXMLValue
+		remove []xml.Name
+	)
+	defaultAlarmDateTime, err := formatDefaultAlarms(options.DefaultAlarmVEventDateTime)
+	if err != nil {
+		return nil, err
+	}
+	defaultAlarmDate, err := formatDefaultAlarms(options.DefaultAlarmVEventDate)
+	if err != nil {
+		return nil, err
+	}
 
-	if options.Name != nil {
-		hasUpdates = true
-		displayName := internal.DisplayName{Name: *options.Name}
-		raw, err := internal.EncodeRawXMLElement(&displayName)
+	// Text properties are removed when set to an empty string
+	textProps := []struct {
+		name   xml.Name
+		value  *string
+		encode func(string) interface{}
+	}{
+		{internal.DisplayNameName, options.Name, func(v string) interface{} {
+			return &internal.DisplayName{Name: v}
+		}},
+		{CalendarDescriptionName, options.Description, func(v string) interface{} {
+			return &calendarDescription{Description: v}
+		}},
+		{CalendarColorName, options.Color, func(v string) interface{} {
+			return &calendarColor{Color: v}
+		}},
+		{CalendarTimezoneName, options.Timezone, func(v string) interface{} {
+			return &calendarTimezone{Timezone: v}
+		}},
+		{DefaultAlarmVEventDateTimeName, defaultAlarmDateTime, func(v string) interface{} {
+			return &defaultAlarmVEventDateTime{Data: v}
+		}},
+		{DefaultAlarmVEventDateName, defaultAlarmDate, func(v string) interface{} {
+			return &defaultAlarmVEventDate{Data: v}
+		}},
+	}
+	for _, prop := range textProps {
+		if prop.value == nil {
+			continue
+		}
+		if *prop.value == "" {
+			remove = append(remove, prop.name)
+			continue
+		}
+		raw, err := internal.EncodeRawXMLElement(prop.encode(*prop.value))
 		if err != nil {
-			return nil, fmt.Errorf("caldav: failed to encode display name: %w", err)
+			return nil, fmt.Errorf("caldav: failed to encode property <%v %v>: %w", prop.name.Space, prop.name.Local, err)
 		}
-		setProp.Raw = append(setProp.Raw, *raw)
+		set = append(set, raw)
 	}
 
-	if options.Description != nil {
-		hasUpdates = true
-		desc := calendarDescription{Description: *options.Description}
-		raw, err := internal.EncodeRawXMLElement(&desc)
+	if options.Order != nil {
+		raw, err := internal.EncodeRawXMLElement(&calendarOrder{Order: strconv.Itoa(*options.Order)})
 		if err != nil {
-			return nil, fmt.Errorf("caldav: failed to encode calendar description: %w", err)
+			return nil, fmt.Errorf("caldav: failed to encode calendar order: %w", err)
 		}
-		setProp.Raw = append(setProp.Raw, *raw)
+		set = append(set, raw)
 	}
 
-	if options.Color != nil {
-		hasUpdates = true
-		color := calendarColor{Color: *options.Color}
-		raw, err := internal.EncodeRawXMLElement(&color)
+	if options.SupportedComponentSet != nil {
+		var compSet supportedCalendarComponentSet
+		for _, name := range options.SupportedComponentSet {
+			compSet.Comp = append(compSet.Comp, comp{Name: name})
+		}
+		raw, err := internal.EncodeRawXMLElement(&compSet)
 		if err != nil {
-			return nil, fmt.Errorf("caldav: failed to encode calendar color: %w", err)
+			return nil, fmt.Errorf("caldav: failed to encode supported component set: %w", err)
 		}
-		setProp.Raw = append(setProp.Raw, *raw)
-	}
-
-	if options.Timezone != nil {
-		hasUpdates = true
-		timezone := calendarTimezone{Timezone: *options.Timezone}
-		raw, err := internal.EncodeRawXMLElement(&timezone)
+		set = append(set, raw)
+	}
+
+	if t := options.ScheduleCalendarTransp; t != nil && *t == "" {
+		remove = append(remove, ScheduleCalendarTranspName)
+	} else if t != nil {
+		var transp scheduleCalendarTransp
+		switch *t {
+		case ScheduleTranspOpaque:
+			transp.Opaque = &struct{}{}
+		case ScheduleTranspTransparent:
+			transp.Transparent = &struct{}{}
+		default:
+			return nil, fmt.Errorf("caldav: invalid schedule-calendar-transp value %q", *t)
+		}
+		raw, err := internal.EncodeRawXMLElement(&transp)
 		if err != nil {
-			return nil, fmt.Errorf("caldav: failed to encode calendar timezone: %w", err)
+			return nil, fmt.Errorf("caldav: failed to encode schedule-calendar-transp: %w", err)
 		}
-		setProp.Raw = append(setProp.Raw, *raw)
+		set = append(set, raw)
 	}
 
-	if !hasUpdates {
+	if len(set) == 0 && len(remove) == 0 {
 		return nil, fmt.Errorf("caldav: no properties to update")
 	}
 
-	// Add Set operation to PropertyUpdate
-	propertyUpdate.Set = append(propertyUpdate.Set, internal.Set{Prop: setProp})
-
-	// Create PROPPATCH request
-	req, err := c.ic.NewXMLRequest("PROPPATCH", path, &propertyUpdate)
-	if err != nil {
-		return nil, fmt.Errorf("caldav: failed to create PROPPATCH request: %w", err)
-	}
-
-	// Execute PROPPATCH request
-	ms, err := c.ic.DoMultiStatus(req.WithContext(ctx))
+	props, err := c.PropPatch(ctx, path, set, remove)
 	if err != nil {
 		return nil, fmt.Errorf("caldav: PROPPATCH request failed: %w", err)
 	}
 
-	// Validate response
-	if len(ms.Responses) != 1 {
-		return nil, fmt.Errorf("caldav: expected 1 response, got %d", len(ms.Responses))
-	}
-
-	resp := &ms.Responses[0]
-	if err = resp.Err(); err != nil {
-		return nil, fmt.Errorf("caldav: property update failed: %w", err)
-	}
-
-	// Check individual property update status
-	for _, propstat := range resp.PropStats {
-		if err = propstat.Status.Err(); err != nil {
-			return nil, fmt.Errorf("caldav: property update failed: %w", err)
+	result := &UpdateCalendarResult{}
+	for _, prop := range props {
+		switch {
+		case prop.Status/100 == 2:
+			result.Applied = append(result.Applied, prop.Name)
+		case prop.Status == http.StatusForbidden:
+			result.Rejected = append(result.Rejected, prop.Name)
+		case prop.Status == http.StatusFailedDependency:
+			result.FailedDependency = append(result.FailedDependency, prop.Name)
+		default:
+			result.Failed = append(result.Failed, prop)
 		}
 	}
 
 	// Fetch updated calendar to return current state
 	// This follows the pattern of other update methods and ensures consistency
-	updatedCalendar, err := c.GetCalendar(ctx, path)
+	result.Calendar, err = c.GetCalendar(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("caldav: failed to fetch updated calendar: %w", err)
 	}
 
-	return updatedCalendar, nil
+	return result, nil
 }
 
 // CalendarMultiget performs a calendar-multiget REPORT request to fetch
-// multiple calendar objects by their paths in a single request.
+// multiple calendar objects by their paths.
 // This is more efficient than making individual GET requests for each object.
 //
 // The paths parameter should contain the full paths to the calendar objects.
 // The comp parameter specifies which calendar components and properties to retrieve.
+//
+// Paths may span several collections and are fetched in chunks, see
+// CalendarMultigetWithOptions. Objects are returned in the order of paths. If
+// any of the objects can't be fetched, the first failure is returned as an
+// error; use CalendarMultigetWithOptions to get partial results instead.
 func (c *Client) CalendarMultiget(ctx context.Context, paths []string, comp *CalendarCompRequest) ([]*CalendarObject, error) {
 	if len(paths) == 0 {
 		return nil, nil
 	}
 
-	// 构建 href 列表
-	hrefs := make([]internal.Href, len(paths))
-	for i, path := range paths {
-		hrefs[i] = internal.Href{Path: path}
-	}
-
-	// 编码日历组件请求
-	propReq, err := encodeCalendarReq(comp)
+	result, err := c.CalendarMultigetWithOptions(ctx, paths, comp, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	// 构建 calendar-multiget 请求
-	multiget := &calendarMultiget{
-		Hrefs: hrefs,
-		Prop:  propReq,
+	if len(result.Errors) > 0 {
+		return nil, result.Errors[0]
 	}
 
-	// 执行 REPORT 请求
-	// 使用第一个路径的父目录作为请求路径
-	basePath := paths[0]
-	if idx := strings.LastIndex(basePath, "/"); idx > 0 {
-		basePath = basePath[:idx+1]
-	}
+	return result.Objects, nil
+}
 
-	depth := internal.DepthOne
-	ms, err := c.ic.ReportDepth(ctx, basePath, &depth, multiget)
+// CalendarQuery performs a calendar-query REPORT request to search for
+// calendar objects that match the specified filter criteria.
+//
+// The path parameter should be the path to a calendar collection.
+// The query parameter specifies the search criteria and which properties to retrieve.
+//
+// If the server reports a failure for any of the matching objects, the first
+// failure is returned as an error; use CalendarQueryWithErrors to get partial
+// results instead.
+func (c *Client) CalendarQuery(ctx context.Context, path string, query *CalendarQueryRequest) ([]CalendarObject, error) {
+	result, err := c.CalendarQueryWithErrors(ctx, path, query)
 	if err != nil {
 		return nil, err
 	}
-
-	// 解析响应
-	objects := make([]*CalendarObject, 0, len(ms.Responses))
-	for _, resp := range ms.Responses {
-		path, err := resp.Path()
-		if err != nil {
-			return nil, err
-		}
-
-		co, err := decodeCalendarObject(resp, path)
-		if err != nil {
-			return nil, err
-		}
-
-		objects = append(objects, co)
+	if len(result.Errors) > 0 {
+		return nil, result.Errors[0]
 	}
 
+	objects := make([]CalendarObject, 0, len(result.Objects))
+	for _, co := range result.Objects {
+		objects = append(objects, *co)
+	}
 	return objects, nil
 }
 
-// CalendarQuery performs a calendar-query REPORT request to search for
-// calendar objects that match the specified filter criteria.
-//
-// The path parameter should be the path to a calendar collection.
-// The query parameter specifies the search criteria and which properties to retrieve.
-func (c *Client) CalendarQuery(ctx context.Context, path string, query *CalendarQueryRequest) ([]CalendarObject, error) {
+// CalendarQueryWithErrors is like CalendarQuery, but failures for individual
+// objects don't abort the query: they're reported in the result's Errors
+// instead, in the order of the server's response.
+func (c *Client) CalendarQueryWithErrors(ctx context.Context, path string, query *CalendarQueryRequest) (*CalendarObjectsResult, error) {
 	// 编码日历组件请求
 	propReq, err := encodeCalendarReq(&query.CompRequest)
 	if err != nil {
@@ -920,22 +1116,26 @@ func (c *Client) CalendarQuery(ctx context.Context, path string, query *Calendar
 	}
 
 	// 解析响应
-	objects := make([]CalendarObject, 0, len(ms.Responses))
+	result := &CalendarObjectsResult{
+		Objects: make([]*CalendarObject, 0, len(ms.Responses)),
+	}
 	for _, resp := range ms.Responses {
 		respPath, err := resp.Path()
 		if err != nil {
-			return nil, err
+			result.Errors = append(result.Errors, newResponseErrors(&resp, err)...)
+			continue
 		}
 
 		co, err := decodeCalendarObject(resp, respPath)
 		if err != nil {
-			return nil, err
+			result.Errors = append(result.Errors, newHrefError(respPath, err))
+			continue
 		}
 
-		objects = append(objects, *co)
+		result.Objects = append(result.Objects, co)
 	}
 
-	return objects, nil
+	return result, nil
 }
 
 // ListCalendarObjects lists all calendar objects in the specified calendar collection.
@@ -1048,8 +1248,9 @@ func (c *Client) SyncCalendarListWithLimit(
 	// 解析响应中的日历信息
 	for _, resp := range ms.Responses {
 		path, err := resp.Path()
-		if err != nil {
-			// 如果路径解析失败，记录错误但继续处理其他响应
+		if len(resp.Hrefs) != 1 {
+			// 路径解析失败，记录错误并继续处理其他响应
+			result.Errors = append(result.Errors, newResponseErrors(&resp, err)...)
 			continue
 		}
 
@@ -1059,20 +1260,21 @@ func (c *Client) SyncCalendarListWithLimit(
 		}
 
 		// 检查响应状态，处理删除的日历
-		if respErr := resp.Err(); respErr != nil {
-			if httpErr, ok := respErr.(*internal.HTTPError); ok && httpErr.Code == http.StatusNotFound {
+		if err != nil {
+			if httpErr, ok := err.(*internal.HTTPError); ok && httpErr.Code == http.StatusNotFound {
 				// 404 状态表示日历已被删除
 				result.DeletedCalendars = append(result.DeletedCalendars, path)
 				continue
 			}
-			// 其他错误继续处理，但记录日志
+			// 其他错误记录下来，继续处理其他响应
+			result.Errors = append(result.Errors, newResponseErrors(&resp, err)...)
 			continue
 		}
 
 		// 解析日历属性
 		calendar, err := parseCalendarFromResponse(&resp)
 		if err != nil {
-			// 解析失败可能是因为资源不是日历，继续处理其他响应
+			result.Errors = append(result.Errors, newHrefError(path, err))
 			continue
 		}
 
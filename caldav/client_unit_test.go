@@ -13,6 +13,8 @@ import (
 	"time"
 
 	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/internal"
 )
 
 // helper to build a client against a test server
@@ -509,3 +511,585 @@ func TestSyncCalendarStartTimeFilter(t *testing.T) {
 		t.Fatalf("unexpected etag, got %s want %s", got, want)
 	}
 }
+
+func TestCalendarMultigetJCal(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed reading request body: %v", err)
+		}
+		if !strings.Contains(string(body), `content-type="application/calendar+json"`) {
+			t.Fatalf("expected calendar-data content-type attribute, got %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/event1.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"etag123"</d:getetag>
+        <cal:calendar-data content-type="application/calendar+json">["vcalendar",[["version",{},"text","2.0"]],[["vevent",[["uid",{},"text","event1"],["dtstart",{},"date","2024-01-02"]],[]]]]</cal:calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	objs, err := c.CalendarMultiget(context.Background(), []string{"/cal/event1.ics"}, &CalendarCompRequest{
+		Name:        "VCALENDAR",
+		ContentType: JCalMIMEType,
+		AllProps:    true,
+		AllComps:    true,
+	})
+	if err != nil {
+		t.Fatalf("CalendarMultiget error: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objs))
+	}
+	if objs[0].ContentType != JCalMIMEType {
+		t.Fatalf("unexpected content type: %q", objs[0].ContentType)
+	}
+
+	cal, err := objs[0].Calendar()
+	if err != nil {
+		t.Fatalf("Calendar() error: %v", err)
+	}
+	events := cal.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if uid, _ := events[0].Props.Text("UID"); uid != "event1" {
+		t.Fatalf("unexpected UID: %q", uid)
+	}
+	if v := events[0].Props.Get("DTSTART"); v.Value != "20240102" || v.Params.Get("VALUE") != "DATE" {
+		t.Fatalf("unexpected DTSTART: %+v", v)
+	}
+
+	if err := objs[0].UnmarshalJCal(objs[0].Data); err != nil {
+		t.Fatalf("UnmarshalJCal() error: %v", err)
+	}
+	if !strings.HasPrefix(string(objs[0].Data), "BEGIN:VCALENDAR\r\n") || objs[0].ContentType != MIMEType {
+		t.Fatalf("expected iCalendar data, got %q (%v)", objs[0].Data, objs[0].ContentType)
+	}
+}
+
+func TestGetCalendarObjectJCal(t *testing.T) {
+	const jcal = `["vcalendar",[["version",{},"text","2.0"]],[["vevent",[["uid",{},"text","event1"]],[]]]]`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Accept"), JCalMIMEType) {
+			w.Header().Set("Content-Type", MIMEType)
+			io.WriteString(w, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+			return
+		}
+		w.Header().Set("Content-Type", JCalMIMEType)
+		io.WriteString(w, jcal)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	result, err := c.GetCalendarObjectWithOptions(ctx, "/cal/event1.ics", &GetCalendarObjectOptions{ContentType: JCalMIMEType})
+	if err != nil {
+		t.Fatalf("GetCalendarObjectWithOptions error: %v", err)
+	}
+	if co := result.Object; co.ContentType != JCalMIMEType || string(co.Data) != jcal {
+		t.Fatalf("unexpected object: %q (%v)", co.Data, co.ContentType)
+	}
+	cal, err := result.Object.Calendar()
+	if err != nil {
+		t.Fatalf("Calendar() error: %v", err)
+	}
+	if uid, _ := cal.Events()[0].Props.Text("UID"); uid != "event1" {
+		t.Errorf("unexpected UID: %q", uid)
+	}
+
+	co, err := c.GetCalendarObject(ctx, "/cal/event1.ics")
+	if err != nil {
+		t.Fatalf("GetCalendarObject error: %v", err)
+	}
+	if co.ContentType != MIMEType {
+		t.Errorf("unexpected content type: %q", co.ContentType)
+	}
+}
+
+func TestGetCalendarSupportedCalendarData(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <cal:supported-calendar-data>
+          <cal:calendar-data content-type="text/calendar" version="2.0"/>
+          <cal:calendar-data content-type="application/calendar+json" version="2.0"/>
+        </cal:supported-calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	cal, err := c.GetCalendar(context.Background(), "/cal/")
+	if err != nil {
+		t.Fatalf("GetCalendar error: %v", err)
+	}
+	if len(cal.SupportedCalendarData) != 2 {
+		t.Fatalf("expected 2 supported calendar data types, got %d", len(cal.SupportedCalendarData))
+	}
+	if !cal.SupportsCalendarData(JCalMIMEType) {
+		t.Fatalf("expected jCal to be supported")
+	}
+	if cal.SupportsCalendarData("application/calendar+xml") {
+		t.Fatalf("expected xCal not to be supported")
+	}
+}
+
+func TestCalendarMultigetXCal(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed reading request body: %v", err)
+		}
+		if !strings.Contains(string(body), `content-type="application/calendar+xml"`) {
+			t.Fatalf("expected calendar-data content-type attribute, got %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/event1.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"etag123"</d:getetag>
+        <cal:calendar-data>
+          <icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+            <vcalendar>
+              <properties><version><text>2.0</text></version></properties>
+              <components>
+                <vevent>
+                  <properties>
+                    <uid><text>event1</text></uid>
+                    <dtstart><date-time>2024-01-02T09:00:00Z</date-time></dtstart>
+                  </properties>
+                </vevent>
+              </components>
+            </vcalendar>
+          </icalendar>
+        </cal:calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	objs, err := c.CalendarMultiget(context.Background(), []string{"/cal/event1.ics"}, &CalendarCompRequest{
+		Name:        "VCALENDAR",
+		ContentType: XCalMIMEType,
+		AllProps:    true,
+		AllComps:    true,
+	})
+	if err != nil {
+		t.Fatalf("CalendarMultiget error: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objs))
+	}
+	if objs[0].ContentType != XCalMIMEType {
+		t.Fatalf("unexpected content type: %q", objs[0].ContentType)
+	}
+
+	cal, err := objs[0].Calendar()
+	if err != nil {
+		t.Fatalf("Calendar() error: %v\n%s", err, objs[0].Data)
+	}
+	events := cal.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if v := events[0].Props.Get("DTSTART"); v.Value != "20240102T090000Z" {
+		t.Fatalf("unexpected DTSTART: %+v", v)
+	}
+}
+
+func TestFindCalendarsQuota(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed reading request body: %v", err)
+		}
+		if !strings.Contains(string(body), "quota-used-bytes") {
+			// Quota wasn't requested
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/home/work/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/home/work/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <d:quota-used-bytes>2048</d:quota-used-bytes>
+        <d:quota-available-bytes>8192</d:quota-available-bytes>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/home/personal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop>
+        <d:quota-used-bytes/>
+        <d:quota-available-bytes/>
+      </d:prop>
+      <d:status>HTTP/1.1 403 Forbidden</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	cals, err := c.FindCalendarsWithOptions(context.Background(), "/home/", &FindCalendarsOptions{Quota: true})
+	if err != nil {
+		t.Fatalf("FindCalendarsWithOptions error: %v", err)
+	}
+	if len(cals) != 2 {
+		t.Fatalf("expected 2 calendars, got %d", len(cals))
+	}
+	if q := cals[0].Quota; q == nil || q.Used != 2048 || q.Available != 8192 {
+		t.Errorf("unexpected quota for %s: %+v", cals[0].Path, q)
+	}
+	if q := cals[1].Quota; q != nil {
+		t.Errorf("expected no quota for %s, got %+v", cals[1].Path, q)
+	}
+
+	cals, err = c.FindCalendars(context.Background(), "/home/")
+	if err != nil {
+		t.Fatalf("FindCalendars error: %v", err)
+	}
+	if len(cals) != 1 || cals[0].Quota != nil {
+		t.Errorf("expected calendars without quota, got %+v", cals)
+	}
+}
+
+func TestUpdateCalendarWithResult(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+
+		switch r.Method {
+		case "PROPPATCH":
+			var update internal.PropertyUpdate
+			if err := xml.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Fatalf("failed to decode PROPPATCH body: %v", err)
+			}
+			if len(update.Remove) != 1 || update.Remove[0].Prop.Get(CalendarDescriptionName) == nil {
+				t.Errorf("expected calendar-description to be removed, got %+v", update.Remove)
+			}
+			if len(update.Set) != 1 {
+				t.Fatalf("expected 1 set element, got %d", len(update.Set))
+			}
+			for _, name := range []xml.Name{internal.DisplayNameName, CalendarOrderName, ScheduleCalendarTranspName, SupportedCalendarComponentSetName} {
+				if update.Set[0].Prop.Get(name) == nil {
+					t.Errorf("expected %v to be set", name)
+				}
+			}
+
+			io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop><cal:supported-calendar-component-set/></d:prop>
+      <d:status>HTTP/1.1 403 Forbidden</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop>
+        <d:displayname/>
+        <cal:calendar-description/>
+        <a:calendar-order/>
+        <cal:schedule-calendar-transp/>
+      </d:prop>
+      <d:status>HTTP/1.1 424 Failed Dependency</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		case "PROPFIND":
+			io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <d:displayname>Work</d:displayname>
+        <a:calendar-order>2</a:calendar-order>
+        <cal:schedule-calendar-transp><cal:transparent/></cal:schedule-calendar-transp>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		default:
+			t.Errorf("unexpected %v request", r.Method)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	name, desc := "Team", ""
+	order := 3
+	transp := ScheduleTranspOpaque
+	options := &UpdateCalendarOptions{
+		Name:                   &name,
+		Description:            &desc,
+		Order:                  &order,
+		ScheduleCalendarTransp: &transp,
+		SupportedComponentSet:  []string{"VEVENT"},
+	}
+
+	result, err := c.UpdateCalendarWithResult(context.Background(), "/cal/", options)
+	if err != nil {
+		t.Fatalf("UpdateCalendarWithResult error: %v", err)
+	}
+	if len(result.Applied) != 0 || len(result.Failed) != 0 {
+		t.Errorf("unexpected applied %v or failed %v properties", result.Applied, result.Failed)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0] != SupportedCalendarComponentSetName {
+		t.Errorf("unexpected rejected properties: %v", result.Rejected)
+	}
+	if len(result.FailedDependency) != 4 {
+		t.Errorf("unexpected failed dependency properties: %v", result.FailedDependency)
+	}
+	if cal := result.Calendar; cal.Order != 2 || cal.ScheduleCalendarTransp != ScheduleTranspTransparent {
+		t.Errorf("unexpected calendar: %+v", cal)
+	}
+
+	_, err = c.UpdateCalendar(context.Background(), "/cal/", options)
+	if webdav.StatusCode(err) != http.StatusForbidden {
+		t.Errorf("expected UpdateCalendar to fail with 403, got %v", err)
+	}
+}
+
+func TestGetCalendarObjectConditional(t *testing.T) {
+	const data = "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("ETag", `"v2"`)
+		io.WriteString(w, data)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	result, err := c.GetCalendarObjectWithOptions(ctx, "/cal/event1.ics", &GetCalendarObjectOptions{IfNoneMatch: "v1"})
+	if err != nil {
+		t.Fatalf("GetCalendarObjectWithOptions error: %v", err)
+	}
+	if !result.NotModified || result.Object.ETag != "v1" || result.Object.Data != nil {
+		t.Errorf("expected not modified result, got %+v", result)
+	}
+
+	result, err = c.GetCalendarObjectWithOptions(ctx, "/cal/event1.ics", &GetCalendarObjectOptions{IfNoneMatch: "v0"})
+	if err != nil {
+		t.Fatalf("GetCalendarObjectWithOptions error: %v", err)
+	}
+	if result.NotModified || result.Object.ETag != "v2" || string(result.Object.Data) != data {
+		t.Errorf("expected modified result, got %+v", result)
+	}
+
+	r, err := c.OpenCalendarObject(ctx, "/cal/event1.ics", nil)
+	if err != nil {
+		t.Fatalf("OpenCalendarObject error: %v", err)
+	}
+	defer r.Close()
+	if r.NotModified || r.Object.ETag != "v2" || r.Object.Path != "/cal/event1.ics" {
+		t.Errorf("unexpected object metadata: %+v", r.Object)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read object: %v", err)
+	}
+	if string(b) != data {
+		t.Errorf("unexpected object data: %q", b)
+	}
+}
+
+func TestDefaultAlarms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+
+		switch r.Method {
+		case "PROPPATCH":
+			var update internal.PropertyUpdate
+			if err := xml.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Fatalf("failed to decode PROPPATCH body: %v", err)
+			}
+			if len(update.Remove) != 1 || update.Remove[0].Prop.Get(DefaultAlarmVEventDateName) == nil {
+				t.Errorf("expected default-alarm-vevent-date to be removed, got %+v", update.Remove)
+			}
+			if len(update.Set) != 1 {
+				t.Fatalf("expected 1 set element, got %d", len(update.Set))
+			}
+			raw := update.Set[0].Prop.Get(DefaultAlarmVEventDateTimeName)
+			if raw == nil {
+				t.Fatalf("expected default-alarm-vevent-datetime to be set")
+			}
+			var alarm defaultAlarmVEventDateTime
+			if err := raw.Decode(&alarm); err != nil {
+				t.Fatalf("failed to decode default-alarm-vevent-datetime: %v", err)
+			}
+			if want := "BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT10M\r\nEND:VALARM\r\n"; alarm.Data != want {
+				t.Errorf("default-alarm-vevent-datetime = %q, want %q", alarm.Data, want)
+			}
+
+			io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <cal:default-alarm-vevent-datetime/>
+        <cal:default-alarm-vevent-date/>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		case "PROPFIND":
+			io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <cal:default-alarm-vevent-datetime>
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT10M
+END:VALARM
+</cal:default-alarm-vevent-datetime>
+        <cal:default-alarm-vevent-date>BEGIN:VCALENDAR
+BEGIN:VALARM
+ACTION:AUDIO
+TRIGGER:-PT15H
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:PT9H
+END:VALARM
+END:VCALENDAR</cal:default-alarm-vevent-date>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		default:
+			t.Errorf("unexpected %v request", r.Method)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	alarm := ical.NewAlarm(ical.ActionDisplay)
+	alarm.SetTrigger(&ical.Trigger{Duration: -10 * time.Minute})
+	cal, err := c.UpdateCalendar(context.Background(), "/cal/", &UpdateCalendarOptions{
+		DefaultAlarmVEventDateTime: &[]ical.Alarm{*alarm},
+		DefaultAlarmVEventDate:     &[]ical.Alarm{},
+	})
+	if err != nil {
+		t.Fatalf("UpdateCalendar error: %v", err)
+	}
+
+	if len(cal.DefaultAlarmVEventDateTime) != 1 {
+		t.Fatalf("expected 1 default alarm for timed events, got %d", len(cal.DefaultAlarmVEventDateTime))
+	}
+	if trigger, err := cal.DefaultAlarmVEventDateTime[0].Trigger(); err != nil || trigger.Duration != -10*time.Minute {
+		t.Errorf("unexpected trigger %+v: %v", trigger, err)
+	}
+	if len(cal.DefaultAlarmVEventDate) != 2 || cal.DefaultAlarmVEventDate[0].Action() != ical.ActionAudio {
+		t.Errorf("unexpected default alarms for all-day events: %+v", cal.DefaultAlarmVEventDate)
+	}
+}
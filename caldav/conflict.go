@@ -0,0 +1,316 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+// ErrPreconditionFailed is matched by errors returned when a conditional
+// request fails with 412 Precondition Failed. It's the same error as
+// webdav.ErrPreconditionFailed.
+var ErrPreconditionFailed = webdav.ErrPreconditionFailed
+
+// ErrMergeConflict is returned by ThreeWayMerge when the local and remote
+// versions both changed the same property in different ways.
+var ErrMergeConflict = errors.New("caldav: merge conflict")
+
+// PreconditionFailedError is returned by PutCalendarObject when the server
+// rejects a conditional request with 412 Precondition Failed, e.g. because
+// the object was modified concurrently. It wraps a
+// *webdav.PreconditionFailedError, whose Path and ETag fields it exposes.
+type PreconditionFailedError struct {
+	*webdav.PreconditionFailedError
+	// Current is the current version of the object. It's only fetched when
+	// PutCalendarObjectOptions.FetchCurrent is set, and is nil if the object
+	// doesn't exist anymore.
+	Current *CalendarObject
+}
+
+func newPreconditionFailedError(path string, err error) *PreconditionFailedError {
+	pfErr := &webdav.PreconditionFailedError{Path: path, Err: err}
+	var httpErr *webdav.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Header != nil {
+		pfErr.ETag = internal.ParseETagHeader(httpErr.Header)
+	}
+	return &PreconditionFailedError{PreconditionFailedError: pfErr}
+}
+
+func (err *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("caldav: precondition failed for %s - resource ETag mismatch or conflict", err.Path)
+}
+
+func (err *PreconditionFailedError) Unwrap() error {
+	return err.PreconditionFailedError
+}
+
+// MergeFunc merges concurrent changes to a calendar object. base is the
+// version the local changes were made against, local is the locally modified
+// version and remote is the current version on the server.
+type MergeFunc func(base, local, remote *ical.Calendar) (*ical.Calendar, error)
+
+// defaultUpdateRetries is the default number of retries of
+// UpdateCalendarObject after a 412 Precondition Failed.
+const defaultUpdateRetries = 3
+
+// UpdateCalendarObjectOptions contains options for UpdateCalendarObject
+type UpdateCalendarObjectOptions struct {
+	// MaxRetries is the maximum number of retries after the server reports a
+	// concurrent modification. Zero means the default of 3.
+	MaxRetries int
+
+	// Merge resolves concurrent modifications. When nil, the mutation is
+	// re-applied to the current version of the object instead, so mutate
+	// must be safe to call several times. ThreeWayMerge can be used here.
+	Merge MergeFunc
+
+	// ScheduleReply is passed to PutCalendarObject.
+	ScheduleReply *bool
+}
+
+// UpdateCalendarObject performs a read-modify-write cycle on a calendar
+// object: it fetches the object, calls mutate on the parsed calendar and
+// writes it back with If-Match. When the object was modified concurrently,
+// the update is retried against the current version of the object, up to
+// opts.MaxRetries times.
+//
+// The returned error wraps ErrPreconditionFailed if the retries were
+// exhausted.
+func (c *Client) UpdateCalendarObject(ctx context.Context, path string, mutate func(*ical.Calendar) error, opts *UpdateCalendarObjectOptions) (*CalendarObject, error) {
+	if opts == nil {
+		opts = new(UpdateCalendarObjectOptions)
+	}
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultUpdateRetries
+	}
+
+	current, err := c.GetCalendarObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := current.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	local, err := applyMutation(base, mutate)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if current.ETag == "" {
+			return nil, fmt.Errorf("caldav: server didn't return an ETag for %s, cannot update it safely", path)
+		}
+
+		var updated CalendarObject
+		if err := updated.SetCalendar(local); err != nil {
+			return nil, err
+		}
+
+		// The current version is only needed if there are retries left
+		co, err := c.PutCalendarObject(ctx, path, bytes.NewReader(updated.Data), &PutCalendarObjectOptions{
+			IfMatch:       current.ETag,
+			ScheduleReply: opts.ScheduleReply,
+			FetchCurrent:  attempt < maxRetries,
+		})
+		if err == nil {
+			co.ContentType = updated.ContentType
+			co.Data = updated.Data
+			return co, nil
+		}
+
+		var pfErr *PreconditionFailedError
+		if !errors.As(err, &pfErr) || attempt >= maxRetries {
+			return nil, err
+		}
+
+		// The object was modified concurrently: continue with the current
+		// version, unless it was deleted in the meantime
+		if pfErr.Current == nil {
+			return nil, err
+		}
+		current = pfErr.Current
+		remote, err := current.Calendar()
+		if err != nil {
+			return nil, err
+		}
+		if opts.Merge != nil {
+			local, err = opts.Merge(base, local, remote)
+		} else {
+			local, err = applyMutation(remote, mutate)
+		}
+		if err != nil {
+			return nil, err
+		}
+		base = remote
+	}
+}
+
+func applyMutation(cal *ical.Calendar, mutate func(*ical.Calendar) error) (*ical.Calendar, error) {
+	mutated := &ical.Calendar{Component: cal.Clone()}
+	if err := mutate(mutated); err != nil {
+		return nil, err
+	}
+	return mutated, nil
+}
+
+// ThreeWayMerge is a MergeFunc merging changes at the property level.
+//
+// Components are matched by name, UID and RECURRENCE-ID (or TZID for
+// VTIMEZONE). Properties are compared by name: a property changed on one side
+// only takes the changed value, a property changed on both sides must be
+// changed identically or ErrMergeConflict is returned. Sub-components such as
+// VALARM are compared as a whole.
+func ThreeWayMerge(base, local, remote *ical.Calendar) (*ical.Calendar, error) {
+	merged, err := mergeComponent(base.Component, local.Component, remote.Component)
+	if err != nil {
+		return nil, err
+	}
+	return &ical.Calendar{Component: merged}, nil
+}
+
+func mergeComponent(base, local, remote *ical.Component) (*ical.Component, error) {
+	merged := ical.NewComponent(local.Name)
+
+	// Properties, in local order followed by properties only known remotely
+	var names []string
+	seen := make(map[string]bool)
+	for _, props := range []ical.Props{local.Props, remote.Props, base.Props} {
+		for _, prop := range props {
+			if !seen[prop.Name] {
+				seen[prop.Name] = true
+				names = append(names, prop.Name)
+			}
+		}
+	}
+	for _, name := range names {
+		b, l, r := base.Props.Values(name), local.Props.Values(name), remote.Props.Values(name)
+		var result []*ical.Prop
+		switch {
+		case propsEqual(l, b):
+			result = r
+		case propsEqual(r, b), propsEqual(r, l):
+			result = l
+		default:
+			return nil, fmt.Errorf("%w: property %v of %v", ErrMergeConflict, name, componentLabel(local))
+		}
+		for _, prop := range result {
+			merged.Props = append(merged.Props, prop.Clone())
+		}
+	}
+
+	// Children
+	baseChildren := indexComponents(base.Children)
+	localChildren := indexComponents(local.Children)
+	remoteChildren := indexComponents(remote.Children)
+
+	var keys []string
+	seen = make(map[string]bool)
+	for _, children := range [][]*ical.Component{local.Children, remote.Children} {
+		for _, child := range children {
+			key := componentKey(child)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, key := range keys {
+		b, l, r := baseChildren[key], localChildren[key], remoteChildren[key]
+		var result *ical.Component
+		switch {
+		case l != nil && r != nil && b != nil && isTopLevelChild(local):
+			child, err := mergeComponent(b, l, r)
+			if err != nil {
+				return nil, err
+			}
+			result = child
+		case componentsEqual(l, b):
+			result = r
+		case componentsEqual(r, b), componentsEqual(r, l):
+			result = l
+		default:
+			c := l
+			if c == nil {
+				c = r
+			}
+			return nil, fmt.Errorf("%w: component %v", ErrMergeConflict, componentLabel(c))
+		}
+		if result != nil {
+			merged.Children = append(merged.Children, result.Clone())
+		}
+	}
+
+	return merged, nil
+}
+
+// isTopLevelChild reports whether the children of comp are merged property by
+// property. Deeper components are compared as a whole.
+func isTopLevelChild(comp *ical.Component) bool {
+	return comp.Name == ical.CompCalendar
+}
+
+func propsEqual(a, b []*ical.Prop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func componentsEqual(a, b *ical.Component) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
+
+func indexComponents(l []*ical.Component) map[string]*ical.Component {
+	m := make(map[string]*ical.Component, len(l))
+	for _, comp := range l {
+		m[componentKey(comp)] = comp
+	}
+	return m
+}
+
+// componentKey identifies a component among its siblings.
+func componentKey(comp *ical.Component) string {
+	switch comp.Name {
+	case ical.CompTimezone:
+		if tzid := comp.Props.Get(ical.PropTimezoneID); tzid != nil {
+			return comp.Name + "\x00" + tzid.Value
+		}
+	case ical.CompEvent, ical.CompToDo, ical.CompJournal, ical.CompFreeBusy:
+		key := comp.Name
+		if uid := comp.Props.Get(ical.PropUID); uid != nil {
+			key += "\x00" + uid.Value
+		}
+		if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil {
+			key += "\x00" + rid.Value
+		}
+		return key
+	}
+
+	// Other components have no identity, fall back to their content
+	var buf bytes.Buffer
+	cal := &ical.Calendar{Component: &ical.Component{Name: ical.CompCalendar, Children: []*ical.Component{comp}}}
+	ical.NewEncoder(&buf).Encode(cal)
+	return buf.String()
+}
+
+func componentLabel(comp *ical.Component) string {
+	if uid := comp.Props.Get(ical.PropUID); uid != nil {
+		return fmt.Sprintf("%v %v", comp.Name, uid.Value)
+	}
+	return comp.Name
+}
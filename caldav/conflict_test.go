@@ -0,0 +1,236 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+const conflictBaseEvent = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:event1\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"DTSTART:20240102T090000Z\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"LOCATION:Room 1\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// objectStore is a minimal server storing a single calendar object.
+type objectStore struct {
+	mu      sync.Mutex
+	data    string
+	version int
+	gets    int
+	puts    int
+	// beforePut is called before each PUT is evaluated, to simulate
+	// concurrent modifications.
+	beforePut func(s *objectStore)
+}
+
+func (s *objectStore) etag() string {
+	return fmt.Sprintf(`"v%d"`, s.version)
+}
+
+func (s *objectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.gets++
+		w.Header().Set("Content-Type", MIMEType)
+		w.Header().Set("ETag", s.etag())
+		io.WriteString(w, s.data)
+	case http.MethodPut:
+		s.puts++
+		if s.beforePut != nil {
+			s.beforePut(s)
+		}
+		if r.Header.Get("If-Match") != s.etag() {
+			w.Header().Set("ETag", s.etag())
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		s.data = string(b)
+		s.version++
+		w.Header().Set("ETag", s.etag())
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func setSummary(summary string) func(*ical.Calendar) error {
+	return func(cal *ical.Calendar) error {
+		cal.Events()[0].Props.SetText(ical.PropSummary, summary)
+		return nil
+	}
+}
+
+func TestUpdateCalendarObjectRetriesMutation(t *testing.T) {
+	store := &objectStore{data: conflictBaseEvent}
+	store.beforePut = func(s *objectStore) {
+		if s.puts == 1 {
+			// Someone else moves the meeting before our first write
+			s.data = strings.Replace(s.data, "LOCATION:Room 1", "LOCATION:Room 2", 1)
+			s.version++
+		}
+	}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	co, err := c.UpdateCalendarObject(context.Background(), "/cal/event1.ics", setSummary("Daily standup"), nil)
+	if err != nil {
+		t.Fatalf("UpdateCalendarObject error: %v", err)
+	}
+	if store.puts != 2 {
+		t.Fatalf("expected 2 PUT requests, got %d", store.puts)
+	}
+	if co.ETag != "v2" {
+		t.Fatalf("unexpected ETag: %q", co.ETag)
+	}
+	if !strings.Contains(store.data, "SUMMARY:Daily standup") || !strings.Contains(store.data, "LOCATION:Room 2") {
+		t.Fatalf("concurrent change was lost:\n%s", store.data)
+	}
+}
+
+func TestUpdateCalendarObjectRetriesExhausted(t *testing.T) {
+	store := &objectStore{data: conflictBaseEvent}
+	store.beforePut = func(s *objectStore) {
+		s.version++
+	}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	_, err = c.UpdateCalendarObject(context.Background(), "/cal/event1.ics", setSummary("x"), &UpdateCalendarObjectOptions{MaxRetries: 2})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+	var pfErr *PreconditionFailedError
+	if !errors.As(err, &pfErr) || pfErr.Path != "/cal/event1.ics" {
+		t.Fatalf("expected *PreconditionFailedError, got %#v", err)
+	}
+	if store.puts != 3 {
+		t.Fatalf("expected 3 PUT requests, got %d", store.puts)
+	}
+	// The current version is only fetched before each retry
+	if store.gets != 3 {
+		t.Fatalf("expected 3 GET requests, got %d", store.gets)
+	}
+}
+
+func TestPutCalendarObjectPreconditionFailed(t *testing.T) {
+	store := &objectStore{data: conflictBaseEvent}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	_, err = c.PutCalendarObject(ctx, "/cal/event1.ics", strings.NewReader(conflictBaseEvent), &PutCalendarObjectOptions{IfNoneMatch: "*"})
+	var pfErr *PreconditionFailedError
+	if !errors.As(err, &pfErr) || !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected *PreconditionFailedError, got %v", err)
+	}
+	if pfErr.ETag != "v0" || pfErr.Current != nil {
+		t.Fatalf("unexpected error fields: ETag %q, Current %v", pfErr.ETag, pfErr.Current)
+	}
+	if store.gets != 0 {
+		t.Fatalf("expected no GET request, got %d", store.gets)
+	}
+	var webdavErr *webdav.PreconditionFailedError
+	if !errors.As(err, &webdavErr) || webdavErr.Path != "/cal/event1.ics" {
+		t.Fatalf("expected *webdav.PreconditionFailedError, got %v", err)
+	}
+
+	_, err = c.PutCalendarObject(ctx, "/cal/event1.ics", strings.NewReader(conflictBaseEvent), &PutCalendarObjectOptions{IfMatch: "v1", FetchCurrent: true})
+	if !errors.As(err, &pfErr) {
+		t.Fatalf("expected *PreconditionFailedError, got %v", err)
+	}
+	if pfErr.Current == nil || pfErr.Current.ETag != "v0" || string(pfErr.Current.Data) != conflictBaseEvent {
+		t.Fatalf("unexpected current object: %+v", pfErr.Current)
+	}
+}
+
+func TestUpdateCalendarObjectMerge(t *testing.T) {
+	store := &objectStore{data: conflictBaseEvent}
+	store.beforePut = func(s *objectStore) {
+		if s.puts == 1 {
+			s.data = strings.Replace(s.data, "LOCATION:Room 1", "LOCATION:Room 2", 1)
+			s.version++
+		}
+	}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	calls := 0
+	mutate := func(cal *ical.Calendar) error {
+		calls++
+		return setSummary("Daily standup")(cal)
+	}
+	_, err = c.UpdateCalendarObject(context.Background(), "/cal/event1.ics", mutate, &UpdateCalendarObjectOptions{Merge: ThreeWayMerge})
+	if err != nil {
+		t.Fatalf("UpdateCalendarObject error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected mutate to be called once, got %d", calls)
+	}
+	if !strings.Contains(store.data, "SUMMARY:Daily standup") || !strings.Contains(store.data, "LOCATION:Room 2") {
+		t.Fatalf("unexpected merged data:\n%s", store.data)
+	}
+}
+
+func TestThreeWayMergeConflict(t *testing.T) {
+	parse := func(s string) *ical.Calendar {
+		cal, err := ical.NewDecoder(strings.NewReader(s)).Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		return cal
+	}
+
+	base := parse(conflictBaseEvent)
+	local := parse(strings.Replace(conflictBaseEvent, "LOCATION:Room 1", "LOCATION:Room 3", 1))
+	remote := parse(strings.Replace(conflictBaseEvent, "LOCATION:Room 1", "LOCATION:Room 2", 1))
+
+	if _, err := ThreeWayMerge(base, local, remote); !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("expected ErrMergeConflict, got %v", err)
+	}
+
+	// Identical changes on both sides don't conflict
+	if _, err := ThreeWayMerge(base, remote, remote); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
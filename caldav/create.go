@@ -0,0 +1,193 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// ErrUIDConflict is matched by errors returned when a calendar object can't be
+// created because the calendar already contains an object with the same UID.
+var ErrUIDConflict = errors.New("caldav: UID conflict")
+
+// UIDConflictError is returned by CreateEvent when the calendar already
+// contains an object with the same UID.
+type UIDConflictError struct {
+	UID string
+	// Href is the path of the existing calendar object, if known.
+	Href string
+	// Err is the underlying HTTP error.
+	Err error
+}
+
+func (err *UIDConflictError) Error() string {
+	if err.Href == "" {
+		return fmt.Sprintf("caldav: a calendar object with UID %q already exists", err.UID)
+	}
+	return fmt.Sprintf("caldav: a calendar object with UID %q already exists at %s", err.UID, err.Href)
+}
+
+func (err *UIDConflictError) Unwrap() error {
+	return err.Err
+}
+
+func (err *UIDConflictError) Is(target error) bool {
+	return target == ErrUIDConflict
+}
+
+// newUIDConflictError builds a UIDConflictError from a no-uid-conflict
+// precondition failure.
+func newUIDConflictError(uid string, err error) *UIDConflictError {
+	uidErr := &UIDConflictError{UID: uid, Err: err}
+	var davErr *webdav.Error
+	if errors.As(err, &davErr) {
+		if raw := davErr.Get(NoUIDConflictName); raw != nil {
+			var cond noUIDConflict
+			if raw.Decode(&cond) == nil {
+				uidErr.Href = cond.Href.Path
+			}
+		}
+	}
+	return uidErr
+}
+
+// maxResourceNameLen is the maximum length of a resource name derived as-is
+// from a UID.
+const maxResourceNameLen = 128
+
+// ResourceNameForUID returns the name of the calendar object resource used to
+// store an object with the specified UID, with the ".ics" extension.
+//
+// UIDs made of letters, digits and "-_.@" are used as-is, other UIDs are
+// hashed so that the name is safe for all servers.
+func ResourceNameForUID(uid string) string {
+	safe := uid != "" && len(uid) <= maxResourceNameLen && uid[0] != '.'
+	for _, r := range uid {
+		if !safe {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == '@':
+		default:
+			safe = false
+		}
+	}
+	if !safe {
+		sum := sha256.Sum256([]byte(uid))
+		uid = hex.EncodeToString(sum[:16])
+	}
+	return uid + ".ics"
+}
+
+// CreateEventOptions contains options for CreateEventWithOptions
+type CreateEventOptions struct {
+	// Calendar contains the properties of the target calendar, used to
+	// validate the object before upload. When nil, they are fetched from the
+	// server.
+	Calendar *Calendar
+}
+
+// CreateEvent stores a new calendar object in the calendar at calendarPath.
+// See CreateEventWithOptions.
+func (c *Client) CreateEvent(ctx context.Context, calendarPath string, event *ical.Calendar) (*CalendarObject, error) {
+	return c.CreateEventWithOptions(ctx, calendarPath, event, nil)
+}
+
+// CreateEventWithOptions stores a new calendar object in the calendar at
+// calendarPath. event usually contains a VEVENT, possibly with overridden
+// instances and VTIMEZONE components.
+//
+// The resource name is derived from the UID with ResourceNameForUID. The
+// object is checked against the calendar's SupportedComponentSet and
+// MaxResourceSize before upload, and is never written over an existing
+// resource. If the server reports another object with the same UID, the
+// returned error is a *UIDConflictError.
+//
+// The returned object's Path follows the Location header sent by the server,
+// if any.
+func (c *Client) CreateEventWithOptions(ctx context.Context, calendarPath string, event *ical.Calendar, opts *CreateEventOptions) (*CalendarObject, error) {
+	if opts == nil {
+		opts = new(CreateEventOptions)
+	}
+
+	compType, uid, err := calendarObjectIdentity(event)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := opts.Calendar
+	if cal == nil {
+		cal, err = c.GetCalendar(ctx, calendarPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !cal.SupportsComponent(compType) {
+		return nil, fmt.Errorf("caldav: calendar %s doesn't support %s components", calendarPath, compType)
+	}
+
+	var obj CalendarObject
+	if err := obj.SetCalendar(event); err != nil {
+		return nil, err
+	}
+	if cal.MaxResourceSize > 0 && int64(len(obj.Data)) > cal.MaxResourceSize {
+		return nil, fmt.Errorf("caldav: calendar object is %d bytes, calendar %s accepts at most %d bytes", len(obj.Data), calendarPath, cal.MaxResourceSize)
+	}
+
+	p := path.Join(calendarPath, ResourceNameForUID(uid))
+	co, err := c.PutCalendarObject(ctx, p, bytes.NewReader(obj.Data), &PutCalendarObjectOptions{
+		IfNoneMatch: "*",
+	})
+	if IsUIDConflict(err) {
+		return nil, newUIDConflictError(uid, err)
+	} else if errors.Is(err, ErrPreconditionFailed) {
+		// The resource name is taken, and it's derived from the UID
+		return nil, &UIDConflictError{UID: uid, Href: p, Err: err}
+	} else if err != nil {
+		return nil, err
+	}
+	co.ContentType = obj.ContentType
+	co.Data = obj.Data
+	return co, nil
+}
+
+// calendarObjectIdentity returns the component type and UID of a calendar
+// object resource, checking that they are consistent as required by RFC 4791
+// section 4.1.
+func calendarObjectIdentity(cal *ical.Calendar) (compType, uid string, err error) {
+	for _, child := range cal.Children {
+		if child.Name == ical.CompTimezone {
+			continue
+		}
+		if compType == "" {
+			compType = child.Name
+		} else if child.Name != compType {
+			return "", "", fmt.Errorf("caldav: calendar object contains both %s and %s components", compType, child.Name)
+		}
+
+		childUID, err := child.Props.Text(ical.PropUID)
+		if err != nil {
+			return "", "", err
+		}
+		if childUID == "" {
+			return "", "", fmt.Errorf("caldav: %s component has no UID", child.Name)
+		}
+		if uid == "" {
+			uid = childUID
+		} else if childUID != uid {
+			return "", "", fmt.Errorf("caldav: calendar object contains several UIDs: %q and %q", uid, childUID)
+		}
+	}
+	if compType == "" {
+		return "", "", fmt.Errorf("caldav: calendar object has no component")
+	}
+	return compType, uid, nil
+}
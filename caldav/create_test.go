@@ -0,0 +1,134 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+func TestResourceNameForUID(t *testing.T) {
+	if got := ResourceNameForUID("abc-123@example.com"); got != "abc-123@example.com.ics" {
+		t.Errorf("got %q for a safe UID", got)
+	}
+	for _, uid := range []string{"a/b", "a b", ".hidden", "", strings.Repeat("a", 200)} {
+		got := ResourceNameForUID(uid)
+		if len(got) != 32+len(".ics") || !strings.HasSuffix(got, ".ics") {
+			t.Errorf("ResourceNameForUID(%q) = %q, want a hashed name", uid, got)
+		}
+		if again := ResourceNameForUID(uid); again != got {
+			t.Errorf("ResourceNameForUID(%q) isn't stable: %q, %q", uid, got, again)
+		}
+	}
+}
+
+func newTestEvent(uid string) *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, "-//example//test//EN")
+	event := ical.NewEvent()
+	event.Props.SetText(ical.PropUID, uid)
+	event.Props.SetText(ical.PropDateTimeStamp, "20240101T000000Z")
+	event.Props.SetText(ical.PropDateTimeStart, "20240102T100000Z")
+	event.Props.SetText(ical.PropSummary, "Test")
+	cal.Children = append(cal.Children, event.Component)
+	return cal
+}
+
+func TestCreateEvent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PROPFIND":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <cal:supported-calendar-component-set><cal:comp name="VEVENT"/></cal:supported-calendar-component-set>
+        <cal:max-resource-size>1000</cal:max-resource-size>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`))
+		case r.Method == http.MethodPut && r.URL.Path == "/cal/new@example.com.ics":
+			if got := r.Header.Get("If-None-Match"); got != "*" {
+				t.Errorf("got If-None-Match %q", got)
+			}
+			w.Header().Set("Location", "/cal/server-name.ics")
+			w.Header().Set("ETag", `"1"`)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/cal/dup@example.com.ics":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<d:error xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav"><cal:no-uid-conflict><d:href>/cal/other.ics</d:href></cal:no-uid-conflict></d:error>`))
+		case r.Method == http.MethodPut && r.URL.Path == "/cal/taken@example.com.ics":
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	co, err := c.CreateEvent(ctx, "/cal/", newTestEvent("new@example.com"))
+	if err != nil {
+		t.Fatalf("CreateEvent error: %v", err)
+	}
+	if co.Path != "/cal/server-name.ics" || co.ETag != "1" || len(co.Data) == 0 {
+		t.Errorf("unexpected object: %+v", co)
+	}
+
+	_, err = c.CreateEvent(ctx, "/cal/", newTestEvent("dup@example.com"))
+	var uidErr *UIDConflictError
+	if !errors.As(err, &uidErr) || !errors.Is(err, ErrUIDConflict) {
+		t.Fatalf("expected UID conflict, got %v", err)
+	}
+	if uidErr.Href != "/cal/other.ics" || uidErr.UID != "dup@example.com" {
+		t.Errorf("unexpected error: %+v", uidErr)
+	}
+
+	_, err = c.CreateEvent(ctx, "/cal/", newTestEvent("taken@example.com"))
+	if !errors.As(err, &uidErr) || uidErr.Href != "/cal/taken@example.com.ics" || !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("expected UID conflict for existing resource, got %v", err)
+	}
+	if !IsUIDConflict(err) {
+		t.Errorf("IsUIDConflict(%v) = false", err)
+	}
+
+	todo := newTestEvent("todo@example.com")
+	todo.Children[0].Name = ical.CompToDo
+	if _, err := c.CreateEvent(ctx, "/cal/", todo); err == nil {
+		t.Errorf("expected unsupported component error")
+	}
+
+	large := newTestEvent("large@example.com")
+	large.Children[0].Props.SetText(ical.PropDescription, strings.Repeat("x", 2000))
+	if _, err := c.CreateEvent(ctx, "/cal/", large); err == nil {
+		t.Errorf("expected resource size error")
+	}
+
+	mixed := newTestEvent("mixed@example.com")
+	other := ical.NewEvent()
+	other.Props.SetText(ical.PropUID, "other@example.com")
+	mixed.Children = append(mixed.Children, other.Component)
+	if _, err := c.CreateEvent(ctx, "/cal/", mixed); err == nil {
+		t.Errorf("expected UID mismatch error")
+	}
+}
@@ -0,0 +1,325 @@
+package caldav
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// ChangeType is the kind of a change reported by DiffCalendars.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// ComponentChange describes a change to a component.
+//
+// Components are matched by name, UID and RECURRENCE-ID, so changes to an
+// overridden occurrence of a recurring event are reported separately from
+// changes to the master component. Components without identity, such as
+// VALARM, are matched by content, then by position.
+type ComponentChange struct {
+	Type ChangeType
+	Name string
+	UID  string
+	// RecurrenceID is the raw RECURRENCE-ID value of an overridden
+	// occurrence, empty for the master component.
+	RecurrenceID string
+	// Old and New are the old and new versions of the component. Old is nil
+	// if it was added, New is nil if it was removed.
+	Old, New *ical.Component
+	// Props and Children describe the changes to a modified component.
+	Props    []PropChange
+	Children []ComponentChange
+}
+
+// Prop returns the first change to the property with the specified name, or
+// nil.
+func (change *ComponentChange) Prop(name string) *PropChange {
+	name = strings.ToUpper(name)
+	for i := range change.Props {
+		if change.Props[i].Name == name {
+			return &change.Props[i]
+		}
+	}
+	return nil
+}
+
+// TimeChanged reports whether the date, duration or recurrence of the
+// component changed.
+func (change *ComponentChange) TimeChanged() bool {
+	for _, name := range []string{
+		ical.PropDateTimeStart,
+		ical.PropDateTimeEnd,
+		ical.PropDuration,
+		ical.PropDue,
+		ical.PropRecurrenceRule,
+		ical.PropRecurrenceDates,
+		ical.PropExceptionDates,
+	} {
+		if change.Prop(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancelled reports whether the component, typically an occurrence, was
+// cancelled: its STATUS became CANCELLED.
+func (change *ComponentChange) Cancelled() bool {
+	if change.New == nil {
+		return false
+	}
+	status, _ := change.New.Props.Text(ical.PropStatus)
+	if !strings.EqualFold(status, "CANCELLED") {
+		return false
+	}
+	if change.Old == nil {
+		return true
+	}
+	oldStatus, _ := change.Old.Props.Text(ical.PropStatus)
+	return !strings.EqualFold(oldStatus, "CANCELLED")
+}
+
+// PropChange describes a change to a property.
+//
+// Properties which can appear several times, such as ATTENDEE or EXDATE, are
+// matched by value: a new value is reported as an added property, and a
+// parameter change (e.g. an attendee's PARTSTAT) as a modified property.
+type PropChange struct {
+	Type ChangeType
+	Name string
+	// Old and New are the old and new versions of the property. Old is nil
+	// if it was added, New is nil if it was removed.
+	Old, New *ical.Prop
+	// Params describes the parameter changes of a modified property.
+	Params []ParamChange
+}
+
+// ParamChange describes a change to a property parameter.
+type ParamChange struct {
+	Type     ChangeType
+	Name     string
+	Old, New []string
+}
+
+// DiffCalendarObjects parses two versions of a calendar object and compares
+// them with DiffCalendars.
+func DiffCalendarObjects(old, new *CalendarObject) ([]ComponentChange, error) {
+	oldCal, err := old.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	newCal, err := new.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	return DiffCalendars(oldCal, newCal), nil
+}
+
+// DiffCalendars returns the changes between two versions of a calendar
+// object. It returns nil if they're equal.
+//
+// Changes to the VCALENDAR properties themselves are ignored.
+func DiffCalendars(old, new *ical.Calendar) []ComponentChange {
+	return diffChildren(old.Children, new.Children)
+}
+
+func diffChildren(old, new []*ical.Component) []ComponentChange {
+	var changes []ComponentChange
+	matched := make(map[*ical.Component]bool)
+
+	newByKey := make(map[string][]*ical.Component)
+	for _, comp := range new {
+		key := componentKey(comp)
+		newByKey[key] = append(newByKey[key], comp)
+	}
+
+	// Match components by key. Identity-less components are keyed by their
+	// content, so unmatched ones are paired by position afterwards.
+	var unmatchedOld []*ical.Component
+	for _, o := range old {
+		key := componentKey(o)
+		if l := newByKey[key]; len(l) > 0 {
+			n := l[0]
+			newByKey[key] = l[1:]
+			matched[n] = true
+			if change := diffComponent(o, n); change != nil {
+				changes = append(changes, *change)
+			}
+		} else {
+			unmatchedOld = append(unmatchedOld, o)
+		}
+	}
+	var unmatchedNew []*ical.Component
+	for _, n := range new {
+		if !matched[n] {
+			unmatchedNew = append(unmatchedNew, n)
+		}
+	}
+
+	for _, o := range unmatchedOld {
+		var n *ical.Component
+		if !hasIdentity(o) {
+			for i, candidate := range unmatchedNew {
+				if candidate.Name == o.Name && !hasIdentity(candidate) {
+					n = candidate
+					unmatchedNew = append(unmatchedNew[:i], unmatchedNew[i+1:]...)
+					break
+				}
+			}
+		}
+		if n != nil {
+			if change := diffComponent(o, n); change != nil {
+				changes = append(changes, *change)
+			}
+		} else {
+			changes = append(changes, newComponentChange(ChangeRemoved, o, nil))
+		}
+	}
+	for _, n := range unmatchedNew {
+		changes = append(changes, newComponentChange(ChangeAdded, nil, n))
+	}
+	return changes
+}
+
+// hasIdentity reports whether componentKey identifies the component by
+// something else than its content.
+func hasIdentity(comp *ical.Component) bool {
+	switch comp.Name {
+	case ical.CompTimezone:
+		return comp.Props.Get(ical.PropTimezoneID) != nil
+	case ical.CompEvent, ical.CompToDo, ical.CompJournal, ical.CompFreeBusy:
+		return true
+	}
+	return false
+}
+
+func newComponentChange(t ChangeType, old, new *ical.Component) ComponentChange {
+	comp := new
+	if comp == nil {
+		comp = old
+	}
+	change := ComponentChange{Type: t, Name: comp.Name, Old: old, New: new}
+	change.UID, _ = comp.Props.Text(ical.PropUID)
+	if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil {
+		change.RecurrenceID = rid.Value
+	}
+	return change
+}
+
+func diffComponent(old, new *ical.Component) *ComponentChange {
+	change := newComponentChange(ChangeModified, old, new)
+	change.Props = diffProps(old.Props, new.Props)
+	change.Children = diffChildren(old.Children, new.Children)
+	if len(change.Props) == 0 && len(change.Children) == 0 {
+		return nil
+	}
+	return &change
+}
+
+// multiValuedProps are properties which are compared by value even if they
+// appear once in each version.
+var multiValuedProps = map[string]bool{
+	ical.PropAttendee: true,
+}
+
+func diffProps(old, new ical.Props) []PropChange {
+	var names []string
+	seen := make(map[string]bool)
+	for _, props := range []ical.Props{old, new} {
+		for _, prop := range props {
+			if !seen[prop.Name] {
+				seen[prop.Name] = true
+				names = append(names, prop.Name)
+			}
+		}
+	}
+
+	var changes []PropChange
+	for _, name := range names {
+		o, n := old.Values(name), new.Values(name)
+		if len(o) == 1 && len(n) == 1 && !multiValuedProps[name] {
+			if change := diffProp(o[0], n[0]); change != nil {
+				changes = append(changes, *change)
+			}
+			continue
+		}
+
+		matched := make(map[*ical.Prop]bool)
+		for _, op := range o {
+			var np *ical.Prop
+			for _, candidate := range n {
+				if !matched[candidate] && propValueKey(candidate) == propValueKey(op) {
+					np = candidate
+					break
+				}
+			}
+			if np == nil {
+				changes = append(changes, PropChange{Type: ChangeRemoved, Name: name, Old: op})
+				continue
+			}
+			matched[np] = true
+			if change := diffProp(op, np); change != nil {
+				changes = append(changes, *change)
+			}
+		}
+		for _, np := range n {
+			if !matched[np] {
+				changes = append(changes, PropChange{Type: ChangeAdded, Name: name, New: np})
+			}
+		}
+	}
+	return changes
+}
+
+// propValueKey returns the value used to match multi-valued properties.
+// Calendar user addresses are case-insensitive.
+func propValueKey(prop *ical.Prop) string {
+	switch prop.Name {
+	case ical.PropAttendee, ical.PropOrganizer:
+		return strings.ToLower(strings.TrimSpace(prop.Value))
+	}
+	return prop.Value
+}
+
+func diffProp(old, new *ical.Prop) *PropChange {
+	params := diffParams(old.Params, new.Params)
+	if old.Value == new.Value && len(params) == 0 {
+		return nil
+	}
+	return &PropChange{Type: ChangeModified, Name: old.Name, Old: old, New: new, Params: params}
+}
+
+func diffParams(old, new ical.Params) []ParamChange {
+	var names []string
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []ParamChange
+	for _, name := range names {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		switch {
+		case !inOld:
+			changes = append(changes, ParamChange{Type: ChangeAdded, Name: name, New: n})
+		case !inNew:
+			changes = append(changes, ParamChange{Type: ChangeRemoved, Name: name, Old: o})
+		case !slices.Equal(o, n):
+			changes = append(changes, ParamChange{Type: ChangeModified, Name: name, Old: o, New: n})
+		}
+	}
+	return changes
+}
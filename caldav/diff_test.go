@@ -0,0 +1,119 @@
+package caldav
+
+import (
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+func TestDiffCalendars(t *testing.T) {
+	old := decodeTestCalendar(t, `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:e
+DTSTART:20240102T100000Z
+DTEND:20240102T110000Z
+RRULE:FREQ=DAILY;COUNT=5
+LOCATION:Room 1
+ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:a@example.com
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+RECURRENCE-ID:20240103T100000Z
+DTSTART:20240103T120000Z
+DTEND:20240103T130000Z
+END:VEVENT
+END:VCALENDAR
+`)
+	new := decodeTestCalendar(t, `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:e
+DTSTART:20240102T100000Z
+DTEND:20240102T110000Z
+RRULE:FREQ=DAILY;COUNT=5
+LOCATION:Room 2
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:A@example.com
+ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:b@example.com
+EXDATE:20240105T100000Z
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT30M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+RECURRENCE-ID:20240103T100000Z
+DTSTART:20240103T140000Z
+DTEND:20240103T150000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+RECURRENCE-ID:20240104T100000Z
+DTSTART:20240104T100000Z
+DTEND:20240104T110000Z
+STATUS:CANCELLED
+END:VEVENT
+END:VCALENDAR
+`)
+
+	if changes := DiffCalendars(old, old); changes != nil {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	changes := DiffCalendars(old, new)
+	if len(changes) != 3 {
+		t.Fatalf("got %d component changes, want 3: %+v", len(changes), changes)
+	}
+
+	master := changes[0]
+	if master.Type != ChangeModified || master.UID != "e" || master.RecurrenceID != "" {
+		t.Errorf("unexpected master change: %+v", master)
+	}
+	if master.TimeChanged() != true {
+		t.Errorf("EXDATE change should count as a time change")
+	}
+	if loc := master.Prop(ical.PropLocation); loc == nil || loc.Type != ChangeModified || loc.New.Value != "Room 2" {
+		t.Errorf("unexpected LOCATION change: %+v", loc)
+	}
+	var attendees []PropChange
+	for _, change := range master.Props {
+		if change.Name == ical.PropAttendee {
+			attendees = append(attendees, change)
+		}
+	}
+	if len(attendees) != 2 {
+		t.Fatalf("got %d attendee changes, want 2: %+v", len(attendees), attendees)
+	}
+	if a := attendees[0]; a.Type != ChangeModified || len(a.Params) != 1 || a.Params[0].Name != ical.ParamParticipationStatus || a.Params[0].New[0] != "ACCEPTED" {
+		t.Errorf("unexpected PARTSTAT change: %+v", a)
+	}
+	if b := attendees[1]; b.Type != ChangeAdded || b.New.Value != "mailto:b@example.com" {
+		t.Errorf("unexpected attendee addition: %+v", b)
+	}
+	if exdate := master.Prop(ical.PropExceptionDates); exdate == nil || exdate.Type != ChangeAdded {
+		t.Errorf("unexpected EXDATE change: %+v", exdate)
+	}
+	if len(master.Children) != 1 || master.Children[0].Type != ChangeModified || master.Children[0].Prop(ical.PropTrigger) == nil {
+		t.Errorf("unexpected alarm changes: %+v", master.Children)
+	}
+
+	moved := changes[1]
+	if moved.Type != ChangeModified || moved.RecurrenceID != "20240103T100000Z" || !moved.TimeChanged() || moved.Cancelled() {
+		t.Errorf("unexpected override change: %+v", moved)
+	}
+
+	cancelled := changes[2]
+	if cancelled.Type != ChangeAdded || cancelled.RecurrenceID != "20240104T100000Z" || !cancelled.Cancelled() {
+		t.Errorf("unexpected cancelled occurrence: %+v", cancelled)
+	}
+
+	removed := DiffCalendars(new, old)
+	if len(removed) != 3 || removed[2].Type != ChangeRemoved || removed[2].Old == nil || removed[2].New != nil {
+		t.Errorf("unexpected reverse changes: %+v", removed)
+	}
+}
@@ -11,7 +11,9 @@ import (
 const namespace = "urn:ietf:params:xml:ns:caldav"
 
 const (
-	appleNamespace = "http://apple.com/ns/ical/"
+	appleNamespace          = "http://apple.com/ns/ical/"
+	calendarServerNamespace = "http://calendarserver.org/ns/"
+	pushNamespace           = "https://bitfire.at/webdav-push"
 )
 
 var (
@@ -26,18 +28,59 @@ var (
 	CalendarMultigetName              = xml.Name{namespace, "calendar-multiget"}
 	CalendarName                      = xml.Name{namespace, "calendar"}
 	CalendarDataName                  = xml.Name{namespace, "calendar-data"}
+	CalendarOrderName                 = xml.Name{appleNamespace, "calendar-order"}
+	ScheduleCalendarTranspName        = xml.Name{namespace, "schedule-calendar-transp"}
+	DefaultAlarmVEventDateTimeName    = xml.Name{namespace, "default-alarm-vevent-datetime"}
+	DefaultAlarmVEventDateName        = xml.Name{namespace, "default-alarm-vevent-date"}
+	ManagedAttachmentsServerURLName   = xml.Name{namespace, "managed-attachments-server-URL"}
+	MaxAttachmentSizeName             = xml.Name{namespace, "max-attachment-size"}
+	MaxAttachmentsPerResourceName     = xml.Name{namespace, "max-attachments-per-resource"}
+
+	CalendarServerGetCTagName        = xml.Name{calendarServerNamespace, "getctag"}
+	CalendarServerPushTransportsName = xml.Name{calendarServerNamespace, "push-transports"}
+	CalendarServerPushKeyName        = xml.Name{calendarServerNamespace, "pushkey"}
+	PushTransportsName               = xml.Name{pushNamespace, "transports"}
+	PushTopicName                    = xml.Name{pushNamespace, "topic"}
+	PushRegisterName                 = xml.Name{pushNamespace, "push-register"}
+	PushMessageName                  = xml.Name{pushNamespace, "push-message"}
 )
 
-var calendarPropFind = internal.NewPropNamePropFind(
+var calendarPropNames = []xml.Name{
 	internal.ResourceTypeName,
 	internal.DisplayNameName,
 	CalendarDescriptionName,
 	MaxResourceSizeName,
 	SupportedCalendarComponentSetName,
+	SupportedCalendarDataName,
 	CalendarColorName,
+	CalendarOrderName,
 	CalendarTimezoneName,
+	ScheduleCalendarTranspName,
+	DefaultAlarmVEventDateTimeName,
+	DefaultAlarmVEventDateName,
 	internal.SyncTokenName,
+	CalendarServerGetCTagName,
 	internal.CurrentUserPrivilegeSetName,
+	PushTransportsName,
+	PushTopicName,
+	CalendarServerPushKeyName,
+}
+
+var calendarPropFind = internal.NewPropNamePropFind(calendarPropNames...)
+
+// calendarQuotaPropFind is like calendarPropFind, but also requests the quota
+// properties, which some servers are slow to compute.
+var calendarQuotaPropFind = internal.NewPropNamePropFind(append(
+	calendarPropNames[:len(calendarPropNames):len(calendarPropNames)],
+	internal.QuotaUsedBytesName,
+	internal.QuotaAvailableBytesName,
+)...)
+
+var calendarHomePropFind = internal.NewPropNamePropFind(
+	ManagedAttachmentsServerURLName,
+	MaxAttachmentSizeName,
+	MaxAttachmentsPerResourceName,
+	CalendarServerPushTransportsName,
 )
 
 // https://tools.ietf.org/html/rfc4791#section-6.2.1
@@ -62,6 +105,19 @@ type supportedCalendarComponentSet struct {
 	Comp    []comp   `xml:"comp"`
 }
 
+// https://tools.ietf.org/html/rfc4791#section-5.2.4
+type supportedCalendarData struct {
+	XMLName xml.Name           `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-data"`
+	Types   []calendarDataType `xml:"calendar-data"`
+}
+
+// https://tools.ietf.org/html/rfc4791#section-9.6
+type calendarDataType struct {
+	XMLName     xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	ContentType string   `xml:"content-type,attr,omitempty"`
+	Version     string   `xml:"version,attr,omitempty"`
+}
+
 // https://tools.ietf.org/html/rfc4791#section-5.2.5
 type maxResourceSize struct {
 	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav max-resource-size"`
@@ -80,6 +136,55 @@ type calendarColor struct {
 	Color   string   `xml:",chardata"`
 }
 
+// http://apple.com/ns/ical/ calendar-order extension
+type calendarOrder struct {
+	XMLName xml.Name `xml:"http://apple.com/ns/ical/ calendar-order"`
+	Order   string   `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/rfc6638#section-9.1
+type scheduleCalendarTransp struct {
+	XMLName     xml.Name  `xml:"urn:ietf:params:xml:ns:caldav schedule-calendar-transp"`
+	Opaque      *struct{} `xml:"opaque,omitempty"`
+	Transparent *struct{} `xml:"transparent,omitempty"`
+}
+
+// https://tools.ietf.org/html/draft-daboo-valarm-extensions-04#section-9
+type defaultAlarmVEventDateTime struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav default-alarm-vevent-datetime"`
+	Data    string   `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/draft-daboo-valarm-extensions-04#section-9
+type defaultAlarmVEventDate struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav default-alarm-vevent-date"`
+	Data    string   `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/rfc4791#section-5.3.2.1
+type noUIDConflict struct {
+	XMLName xml.Name      `xml:"urn:ietf:params:xml:ns:caldav no-uid-conflict"`
+	Href    internal.Href `xml:"DAV: href"`
+}
+
+// https://tools.ietf.org/html/rfc8607#section-6.1
+type managedAttachmentsServerURL struct {
+	XMLName xml.Name       `xml:"urn:ietf:params:xml:ns:caldav managed-attachments-server-URL"`
+	Href    *internal.Href `xml:"DAV: href,omitempty"`
+}
+
+// https://tools.ietf.org/html/rfc8607#section-6.2
+type maxAttachmentSize struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav max-attachment-size"`
+	Size    int64    `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/rfc8607#section-6.3
+type maxAttachmentsPerResource struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav max-attachments-per-resource"`
+	Max     int64    `xml:",chardata"`
+}
+
 // https://tools.ietf.org/html/rfc4791#section-9.5
 type calendarQuery struct {
 	XMLName  xml.Name       `xml:"urn:ietf:params:xml:ns:caldav calendar-query"`
@@ -190,9 +295,11 @@ func (t *dateWithUTCTime) MarshalText() ([]byte, error) {
 
 // Request variant of https://tools.ietf.org/html/rfc4791#section-9.6
 type calendarDataReq struct {
-	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
-	Comp    *comp    `xml:"comp,omitempty"`
-	Expand  *expand  `xml:"expand,omitempty"`
+	XMLName     xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	ContentType string   `xml:"content-type,attr,omitempty"`
+	Version     string   `xml:"version,attr,omitempty"`
+	Comp        *comp    `xml:"comp,omitempty"`
+	Expand      *expand  `xml:"expand,omitempty"`
 	// TODO: limit-recurrence-set, limit-freebusy-set
 }
 
@@ -223,8 +330,12 @@ type prop struct {
 
 // Response variant of https://tools.ietf.org/html/rfc4791#section-9.6
 type calendarDataResp struct {
-	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
-	Data    []byte   `xml:",chardata"`
+	XMLName     xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	ContentType string   `xml:"content-type,attr,omitempty"`
+	Data        []byte   `xml:",chardata"`
+	// XML holds the calendar data when it's returned as XML elements
+	// rather than text, e.g. xCal
+	XML []internal.RawXMLValue `xml:",any"`
 }
 
 type reportReq struct {
@@ -248,3 +359,90 @@ func (r *reportReq) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 	return d.DecodeElement(v, &start)
 }
+
+// https://github.com/bitfireAT/webdav-push/blob/main/content.mkd#transports
+type pushTransports struct {
+	XMLName xml.Name          `xml:"https://bitfire.at/webdav-push transports"`
+	WebPush *webPushTransport `xml:"https://bitfire.at/webdav-push web-push,omitempty"`
+}
+
+type webPushTransport struct {
+	VAPIDPublicKey *vapidPublicKey `xml:"https://bitfire.at/webdav-push vapid-public-key,omitempty"`
+}
+
+type vapidPublicKey struct {
+	Type string `xml:"type,attr,omitempty"`
+	Key  string `xml:",chardata"`
+}
+
+// https://github.com/bitfireAT/webdav-push/blob/main/content.mkd#topic
+type pushTopic struct {
+	XMLName xml.Name `xml:"https://bitfire.at/webdav-push topic"`
+	Topic   string   `xml:",chardata"`
+}
+
+// https://github.com/bitfireAT/webdav-push/blob/main/content.mkd#subscription-registration
+type pushRegister struct {
+	XMLName      xml.Name         `xml:"https://bitfire.at/webdav-push push-register"`
+	Subscription pushSubscription `xml:"https://bitfire.at/webdav-push subscription"`
+	Expires      string           `xml:"https://bitfire.at/webdav-push expires,omitempty"`
+}
+
+type pushSubscription struct {
+	WebPush *webPushSubscription `xml:"https://bitfire.at/webdav-push web-push-subscription,omitempty"`
+}
+
+type webPushSubscription struct {
+	PushResource          string                 `xml:"https://bitfire.at/webdav-push push-resource"`
+	SubscriptionPublicKey *subscriptionPublicKey `xml:"https://bitfire.at/webdav-push subscription-public-key,omitempty"`
+	AuthSecret            string                 `xml:"https://bitfire.at/webdav-push auth-secret,omitempty"`
+}
+
+type subscriptionPublicKey struct {
+	Type string `xml:"type,attr,omitempty"`
+	Key  string `xml:",chardata"`
+}
+
+// https://github.com/bitfireAT/webdav-push/blob/main/content.mkd#push-message
+type pushMessage struct {
+	XMLName        xml.Name           `xml:"https://bitfire.at/webdav-push push-message"`
+	Topic          string             `xml:"https://bitfire.at/webdav-push topic"`
+	ContentUpdate  *pushContentUpdate `xml:"https://bitfire.at/webdav-push content-update,omitempty"`
+	PropertyUpdate *struct{}          `xml:"https://bitfire.at/webdav-push property-update,omitempty"`
+}
+
+type pushContentUpdate struct {
+	SyncToken string `xml:"DAV: sync-token,omitempty"`
+}
+
+// http://calendarserver.org/ns/ push-transports extension
+type calendarServerPushTransports struct {
+	XMLName    xml.Name                      `xml:"http://calendarserver.org/ns/ push-transports"`
+	Transports []calendarServerPushTransport `xml:"http://calendarserver.org/ns/ transport"`
+}
+
+type calendarServerPushTransport struct {
+	Type            string                     `xml:"type,attr"`
+	SubscriptionURL *calendarServerHrefElement `xml:"http://calendarserver.org/ns/ subscription-url,omitempty"`
+	APSBundleID     string                     `xml:"http://calendarserver.org/ns/ apsbundleid,omitempty"`
+	Env             string                     `xml:"http://calendarserver.org/ns/ env,omitempty"`
+	RefreshInterval string                     `xml:"http://calendarserver.org/ns/ refresh-interval,omitempty"`
+	XMPPServer      string                     `xml:"http://calendarserver.org/ns/ xmpp-server,omitempty"`
+	XMPPURI         string                     `xml:"http://calendarserver.org/ns/ xmpp-uri,omitempty"`
+}
+
+type calendarServerHrefElement struct {
+	Href internal.Href `xml:"DAV: href"`
+}
+
+// http://calendarserver.org/ns/ getctag extension
+type calendarServerGetCTag struct {
+	XMLName xml.Name `xml:"http://calendarserver.org/ns/ getctag"`
+	CTag    string   `xml:",chardata"`
+}
+
+// http://calendarserver.org/ns/ pushkey extension
+type calendarServerPushKey struct {
+	XMLName xml.Name `xml:"http://calendarserver.org/ns/ pushkey"`
+	Key     string   `xml:",chardata"`
+}
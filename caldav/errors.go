@@ -0,0 +1,42 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+)
+
+// Preconditions and postconditions reported by CalDAV servers in DAV:error
+// elements, defined in RFC 4791 section 1.3. They can be checked with
+// webdav.HasCondition. MaxResourceSizeName and SupportedCalendarDataName are
+// also used as preconditions.
+var (
+	ValidCalendarDataName           = xml.Name{namespace, "valid-calendar-data"}
+	ValidCalendarObjectResourceName = xml.Name{namespace, "valid-calendar-object-resource"}
+	SupportedCalendarComponentName  = xml.Name{namespace, "supported-calendar-component"}
+	NoUIDConflictName               = xml.Name{namespace, "no-uid-conflict"}
+	MinDateTimeName                 = xml.Name{namespace, "min-date-time"}
+	MaxDateTimeName                 = xml.Name{namespace, "max-date-time"}
+)
+
+// IsUIDConflict reports whether err indicates that a calendar object couldn't
+// be stored because another object in the calendar collection already has the
+// same UID. This includes errors matching ErrUIDConflict, such as the ones
+// returned by CreateEvent.
+func IsUIDConflict(err error) bool {
+	return errors.Is(err, ErrUIDConflict) || webdav.HasCondition(err, NoUIDConflictName)
+}
+
+// IsInvalidCalendarData reports whether err indicates that the server rejected
+// a calendar object because its data isn't valid.
+func IsInvalidCalendarData(err error) bool {
+	return webdav.HasCondition(err, ValidCalendarDataName) ||
+		webdav.HasCondition(err, ValidCalendarObjectResourceName)
+}
+
+// IsResourceTooLarge reports whether err indicates that a calendar object
+// exceeds the max-resource-size of its calendar collection.
+func IsResourceTooLarge(err error) bool {
+	return webdav.HasCondition(err, MaxResourceSizeName)
+}
@@ -0,0 +1,67 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+)
+
+func TestClientErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError := func(code int, condition string) {
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(code)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:error xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">` + condition + `</d:error>`))
+		}
+
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/cal/dup.ics":
+			writeError(http.StatusForbidden, `<cal:no-uid-conflict><d:href>/cal/orig.ics</d:href></cal:no-uid-conflict>`)
+		case r.Method == http.MethodPut && r.URL.Path == "/cal/stale.ics":
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case r.Method == "REPORT":
+			writeError(http.StatusForbidden, `<d:valid-sync-token/>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = c.PutCalendarObject(ctx, "/cal/dup.ics", strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil)
+	if !IsUIDConflict(err) {
+		t.Errorf("PutCalendarObject: expected UID conflict, got %v", err)
+	}
+	if code := webdav.StatusCode(err); code != http.StatusForbidden {
+		t.Errorf("PutCalendarObject: got status code %v, want 403", code)
+	}
+
+	_, err = c.PutCalendarObject(ctx, "/cal/stale.ics", strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), &PutCalendarObjectOptions{IfMatch: "1"})
+	if !webdav.IsPreconditionFailed(err) {
+		t.Errorf("PutCalendarObject: expected precondition failure, got %v", err)
+	}
+
+	err = c.DeleteCalendarObject(ctx, "/cal/missing.ics", nil)
+	if !webdav.IsNotFound(err) {
+		t.Errorf("DeleteCalendarObject: expected not found, got %v", err)
+	}
+
+	_, err = c.SyncCalendar(ctx, "/cal/", &SyncQuery{SyncToken: "expired"})
+	if !webdav.IsInvalidSyncToken(err) {
+		t.Errorf("SyncCalendar: expected invalid sync token, got %v", err)
+	}
+	_, err = c.SyncCalendarList(ctx, "/", "expired")
+	if !webdav.IsInvalidSyncToken(err) {
+		t.Errorf("SyncCalendarList: expected invalid sync token, got %v", err)
+	}
+}
@@ -1,12 +1,18 @@
 package caldav
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/ical"
 	"github.com/yinjun1991/caldav-client-go/internal"
 )
 
@@ -55,23 +61,74 @@ func parseCalendarFromResponse(resp *internal.Response) (*Calendar, error) {
 		compNames = append(compNames, comp.Name)
 	}
 
+	var supportedCalData supportedCalendarData
+	if err := resp.DecodeProp(&supportedCalData); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+
+	var calDataTypes []CalendarDataType
+	for _, t := range supportedCalData.Types {
+		calDataTypes = append(calDataTypes, CalendarDataType{
+			ContentType: t.ContentType,
+			Version:     t.Version,
+		})
+	}
+
 	var calColor calendarColor
 	if err := resp.DecodeProp(&calColor); err != nil && !internal.IsNotFound(err) {
 		return nil, err
 	}
 
+	var calOrder calendarOrder
+	if err := resp.DecodeProp(&calOrder); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	// Some servers return an empty or non-integer order, ignore it
+	order, _ := strconv.Atoi(strings.TrimSpace(calOrder.Order))
+
+	var schedTransp scheduleCalendarTransp
+	if err := resp.DecodeProp(&schedTransp); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	var transp ScheduleTransp
+	if schedTransp.Opaque != nil {
+		transp = ScheduleTranspOpaque
+	} else if schedTransp.Transparent != nil {
+		transp = ScheduleTranspTransparent
+	}
+
 	var calTimezone calendarTimezone
 	if err := resp.DecodeProp(&calTimezone); err != nil && !internal.IsNotFound(err) {
 		return nil, err
 	}
 
+	var alarmDateTime defaultAlarmVEventDateTime
+	if err := resp.DecodeProp(&alarmDateTime); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	var alarmDate defaultAlarmVEventDate
+	if err := resp.DecodeProp(&alarmDate); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+	// Malformed default alarms are ignored, like other display properties
+	defaultAlarmsDateTime, _ := parseDefaultAlarms(alarmDateTime.Data)
+	defaultAlarmsDate, _ := parseDefaultAlarms(alarmDate.Data)
+
 	var syncToken string
-	if rawSyncToken := resp.PropStats[0].Prop.Get(internal.SyncTokenName); rawSyncToken != nil {
-		if err := rawSyncToken.Decode(&syncToken); err != nil {
-			return nil, err
+	for _, propstat := range resp.PropStats {
+		if rawSyncToken := propstat.Prop.Get(internal.SyncTokenName); rawSyncToken != nil && propstat.Status.Err() == nil {
+			if err := rawSyncToken.Decode(&syncToken); err != nil {
+				return nil, err
+			}
+			break
 		}
 	}
 
+	var ctag calendarServerGetCTag
+	if err := resp.DecodeProp(&ctag); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+
 	var currentUserPrivileges []string
 	var privSet internal.CurrentUserPrivilegeSet
 	if err := resp.DecodeProp(&privSet); err != nil && !internal.IsNotFound(err) {
@@ -85,19 +142,101 @@ func parseCalendarFromResponse(resp *internal.Response) (*Calendar, error) {
 		}
 	}
 
+	var quota *webdav.Quota
+	if used, available, ok := resp.DecodeQuota(); ok {
+		quota = &webdav.Quota{Used: used, Available: available}
+	}
+
+	var transports pushTransports
+	if err := resp.DecodeProp(&transports); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+
+	var topic pushTopic
+	if err := resp.DecodeProp(&topic); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+
+	var pushKey calendarServerPushKey
+	if err := resp.DecodeProp(&pushKey); err != nil && !internal.IsNotFound(err) {
+		return nil, err
+	}
+
 	return &Calendar{
-		Path:                  path,
-		Name:                  dispName.Name,
-		Description:           desc.Description,
-		MaxResourceSize:       maxResSize.Size,
-		SupportedComponentSet: compNames,
-		Color:                 calColor.Color,
-		Timezone:              calTimezone.Timezone,
-		SyncToken:             syncToken,
-		CurrentUserPrivileges: currentUserPrivileges,
+		Path:                       path,
+		Name:                       dispName.Name,
+		Description:                desc.Description,
+		MaxResourceSize:            maxResSize.Size,
+		SupportedComponentSet:      compNames,
+		SupportedCalendarData:      calDataTypes,
+		Color:                      calColor.Color,
+		Order:                      order,
+		Timezone:                   calTimezone.Timezone,
+		ScheduleCalendarTransp:     transp,
+		SyncToken:                  syncToken,
+		CTag:                       strings.TrimSpace(ctag.CTag),
+		CurrentUserPrivileges:      currentUserPrivileges,
+		Quota:                      quota,
+		PushTransports:             newWebPushTransports(&transports),
+		PushTopic:                  strings.TrimSpace(topic.Topic),
+		PushKey:                    strings.TrimSpace(pushKey.Key),
+		DefaultAlarmVEventDateTime: defaultAlarmsDateTime,
+		DefaultAlarmVEventDate:     defaultAlarmsDate,
 	}, nil
 }
 
+// parseDefaultAlarms parses the value of a default alarm property: a list of
+// VALARM components, as defined in draft-daboo-valarm-extensions section 9.
+// Some servers wrap them in a VCALENDAR component.
+func parseDefaultAlarms(data string) ([]ical.Alarm, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(strings.ToUpper(data), "BEGIN:"+ical.CompCalendar) {
+		data = "BEGIN:" + ical.CompCalendar + "\r\n" + data + "\r\nEND:" + ical.CompCalendar + "\r\n"
+	}
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("caldav: invalid default alarm: %w", err)
+	}
+
+	var alarms []ical.Alarm
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompAlarm {
+			return nil, fmt.Errorf("caldav: invalid default alarm: unexpected %s component", comp.Name)
+		}
+		alarms = append(alarms, ical.Alarm{Component: comp})
+	}
+	return alarms, nil
+}
+
+// formatDefaultAlarms formats the value of a default alarm property for
+// UpdateCalendarOptions: nil is kept as-is and an empty list is formatted as
+// an empty string, which removes the property.
+func formatDefaultAlarms(alarms *[]ical.Alarm) (*string, error) {
+	if alarms == nil {
+		return nil, nil
+	}
+	var buf strings.Builder
+	for _, alarm := range *alarms {
+		if alarm.Component == nil || alarm.Name != ical.CompAlarm {
+			return nil, fmt.Errorf("caldav: default alarms must be %s components", ical.CompAlarm)
+		}
+		// Encode the alarm in a VCALENDAR component and strip the latter
+		var encoded strings.Builder
+		cal := &ical.Calendar{Component: &ical.Component{Name: ical.CompCalendar, Children: []*ical.Component{alarm.Component}}}
+		if err := ical.NewEncoder(&encoded).Encode(cal); err != nil {
+			return nil, err
+		}
+		s := strings.TrimPrefix(encoded.String(), "BEGIN:"+ical.CompCalendar+"\r\n")
+		s = strings.TrimSuffix(s, "END:"+ical.CompCalendar+"\r\n")
+		buf.WriteString(s)
+	}
+	s := buf.String()
+	return &s, nil
+}
+
 func encodeCalendarCompReq(c *CalendarCompRequest) (*comp, error) {
 	encoded := comp{Name: c.Name}
 
@@ -130,7 +269,12 @@ func encodeCalendarReq(c *CalendarCompRequest) (*internal.Prop, error) {
 
 	expandReq := encodeExpandRequest(c.Expand)
 
-	calDataReq := calendarDataReq{Comp: compReq, Expand: expandReq}
+	calDataReq := calendarDataReq{
+		ContentType: c.ContentType,
+		Version:     c.Version,
+		Comp:        compReq,
+		Expand:      expandReq,
+	}
 
 	getLastModReq := internal.NewRawXMLElement(internal.GetLastModifiedName, nil, nil)
 	getETagReq := internal.NewRawXMLElement(internal.GetETagName, nil, nil)
@@ -160,6 +304,18 @@ func decodeCalendarObject(resp internal.Response, path string) (*CalendarObject,
 		// calendar-data 缺失时，Data 字段为 nil，客户端可以根据需要单独获取
 	}
 
+	data, contentType := calData.Data, calData.ContentType
+	if len(calData.XML) > 0 {
+		b, err := encodeRawXML(calData.XML)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+		if contentType == "" {
+			contentType = XCalMIMEType
+		}
+	}
+
 	var getLastMod internal.GetLastModified
 	if err := resp.DecodeProp(&getLastMod); err != nil && !internal.IsNotFound(err) {
 		return nil, err
@@ -180,10 +336,49 @@ func decodeCalendarObject(resp internal.Response, path string) (*CalendarObject,
 		ModTime:       time.Time(getLastMod.LastModified),
 		ContentLength: getContentLength.Length,
 		ETag:          string(getETag.ETag),
-		Data:          calData.Data, // 可能为 nil，表示需要单独获取
+		ContentType:   contentType,
+		Data:          data, // 可能为 nil，表示需要单独获取
 	}, nil
 }
 
+// encodeRawXML serializes decoded XML elements into a standalone document
+// fragment. Namespace declarations inherited from the decoded document are
+// dropped, the encoder re-declares the namespaces it needs.
+func encodeRawXML(values []internal.RawXMLValue) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	for i := range values {
+		tr := values[i].TokenReader()
+		for {
+			tok, err := tr.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			if start, ok := tok.(xml.StartElement); ok {
+				attrs := make([]xml.Attr, 0, len(start.Attr))
+				for _, attr := range start.Attr {
+					if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+						continue
+					}
+					attrs = append(attrs, attr)
+				}
+				start.Attr = attrs
+				tok = start
+			}
+			if err := enc.EncodeToken(tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func populateCalendarObject(co *CalendarObject, h http.Header) error {
 	if loc := h.Get("Location"); loc != "" {
 		u, err := url.Parse(loc)
@@ -0,0 +1,218 @@
+package caldav
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+const (
+	// defaultMultigetChunkSize is the default maximum number of hrefs per
+	// calendar-multiget REPORT. Some servers, such as iCloud, reject larger
+	// requests.
+	defaultMultigetChunkSize = 50
+	// defaultMultigetConcurrency is the default maximum number of concurrent
+	// calendar-multiget REPORT requests.
+	defaultMultigetConcurrency = 4
+)
+
+// CalendarMultigetOptions contains options for CalendarMultigetWithOptions
+type CalendarMultigetOptions struct {
+	// ChunkSize is the maximum number of hrefs sent in a single REPORT
+	// request. Zero means the default of 50.
+	ChunkSize int
+
+	// Concurrency is the maximum number of REPORT requests in flight. Zero
+	// means the default of 4.
+	Concurrency int
+}
+
+// CalendarMultigetWithOptions fetches calendar objects by their paths.
+//
+// Paths are grouped by calendar collection and split into chunks of at most
+// opts.ChunkSize hrefs, which are fetched with up to opts.Concurrency
+// concurrent calendar-multiget REPORT requests. A failure for a single href,
+// or for a whole chunk, doesn't abort the operation: it's reported in the
+// result's Errors instead. Objects and Errors are in the order of paths.
+// Duplicate paths are silently de-duplicated.
+//
+// Hrefs the server left out of its response are reported with
+// ErrMissingFromResponse rather than as 404 Not Found.
+//
+// An error is only returned if ctx is cancelled.
+func (c *Client) CalendarMultigetWithOptions(ctx context.Context, paths []string, comp *CalendarCompRequest, opts *CalendarMultigetOptions) (*CalendarObjectsResult, error) {
+	if opts == nil {
+		opts = new(CalendarMultigetOptions)
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultMultigetChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultMultigetConcurrency
+	}
+
+	if len(paths) == 0 {
+		return &CalendarObjectsResult{}, nil
+	}
+
+	propReq, err := encodeCalendarReq(comp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group paths by collection, preserving the order of first appearance
+	var collections []string
+	groups := make(map[string][]string)
+	requested := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		key := c.hrefKey(p)
+		if requested[key] {
+			continue
+		}
+		requested[key] = true
+
+		collection := parentCollectionPath(p)
+		if _, ok := groups[collection]; !ok {
+			collections = append(collections, collection)
+		}
+		groups[collection] = append(groups[collection], p)
+	}
+
+	type chunk struct {
+		collection string
+		paths      []string
+	}
+	var chunks []chunk
+	for _, collection := range collections {
+		l := groups[collection]
+		for len(l) > 0 {
+			n := min(chunkSize, len(l))
+			chunks = append(chunks, chunk{collection, l[:n]})
+			l = l[n:]
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		objects = make(map[string]*CalendarObject, len(paths))
+		errs    = make(map[string]*HrefError)
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, ch := range chunks {
+		wg.Add(1)
+		go func(ch chunk) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			chunkObjects, chunkErrs := c.calendarMultigetChunk(ctx, ch.collection, ch.paths, propReq)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range chunkObjects {
+				objects[k] = v
+			}
+			for k, v := range chunkErrs {
+				errs[k] = v
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &CalendarObjectsResult{}
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		key := c.hrefKey(p)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if co, ok := objects[key]; ok {
+			result.Objects = append(result.Objects, co)
+		} else if hrefErr, ok := errs[key]; ok {
+			result.Errors = append(result.Errors, hrefErr)
+		} else {
+			result.Errors = append(result.Errors, &HrefError{Href: p, Err: ErrMissingFromResponse})
+		}
+	}
+	return result, nil
+}
+
+// calendarMultigetChunk performs a single calendar-multiget REPORT. Results
+// are keyed by hrefKey.
+func (c *Client) calendarMultigetChunk(ctx context.Context, collection string, paths []string, propReq *internal.Prop) (map[string]*CalendarObject, map[string]*HrefError) {
+	objects := make(map[string]*CalendarObject, len(paths))
+	errs := make(map[string]*HrefError)
+
+	hrefs := make([]internal.Href, len(paths))
+	for i, p := range paths {
+		hrefs[i] = internal.Href{Path: p}
+	}
+
+	multiget := &calendarMultiget{
+		Hrefs: hrefs,
+		Prop:  propReq,
+	}
+
+	depth := internal.DepthOne
+	ms, err := c.ic.ReportDepth(ctx, collection, &depth, multiget)
+	if err != nil {
+		for _, p := range paths {
+			errs[c.hrefKey(p)] = newHrefError(p, err)
+		}
+		return objects, errs
+	}
+
+	for _, resp := range ms.Responses {
+		p, err := resp.Path()
+		if err != nil {
+			for _, hrefErr := range newResponseErrors(&resp, err) {
+				// Without href, the failure can't be matched to a path,
+				// which is then reported as missing from the response
+				if hrefErr.Href != "" {
+					errs[c.hrefKey(hrefErr.Href)] = hrefErr
+				}
+			}
+			continue
+		}
+
+		co, err := decodeCalendarObject(resp, p)
+		if err != nil {
+			errs[c.hrefKey(p)] = newHrefError(p, err)
+			continue
+		}
+		objects[c.hrefKey(p)] = co
+	}
+	return objects, errs
+}
+
+// hrefKey normalizes a path so that request paths can be matched against
+// hrefs returned by the server.
+func (c *Client) hrefKey(p string) string {
+	return c.ic.ResolveHref(p).Path
+}
+
+// parentCollectionPath returns the path of the collection containing the
+// resource at p, with a trailing slash.
+func parentCollectionPath(p string) string {
+	p = strings.TrimRight(p, "/")
+	if idx := strings.LastIndex(p, "/"); idx >= 0 {
+		return p[:idx+1]
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCalendarMultigetWithOptions(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requests  = make(map[string]int)
+		chunkSize = 2
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "REPORT" {
+			t.Errorf("expected REPORT, got %s", r.Method)
+			return
+		}
+
+		var mg calendarMultiget
+		if err := xml.NewDecoder(r.Body).Decode(&mg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+		mu.Lock()
+		if len(mg.Hrefs) > chunkSize {
+			t.Errorf("expected at most %d hrefs per request, got %d", chunkSize, len(mg.Hrefs))
+		}
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		var sb strings.Builder
+		sb.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		for _, href := range mg.Hrefs {
+			if !strings.HasPrefix(href.Path, r.URL.Path) {
+				t.Errorf("href %s requested on collection %s", href.Path, r.URL.Path)
+			}
+			if strings.Contains(href.Path, "dropped") {
+				continue
+			}
+			if strings.Contains(href.Path, "missing") {
+				fmt.Fprintf(&sb, `<d:response><d:href>%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>`, href.Path)
+				continue
+			}
+			fmt.Fprintf(&sb, `<d:response>
+  <d:href>%s</d:href>
+  <d:propstat>
+    <d:prop>
+      <d:getetag>"%s"</d:getetag>
+      <cal:calendar-data>BEGIN:VCALENDAR
+END:VCALENDAR</cal:calendar-data>
+    </d:prop>
+    <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+</d:response>`, href.Path, href.Path)
+		}
+		sb.WriteString(`</d:multistatus>`)
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(sb.String()))
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	paths := []string{
+		"/cal/a/1.ics",
+		"/cal/b/1.ics",
+		"/cal/a/missing.ics",
+		"/cal/a/2.ics",
+		"/cal/b/dropped.ics",
+		"/cal/a/2.ics",
+		"/cal/a/3.ics",
+		"/cal/b/2.ics",
+	}
+	result, err := c.CalendarMultigetWithOptions(context.Background(), paths, &CalendarCompRequest{Name: "VCALENDAR"}, &CalendarMultigetOptions{
+		ChunkSize:   chunkSize,
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("CalendarMultigetWithOptions error: %v", err)
+	}
+
+	if requests["/cal/a/"] != 2 || requests["/cal/b/"] != 2 {
+		t.Fatalf("unexpected requests per collection: %v", requests)
+	}
+
+	want := []string{"/cal/a/1.ics", "/cal/b/1.ics", "/cal/a/2.ics", "/cal/a/3.ics", "/cal/b/2.ics"}
+	if len(result.Objects) != len(want) {
+		t.Fatalf("expected %d objects, got %d", len(want), len(result.Objects))
+	}
+	for i, co := range result.Objects {
+		if co.Path != want[i] {
+			t.Errorf("object %d: got path %s, want %s", i, co.Path, want[i])
+		}
+		if co.ETag != co.Path {
+			t.Errorf("object %d: got ETag %s, want %s", i, co.ETag, co.Path)
+		}
+	}
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d", len(result.Errors))
+	}
+	if hrefErr := result.Errors[0]; hrefErr.Href != "/cal/a/missing.ics" || hrefErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected error: %+v", hrefErr)
+	}
+	if hrefErr := result.Errors[1]; hrefErr.Href != "/cal/b/dropped.ics" || hrefErr.StatusCode != 0 || !errors.Is(hrefErr, ErrMissingFromResponse) {
+		t.Fatalf("unexpected error: %+v", hrefErr)
+	}
+
+	// CalendarMultiget reports the failure
+	mu.Lock()
+	chunkSize = defaultMultigetChunkSize
+	mu.Unlock()
+	if _, err := c.CalendarMultiget(context.Background(), paths, &CalendarCompRequest{Name: "VCALENDAR"}); err == nil {
+		t.Fatalf("expected CalendarMultiget to fail")
+	}
+}
+
+func TestParentCollectionPath(t *testing.T) {
+	tcs := []struct {
+		input, expected string
+	}{
+		{"/cal/a/1.ics", "/cal/a/"},
+		{"/1.ics", "/"},
+		{"/cal/a/", "/cal/"},
+		{"1.ics", ""},
+	}
+	for _, tc := range tcs {
+		if got := parentCollectionPath(tc.input); got != tc.expected {
+			t.Errorf("parentCollectionPath(%q)=%q, want %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestSyncCalendarStartTimeMultigetErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sb strings.Builder
+		sb.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		var mg calendarMultiget
+		if err := xml.NewDecoder(r.Body).Decode(&mg); err == nil && len(mg.Hrefs) > 0 {
+			// calendar-multiget: deleted.ics is gone, failing.ics errors out
+			// and dropped.ics is left out
+			sb.WriteString(`<d:response>
+  <d:href>/cal/kept.ics</d:href>
+  <d:propstat>
+    <d:prop>
+      <d:getetag>"kept"</d:getetag>
+      <cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20231003T100000Z
+DTEND:20231003T110000Z
+END:VEVENT
+END:VCALENDAR</cal:calendar-data>
+    </d:prop>
+    <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+</d:response>
+<d:response>
+  <d:href>/cal/deleted.ics</d:href>
+  <d:status>HTTP/1.1 404 Not Found</d:status>
+</d:response>
+<d:response>
+  <d:href>/cal/failing.ics</d:href>
+  <d:status>HTTP/1.1 500 Internal Server Error</d:status>
+</d:response>`)
+		} else {
+			// sync-collection without calendar-data
+			sb.WriteString(`<d:sync-token>token-1</d:sync-token>`)
+			for _, name := range []string{"kept", "deleted", "failing", "dropped"} {
+				fmt.Fprintf(&sb, `<d:response>
+  <d:href>/cal/%s.ics</d:href>
+  <d:propstat>
+    <d:prop><d:getetag>"%s"</d:getetag></d:prop>
+    <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+</d:response>`, name, name)
+			}
+		}
+		sb.WriteString(`</d:multistatus>`)
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(sb.String()))
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	cutoff := time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)
+	resp, err := c.SyncCalendar(context.Background(), "/cal/", &SyncQuery{StartTime: cutoff})
+	if err != nil {
+		t.Fatalf("SyncCalendar error: %v", err)
+	}
+	if len(resp.Updated) != 1 || resp.Updated[0].Path != "/cal/kept.ics" || len(resp.Updated[0].Data) == 0 {
+		t.Errorf("unexpected updated objects: %+v", resp.Updated)
+	}
+	if len(resp.Deleted) != 1 || resp.Deleted[0] != "/cal/deleted.ics" {
+		t.Errorf("unexpected deleted objects: %v", resp.Deleted)
+	}
+	if len(resp.Errors) != 2 || resp.Errors[0].Href != "/cal/failing.ics" || resp.Errors[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected errors: %+v", resp.Errors)
+	} else if !errors.Is(resp.Errors[1], ErrMissingFromResponse) || resp.Errors[1].Href != "/cal/dropped.ics" {
+		t.Errorf("unexpected error for an object missing from the response: %+v", resp.Errors[1])
+	}
+}
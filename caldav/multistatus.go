@@ -0,0 +1,97 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+// ErrMissingFromResponse is wrapped by the HrefError reported for a resource
+// the server left out of a multi-status response. Unlike a 404 Not Found
+// reported by the server, it doesn't mean that the resource doesn't exist.
+var ErrMissingFromResponse = errors.New("caldav: resource missing from multi-status response")
+
+// HrefError reports the failure of a single resource in an operation on
+// several resources.
+type HrefError struct {
+	Href string
+	// StatusCode is the HTTP status code reported for the resource, or zero
+	// if the failure isn't an HTTP error, e.g. ErrMissingFromResponse.
+	StatusCode int
+	// Precondition is the name of the precondition or postcondition element
+	// reported by the server in a DAV:error element, e.g. CalDAV's
+	// valid-calendar-data. It's zero if the server didn't report any.
+	Precondition xml.Name
+	// Message is the human-readable description of the failure provided by
+	// the server, if any.
+	Message string
+	Err     error
+}
+
+func (err *HrefError) Error() string {
+	return fmt.Sprintf("caldav: %s: %v", err.Href, err.Err)
+}
+
+func (err *HrefError) Unwrap() error {
+	return err.Err
+}
+
+func newHrefError(href string, err error) *HrefError {
+	hrefErr := &HrefError{Href: href, Err: err}
+	var httpErr *internal.HTTPError
+	if errors.As(err, &httpErr) {
+		hrefErr.StatusCode = httpErr.Code
+		var davErr *internal.Error
+		if httpErr.Err != nil && !errors.As(httpErr.Err, &davErr) {
+			hrefErr.Message = httpErr.Err.Error()
+		}
+	}
+	hrefErr.Precondition = errorCondition(err)
+	return hrefErr
+}
+
+// newResponseErrors returns the errors for a multi-status response which
+// failed with err, one for each of its hrefs. A response without any href
+// yields a single error with an empty Href.
+func newResponseErrors(resp *internal.Response, err error) []*HrefError {
+	hrefs := make([]string, 0, len(resp.Hrefs))
+	for _, href := range resp.Hrefs {
+		hrefs = append(hrefs, href.Path)
+	}
+	if len(hrefs) == 0 {
+		hrefs = append(hrefs, "")
+	}
+
+	errs := make([]*HrefError, 0, len(hrefs))
+	for _, href := range hrefs {
+		hrefErr := newHrefError(href, err)
+		if resp.ResponseDescription != "" {
+			hrefErr.Message = resp.ResponseDescription
+		}
+		errs = append(errs, hrefErr)
+	}
+	return errs
+}
+
+// errorCondition returns the name of the first precondition or postcondition
+// element of the DAV:error element wrapped by err, if any.
+func errorCondition(err error) xml.Name {
+	if conditions := webdav.Conditions(err); len(conditions) > 0 {
+		return conditions[0]
+	}
+	return xml.Name{}
+}
+
+// CalendarObjectsResult contains the outcome of an operation on several
+// calendar objects, such as CalendarMultigetWithOptions or
+// CalendarQueryWithErrors. A failure for a single object doesn't abort the
+// whole operation.
+type CalendarObjectsResult struct {
+	// Objects contains the calendar objects fetched successfully.
+	Objects []*CalendarObject
+	// Errors contains an entry for each resource which couldn't be fetched.
+	Errors []*HrefError
+}
@@ -0,0 +1,202 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCalendarQueryWithErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/ok.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"1"</d:getetag>
+        <cal:calendar-data>BEGIN:VCALENDAR
+END:VCALENDAR</cal:calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/cal/bad.ics</d:href>
+    <d:status>HTTP/1.1 403 Forbidden</d:status>
+    <d:error><cal:valid-calendar-data/></d:error>
+    <d:responsedescription>Invalid calendar data</d:responsedescription>
+  </d:response>
+</d:multistatus>`))
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	query := &CalendarQueryRequest{
+		CompRequest: CalendarCompRequest{Name: "VCALENDAR"},
+		Filter:      CompFilter{Name: "VCALENDAR"},
+	}
+	result, err := c.CalendarQueryWithErrors(context.Background(), "/cal/", query)
+	if err != nil {
+		t.Fatalf("CalendarQueryWithErrors error: %v", err)
+	}
+
+	if len(result.Objects) != 1 || result.Objects[0].Path != "/cal/ok.ics" {
+		t.Fatalf("unexpected objects: %+v", result.Objects)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(result.Errors))
+	}
+	hrefErr := result.Errors[0]
+	if hrefErr.Href != "/cal/bad.ics" || hrefErr.StatusCode != http.StatusForbidden {
+		t.Errorf("unexpected error: %+v", hrefErr)
+	}
+	if want := (xml.Name{Space: namespace, Local: "valid-calendar-data"}); hrefErr.Precondition != want {
+		t.Errorf("got precondition %v, want %v", hrefErr.Precondition, want)
+	}
+	if hrefErr.Message != "Invalid calendar data" {
+		t.Errorf("got message %q", hrefErr.Message)
+	}
+
+	if _, err := c.CalendarQuery(context.Background(), "/cal/", query); err == nil {
+		t.Fatalf("expected CalendarQuery to fail")
+	}
+}
+
+func TestSyncCalendarListErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/home/work/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <d:displayname>Work</d:displayname>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/home/old/</d:href>
+    <d:status>HTTP/1.1 404 Not Found</d:status>
+  </d:response>
+  <d:response>
+    <d:href>/home/secret/</d:href>
+    <d:status>HTTP/1.1 403 Forbidden</d:status>
+  </d:response>
+  <d:sync-token>token-2</d:sync-token>
+</d:multistatus>`))
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	result, err := c.SyncCalendarList(context.Background(), "/home/", "token-1")
+	if err != nil {
+		t.Fatalf("SyncCalendarList error: %v", err)
+	}
+
+	if len(result.UpdatedCalendars) != 1 || result.UpdatedCalendars[0].Path != "/home/work/" {
+		t.Errorf("unexpected updated calendars: %+v", result.UpdatedCalendars)
+	}
+	if len(result.DeletedCalendars) != 1 || result.DeletedCalendars[0] != "/home/old/" {
+		t.Errorf("unexpected deleted calendars: %v", result.DeletedCalendars)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Href != "/home/secret/" || result.Errors[0].StatusCode != http.StatusForbidden {
+		t.Errorf("unexpected errors: %+v", result.Errors)
+	}
+}
+
+func TestSyncCalendarErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/ok.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"ok"</d:getetag>
+        <cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+END:VEVENT
+END:VCALENDAR</cal:calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/cal/deleted.ics</d:href>
+    <d:status>HTTP/1.1 404 Not Found</d:status>
+  </d:response>
+  <d:response>
+    <d:href>/cal/secret.ics</d:href>
+    <d:status>HTTP/1.1 403 Forbidden</d:status>
+  </d:response>
+  <d:response>
+    <d:href>/cal/broken.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"broken"</d:getetag>
+        <d:getlastmodified>yesterday</d:getlastmodified>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:status>HTTP/1.1 404 Not Found</d:status>
+    <d:responsedescription>Backend unavailable</d:responsedescription>
+  </d:response>
+  <d:sync-token>token-2</d:sync-token>
+</d:multistatus>`))
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	resp, err := c.SyncCalendar(context.Background(), "/cal/", &SyncQuery{SyncToken: "token-1"})
+	if err != nil {
+		t.Fatalf("SyncCalendar error: %v", err)
+	}
+
+	if resp.SyncToken != "token-2" {
+		t.Errorf("unexpected sync token: %q", resp.SyncToken)
+	}
+	if len(resp.Updated) != 1 || resp.Updated[0].Path != "/cal/ok.ics" {
+		t.Errorf("unexpected updated objects: %+v", resp.Updated)
+	}
+	if len(resp.Deleted) != 1 || resp.Deleted[0] != "/cal/deleted.ics" {
+		t.Errorf("unexpected deleted objects: %v", resp.Deleted)
+	}
+	if len(resp.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got %+v", resp.Errors)
+	}
+	if resp.Errors[0].Href != "/cal/secret.ics" || resp.Errors[0].StatusCode != http.StatusForbidden {
+		t.Errorf("unexpected error: %+v", resp.Errors[0])
+	}
+	if resp.Errors[1].Href != "/cal/broken.ics" || resp.Errors[1].StatusCode != 0 {
+		t.Errorf("unexpected error: %+v", resp.Errors[1])
+	}
+	// A response without href is still reported
+	if hrefErr := resp.Errors[2]; hrefErr.Href != "" || hrefErr.StatusCode != http.StatusNotFound || hrefErr.Message != "Backend unavailable" {
+		t.Errorf("unexpected error: %+v", hrefErr)
+	}
+}
@@ -0,0 +1,243 @@
+package caldav
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// NormalizationProfile describes the changes a server is known to make to
+// calendar objects, so that they can be ignored when comparing a local copy
+// with the server's copy. See Normalize.
+type NormalizationProfile struct {
+	// IgnoreProps lists properties removed from all components. A trailing
+	// "*" matches all properties with the prefix, e.g. "X-GOOGLE-*".
+	IgnoreProps []string
+	// StripXProps removes all X- properties except those matching
+	// KeepXProps, for servers which drop unknown extensions.
+	StripXProps bool
+	KeepXProps  []string
+	// IgnoreAlarms removes VALARM components, for servers which rewrite
+	// alarms.
+	IgnoreAlarms bool
+	// IgnoreTimezones removes VTIMEZONE components, for servers which
+	// re-encode timezone definitions. TZID parameters are still compared.
+	IgnoreTimezones bool
+	// TZIDMap renames TZIDs, e.g. from Windows timezone names to IANA ones.
+	TZIDMap map[string]string
+}
+
+// volatileProps are properties updated by servers and clients on every write.
+var volatileProps = []string{
+	ical.PropDateTimeStamp,
+	ical.PropLastModified,
+	ical.PropCreated,
+	ical.PropProductID,
+}
+
+// Normalization profiles for common servers.
+var (
+	// DefaultNormalization only ignores properties updated on every write.
+	DefaultNormalization = &NormalizationProfile{
+		IgnoreProps: volatileProps,
+	}
+	// ICloudNormalization ignores alarms, which iCloud rewrites, and X-
+	// properties, which it strips or adds.
+	ICloudNormalization = &NormalizationProfile{
+		IgnoreProps:  volatileProps,
+		StripXProps:  true,
+		IgnoreAlarms: true,
+	}
+	// GoogleNormalization ignores timezone definitions, which Google
+	// re-encodes, and Google extensions.
+	GoogleNormalization = &NormalizationProfile{
+		IgnoreProps:     append([]string{"X-GOOGLE-*"}, volatileProps...),
+		IgnoreTimezones: true,
+	}
+	// ExchangeNormalization maps Windows TZIDs to IANA ones, and ignores
+	// timezone definitions and Microsoft extensions.
+	ExchangeNormalization = &NormalizationProfile{
+		IgnoreProps:     append([]string{"X-MICROSOFT-*", "X-MS-*"}, volatileProps...),
+		IgnoreTimezones: true,
+		TZIDMap:         ical.WindowsTimezones,
+	}
+)
+
+// Normalize returns a canonical copy of cal, suitable for comparison with
+// ical.Component.Equal. If profile is nil, DefaultNormalization is used.
+//
+// Besides the changes described by the profile, properties and components
+// are sorted, TEXT values are re-escaped and SEQUENCE:0 is removed since it's
+// the default. Line endings and folding are canonicalised when encoding the
+// result, see NormalizeData.
+func Normalize(cal *ical.Calendar, profile *NormalizationProfile) *ical.Calendar {
+	if profile == nil {
+		profile = DefaultNormalization
+	}
+	n := normalizer{profile: profile}
+	return &ical.Calendar{Component: n.normalize(cal.Component)}
+}
+
+// NormalizeData normalises iCalendar data with Normalize and encodes it
+// back, with CRLF line endings and lines folded at 75 octets.
+func NormalizeData(data []byte, profile *NormalizationProfile) ([]byte, error) {
+	cal, err := ical.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(Normalize(cal, profile)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EqualNormalized reports whether two calendars are equal once normalised
+// with the profile.
+func EqualNormalized(a, b *ical.Calendar, profile *NormalizationProfile) bool {
+	return Normalize(a, profile).Equal(Normalize(b, profile).Component)
+}
+
+type normalizer struct {
+	profile *NormalizationProfile
+}
+
+func (n *normalizer) normalize(comp *ical.Component) *ical.Component {
+	out := ical.NewComponent(comp.Name)
+	for _, prop := range comp.Props {
+		if n.ignoreProp(prop.Name) {
+			continue
+		}
+		if prop.Name == ical.PropSequence && strings.TrimSpace(prop.Value) == "0" {
+			continue
+		}
+		out.Props = append(out.Props, n.normalizeProp(prop))
+	}
+	sort.SliceStable(out.Props, func(i, j int) bool {
+		return propSortKey(out.Props[i]) < propSortKey(out.Props[j])
+	})
+
+	for _, child := range comp.Children {
+		switch {
+		case child.Name == ical.CompAlarm && n.profile.IgnoreAlarms:
+			continue
+		case child.Name == ical.CompTimezone && n.profile.IgnoreTimezones:
+			continue
+		}
+		out.Children = append(out.Children, n.normalize(child))
+	}
+	keys := make(map[*ical.Component]string, len(out.Children))
+	for _, child := range out.Children {
+		keys[child] = componentSortKey(child)
+	}
+	sort.SliceStable(out.Children, func(i, j int) bool {
+		return keys[out.Children[i]] < keys[out.Children[j]]
+	})
+	return out
+}
+
+func (n *normalizer) ignoreProp(name string) bool {
+	if matchPropName(n.profile.IgnoreProps, name) {
+		return true
+	}
+	return n.profile.StripXProps && strings.HasPrefix(name, "X-") && !matchPropName(n.profile.KeepXProps, name)
+}
+
+func matchPropName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToUpper(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *normalizer) normalizeProp(prop *ical.Prop) *ical.Prop {
+	out := prop.Clone()
+	out.Line = 0
+
+	if tzid := out.Params.Get(ical.ParamTimezoneID); tzid != "" {
+		out.Params.Set(ical.ParamTimezoneID, n.mapTZID(tzid))
+	}
+	if out.Name == ical.PropTimezoneID {
+		out.Value = n.mapTZID(out.Value)
+	}
+
+	if out.ValueType() == ical.ValueText {
+		switch out.Name {
+		case ical.PropCategories, ical.PropResources:
+			if l, err := out.TextList(); err == nil {
+				out.SetTextList(l)
+			}
+		default:
+			if s, err := out.Text(); err == nil {
+				out.SetText(s)
+			}
+		}
+	}
+	return out
+}
+
+func (n *normalizer) mapTZID(tzid string) string {
+	if mapped, ok := n.profile.TZIDMap[tzid]; ok {
+		return mapped
+	}
+	return tzid
+}
+
+// propSortKey returns a key ordering properties by name, then by parameters
+// and value.
+func propSortKey(prop *ical.Prop) string {
+	names := make([]string, 0, len(prop.Params))
+	for name := range prop.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(prop.Name)
+	for _, name := range names {
+		sb.WriteString(";")
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(strings.Join(prop.Params[name], ","))
+	}
+	sb.WriteString(":")
+	sb.WriteString(prop.Value)
+	return sb.String()
+}
+
+// componentSortKey returns a key ordering VTIMEZONE components first, then
+// components by name, UID and RECURRENCE-ID (the master component first),
+// then by content. comp must already be normalised.
+func componentSortKey(comp *ical.Component) string {
+	var sb strings.Builder
+	if comp.Name == ical.CompTimezone {
+		sb.WriteString("0")
+	} else {
+		sb.WriteString("1")
+	}
+	sb.WriteString(comp.Name)
+	for _, name := range []string{ical.PropUID, ical.PropTimezoneID, ical.PropRecurrenceID} {
+		sb.WriteString("\x00")
+		if prop := comp.Props.Get(name); prop != nil {
+			sb.WriteString(prop.Value)
+		}
+	}
+	for _, prop := range comp.Props {
+		sb.WriteString("\x00")
+		sb.WriteString(propSortKey(prop))
+	}
+	for _, child := range comp.Children {
+		sb.WriteString("\x00")
+		sb.WriteString(componentSortKey(child))
+	}
+	return sb.String()
+}
@@ -0,0 +1,143 @@
+package caldav
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+func decodeTestCalendar(t *testing.T, s string) *ical.Calendar {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(s)).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return cal
+}
+
+func TestNormalize(t *testing.T) {
+	local := decodeTestCalendar(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//local//EN\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:event-1\r\n"+
+		"DTSTAMP:20240101T000000Z\r\n"+
+		"SUMMARY:Lunch\\, with Bob\r\n"+
+		"DTSTART;TZID=W. Europe Standard Time:20240102T120000\r\n"+
+		"SEQUENCE:0\r\n"+
+		"ATTENDEE:mailto:b@example.com\r\n"+
+		"ATTENDEE:mailto:a@example.com\r\n"+
+		"X-CUSTOM:1\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"ACTION:DISPLAY\r\n"+
+		"TRIGGER:-PT15M\r\n"+
+		"END:VALARM\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n")
+	remote := decodeTestCalendar(t, "BEGIN:VCALENDAR\n"+
+		"PRODID:-//server//EN\n"+
+		"VERSION:2.0\n"+
+		"BEGIN:VEVENT\n"+
+		"ATTENDEE:mailto:a@example.com\n"+
+		"ATTENDEE:mailto:b@example.com\n"+
+		"DTSTART;TZID=Europe/Berlin:20240102T120000\n"+
+		"SUMMARY:Lunch\\, wi\n th Bob\n"+
+		"UID:event-1\n"+
+		"DTSTAMP:20240301T000000Z\n"+
+		"X-APPLE-TRAVEL-ADVISORY-BEHAVIOR:AUTOMATIC\n"+
+		"END:VEVENT\n"+
+		"END:VCALENDAR\n")
+
+	if EqualNormalized(local, remote, nil) {
+		t.Errorf("calendars shouldn't be equal with the default profile")
+	}
+
+	profile := &NormalizationProfile{
+		IgnoreProps:  ICloudNormalization.IgnoreProps,
+		StripXProps:  true,
+		IgnoreAlarms: true,
+		TZIDMap:      ical.WindowsTimezones,
+	}
+	if !EqualNormalized(local, remote, profile) {
+		var a, b bytes.Buffer
+		ical.NewEncoder(&a).Encode(Normalize(local, profile))
+		ical.NewEncoder(&b).Encode(Normalize(remote, profile))
+		t.Errorf("calendars should be equal once normalised:\n%s\n%s", a.String(), b.String())
+	}
+
+	// Normalize doesn't modify its argument
+	if local.Children[0].Props.Get(ical.PropDateTimeStamp) == nil || len(local.Children[0].Children) != 1 {
+		t.Errorf("Normalize modified its argument")
+	}
+}
+
+func TestNormalizeComponentOrder(t *testing.T) {
+	a := decodeTestCalendar(t, `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:e
+RECURRENCE-ID:20240103T100000Z
+SUMMARY:Moved
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+DTSTART:20240102T100000Z
+RRULE:FREQ=DAILY
+END:VEVENT
+BEGIN:VTIMEZONE
+TZID:Europe/Paris
+END:VTIMEZONE
+END:VCALENDAR
+`)
+	b := decodeTestCalendar(t, `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Europe/Paris
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:e
+DTSTART:20240102T100000Z
+RRULE:FREQ=DAILY
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+RECURRENCE-ID:20240103T100000Z
+SUMMARY:Moved
+END:VEVENT
+END:VCALENDAR
+`)
+	normalized := Normalize(a, nil)
+	if !normalized.Equal(Normalize(b, nil).Component) {
+		t.Errorf("component order should be canonical")
+	}
+	if normalized.Children[0].Name != ical.CompTimezone || normalized.Children[1].Props.Get(ical.PropRecurrenceID) != nil {
+		t.Errorf("unexpected component order")
+	}
+
+	if !EqualNormalized(a, b, GoogleNormalization) {
+		t.Errorf("calendars should be equal without timezones")
+	}
+}
+
+func TestNormalizeData(t *testing.T) {
+	data := "BEGIN:VCALENDAR\nVERSION:2.0\nBEGIN:VEVENT\nUID:e\nDESCRIPTION:" + strings.Repeat("x", 100) + "\nEND:VEVENT\nEND:VCALENDAR\n"
+	out, err := NormalizeData([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("NormalizeData error: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\r\n"), "\r\n") {
+		if strings.Contains(line, "\n") || len(line) > 75 {
+			t.Errorf("line isn't canonical: %q", line)
+		}
+	}
+
+	again, err := NormalizeData(out, nil)
+	if err != nil {
+		t.Fatalf("NormalizeData error: %v", err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Errorf("NormalizeData isn't idempotent:\n%s\n%s", out, again)
+	}
+}
@@ -0,0 +1,103 @@
+package caldav
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// mediaType returns the media type of the object's data. When the server
+// didn't report one, it's sniffed from the data.
+func (co *CalendarObject) mediaType() string {
+	if co.ContentType != "" {
+		if t, _, err := mime.ParseMediaType(co.ContentType); err == nil {
+			return strings.ToLower(t)
+		}
+	}
+	if trimmed := bytes.TrimSpace(co.Data); len(trimmed) > 0 {
+		switch trimmed[0] {
+		case '[':
+			return JCalMIMEType
+		case '<':
+			return XCalMIMEType
+		}
+	}
+	return MIMEType
+}
+
+// Calendar parses the object's data into an iCalendar object, whatever
+// representation the server returned it in.
+func (co *CalendarObject) Calendar() (*ical.Calendar, error) {
+	if len(co.Data) == 0 {
+		return nil, fmt.Errorf("caldav: calendar object %s has no data", co.Path)
+	}
+
+	switch t := co.mediaType(); t {
+	case MIMEType:
+		return ical.NewDecoder(bytes.NewReader(co.Data)).Decode()
+	case JCalMIMEType:
+		return ical.UnmarshalJCal(co.Data)
+	case XCalMIMEType:
+		return ical.UnmarshalXCal(co.Data)
+	default:
+		return nil, fmt.Errorf("caldav: unsupported calendar data type %q", t)
+	}
+}
+
+// SetCalendar replaces the object's data with the iCalendar encoding of cal.
+func (co *CalendarObject) SetCalendar(cal *ical.Calendar) error {
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(cal); err != nil {
+		return err
+	}
+	co.Data = buf.Bytes()
+	co.ContentType = MIMEType
+	return nil
+}
+
+// MarshalJCal returns the jCal representation of the object's data.
+func (co *CalendarObject) MarshalJCal() ([]byte, error) {
+	if co.mediaType() == JCalMIMEType {
+		return co.Data, nil
+	}
+	cal, err := co.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	return ical.MarshalJCal(cal)
+}
+
+// UnmarshalJCal replaces the object's data with the iCalendar equivalent of
+// the jCal data b.
+func (co *CalendarObject) UnmarshalJCal(b []byte) error {
+	cal, err := ical.UnmarshalJCal(b)
+	if err != nil {
+		return err
+	}
+	return co.SetCalendar(cal)
+}
+
+// MarshalXCal returns the xCal representation of the object's data.
+func (co *CalendarObject) MarshalXCal() ([]byte, error) {
+	if co.mediaType() == XCalMIMEType {
+		return co.Data, nil
+	}
+	cal, err := co.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	return ical.MarshalXCal(cal)
+}
+
+// UnmarshalXCal replaces the object's data with the iCalendar equivalent of
+// the xCal data b.
+func (co *CalendarObject) UnmarshalXCal(b []byte) error {
+	cal, err := ical.UnmarshalXCal(b)
+	if err != nil {
+		return err
+	}
+	return co.SetCalendar(cal)
+}
@@ -0,0 +1,394 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/itip"
+)
+
+// ErrNoOccurrence is matched by errors returned when a RECURRENCE-ID doesn't
+// identify an occurrence of a recurring calendar object.
+var ErrNoOccurrence = errors.New("caldav: no such occurrence")
+
+// occurrenceTime checks that recurrenceID identifies an occurrence of the
+// master component of cal and returns its recurrence set and start time.
+// Occurrences which are already overridden are always accepted.
+func occurrenceTime(cal *ical.Calendar, recurrenceID *ical.Prop) (*ical.Component, *ical.RecurrenceSet, time.Time, error) {
+	if recurrenceID == nil {
+		return nil, nil, time.Time{}, fmt.Errorf("caldav: missing RECURRENCE-ID")
+	}
+	master := cal.Master()
+	if master == nil {
+		return nil, nil, time.Time{}, fmt.Errorf("caldav: calendar object has no master component")
+	}
+	set, err := cal.RecurrenceSet(master, nil)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	t, err := recurrenceID.DateTime(set.Start.Location())
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	if cal.Override(recurrenceID) == nil && !set.Contains(t) {
+		return nil, nil, time.Time{}, fmt.Errorf("%w: RECURRENCE-ID %s", ErrNoOccurrence, recurrenceID.Value)
+	}
+	return master, set, t, nil
+}
+
+// OverrideOccurrence returns the component overriding the occurrence of a
+// recurring calendar object identified by recurrenceID. If the occurrence
+// isn't overridden yet, a component is created with ical.NewOverride and
+// added to cal.
+//
+// The returned error wraps ErrNoOccurrence if recurrenceID isn't an
+// occurrence of the series.
+func OverrideOccurrence(cal *ical.Calendar, recurrenceID *ical.Prop) (*ical.Component, error) {
+	if override := cal.Override(recurrenceID); override != nil && override.Props.Get(ical.PropRecurrenceID) != nil {
+		return override, nil
+	}
+	master, _, _, err := occurrenceTime(cal, recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	override := ical.NewOverride(master, recurrenceID)
+	cal.Children = append(cal.Children, override)
+	return override, nil
+}
+
+// ExcludeOccurrence cancels the occurrence of a recurring calendar object
+// identified by recurrenceID: an EXDATE is added to the master component,
+// in the same form as its DTSTART, and the overridden component for the
+// occurrence, if any, is removed.
+//
+// The returned error wraps ErrNoOccurrence if recurrenceID isn't an
+// occurrence of the series.
+func ExcludeOccurrence(cal *ical.Calendar, recurrenceID *ical.Prop) error {
+	master, set, t, err := occurrenceTime(cal, recurrenceID)
+	if err != nil {
+		return err
+	}
+	if t.Equal(set.Start) && !set.IsRecurring() {
+		return fmt.Errorf("caldav: cannot exclude the only occurrence of a calendar object")
+	}
+
+	exdate := master.Props.Get(ical.PropDateTimeStart).Clone()
+	exdate.Name = ical.PropExceptionDates
+	exdate.ReplaceDateTime(t)
+	master.Props.Add(exdate)
+
+	removeOverrides(cal, func(ridTime time.Time) bool {
+		return ridTime.Equal(t)
+	}, set.Start.Location())
+	return nil
+}
+
+// removeOverrides removes the overridden components of cal whose
+// RECURRENCE-ID matches f.
+func removeOverrides(cal *ical.Calendar, f func(time.Time) bool, loc *time.Location) {
+	children := cal.Children[:0]
+	for _, comp := range cal.Children {
+		if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil && comp.Name != ical.CompTimezone {
+			if t, err := rid.DateTime(loc); err == nil && f(t) {
+				continue
+			}
+		}
+		children = append(children, comp)
+	}
+	cal.Children = children
+}
+
+// SplitSeries splits a recurring calendar object at the occurrence
+// identified by recurrenceID, as needed to change "this and following"
+// occurrences. Few servers and clients support overridden components with
+// RANGE=THISANDFUTURE, so a new series is created instead:
+//
+//   - cal is truncated: its recurrence rules end with an UNTIL just before
+//     the occurrence, and later RDATE, EXDATE and overridden components are
+//     removed. The SEQUENCE of the master component is incremented.
+//   - The returned calendar holds the following occurrences, with UID
+//     newUID. Its master component starts at the occurrence, the COUNT of
+//     its rules is reduced by the number of earlier occurrences, and the
+//     later overridden components of cal are moved to it.
+//
+// Splitting at the first occurrence is an error: the whole series should be
+// modified instead. The returned error wraps ErrNoOccurrence if
+// recurrenceID isn't an occurrence of the series. cal isn't modified if an
+// error is returned.
+func SplitSeries(cal *ical.Calendar, recurrenceID *ical.Prop, newUID string) (*ical.Calendar, error) {
+	if newUID == "" {
+		return nil, fmt.Errorf("caldav: empty UID for the new series")
+	}
+	master, set, split, err := occurrenceTime(cal, recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	if !split.After(set.Start) {
+		return nil, fmt.Errorf("caldav: cannot split a series at its first occurrence")
+	}
+	loc := set.Start.Location()
+	start := master.Props.Get(ical.PropDateTimeStart)
+
+	// Compute the rules of both series before modifying anything
+	var pastRules, futureRules []*ical.Prop
+	for _, prop := range master.Props.Values(ical.PropRecurrenceRule) {
+		rule, err := prop.RecurrenceRule()
+		if err != nil {
+			return nil, err
+		}
+		earlier, hasFuture := 0, false
+		for occ := range rule.Occurrences(set.Start) {
+			if !occ.Before(split) {
+				hasFuture = true
+				break
+			}
+			earlier++
+		}
+		if !hasFuture {
+			pastRules = append(pastRules, prop)
+			continue
+		}
+
+		future := *rule
+		if future.Count > 0 {
+			future.Count -= earlier
+		}
+		futureProp := prop.Clone()
+		futureProp.SetRecurrenceRule(&future)
+		futureRules = append(futureRules, futureProp)
+
+		past := *rule
+		past.Count = 0
+		switch {
+		case start.IsDate():
+			past.Until, past.UntilDate, past.UntilFloating = split.AddDate(0, 0, -1), true, false
+		case !strings.HasSuffix(strings.TrimSpace(start.Value), "Z") && start.Params.Get(ical.ParamTimezoneID) == "":
+			past.Until, past.UntilDate, past.UntilFloating = split.Add(-time.Second), false, true
+		default:
+			past.Until, past.UntilDate, past.UntilFloating = split.Add(-time.Second).UTC(), false, false
+		}
+		pastProp := prop.Clone()
+		pastProp.SetRecurrenceRule(&past)
+		pastRules = append(pastRules, pastProp)
+	}
+
+	future := &ical.Calendar{Component: cal.Clone()}
+	futureMaster := future.Master()
+
+	// Truncate the original series
+	master.Props.Del(ical.PropRecurrenceRule)
+	for _, prop := range pastRules {
+		master.Props.Add(prop)
+	}
+	for _, name := range []string{ical.PropRecurrenceDates, ical.PropExceptionDates} {
+		filterDateLists(&master.Props, name, loc, func(t time.Time) bool { return t.Before(split) })
+	}
+	itip.IncrementSequence(master)
+	removeOverrides(cal, func(t time.Time) bool { return !t.Before(split) }, loc)
+
+	// Build the new series
+	futureMaster.Props.Del(ical.PropRecurrenceRule)
+	for _, prop := range futureRules {
+		futureMaster.Props.Add(prop)
+	}
+	for _, name := range []string{ical.PropRecurrenceDates, ical.PropExceptionDates} {
+		filterDateLists(&futureMaster.Props, name, loc, func(t time.Time) bool { return !t.Before(split) })
+	}
+	shiftStart(futureMaster, set.Start, split)
+	futureMaster.Props.Del(ical.PropSequence)
+	stamp := ical.NewProp(ical.PropDateTimeStamp)
+	stamp.SetDateTime(time.Now().UTC())
+	futureMaster.Props.Set(stamp)
+	removeOverrides(future, func(t time.Time) bool { return t.Before(split) }, loc)
+	for _, comp := range future.Children {
+		if comp.Name == ical.CompTimezone {
+			continue
+		}
+		comp.Props.SetText(ical.PropUID, newUID)
+		if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil {
+			rid.Params.Del(ical.ParamRange)
+		}
+	}
+	return future, nil
+}
+
+// shiftStart moves the DTSTART of comp from oldStart to newStart, keeping
+// its form, and shifts its DTEND or DUE to keep its duration.
+func shiftStart(comp *ical.Component, oldStart, newStart time.Time) {
+	comp.Props.Get(ical.PropDateTimeStart).ReplaceDateTime(newStart)
+	end := comp.Props.Get(ical.PropDateTimeEnd)
+	if end == nil {
+		end = comp.Props.Get(ical.PropDue)
+	}
+	if end == nil {
+		return
+	}
+	if endTime, err := end.DateTime(oldStart.Location()); err == nil {
+		end.ReplaceDateTime(newStart.Add(endTime.Sub(oldStart)))
+	}
+}
+
+// filterDateLists keeps the values of the DATE, DATE-TIME or PERIOD list
+// properties named name which match keep, removing properties left empty.
+// Values which can't be parsed are kept.
+func filterDateLists(props *ical.Props, name string, loc *time.Location, keep func(time.Time) bool) {
+	var kept []*ical.Prop
+	for _, prop := range props.Values(name) {
+		var values []string
+		for _, v := range strings.Split(prop.Value, ",") {
+			start, _, _ := strings.Cut(v, "/")
+			p := &ical.Prop{Name: prop.Name, Params: prop.Params, Value: start}
+			if prop.ValueType() == ical.ValuePeriod {
+				p.Params = ical.Params{ical.ParamTimezoneID: prop.Params.Values(ical.ParamTimezoneID)}
+			}
+			if t, err := p.DateTime(loc); err != nil || keep(t) {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			prop.Value = strings.Join(values, ",")
+			kept = append(kept, prop)
+		}
+	}
+	props.Del(name)
+	for _, prop := range kept {
+		props.Add(prop)
+	}
+}
+
+// UpdateOccurrence modifies a single occurrence of the recurring event
+// stored at eventPath: mutate is called on the component overriding the
+// occurrence, created with OverrideOccurrence if needed, and the object is
+// written back like UpdateCalendarObject.
+func (c *Client) UpdateOccurrence(ctx context.Context, eventPath string, recurrenceID *ical.Prop, mutate func(*ical.Component) error, opts *UpdateCalendarObjectOptions) (*CalendarObject, error) {
+	return c.UpdateCalendarObject(ctx, eventPath, func(cal *ical.Calendar) error {
+		override, err := OverrideOccurrence(cal, recurrenceID)
+		if err != nil {
+			return err
+		}
+		return mutate(override)
+	}, opts)
+}
+
+// CancelOccurrence cancels a single occurrence of the recurring event stored
+// at eventPath with ExcludeOccurrence, and writes it back like
+// UpdateCalendarObject.
+func (c *Client) CancelOccurrence(ctx context.Context, eventPath string, recurrenceID *ical.Prop, opts *UpdateCalendarObjectOptions) (*CalendarObject, error) {
+	return c.UpdateCalendarObject(ctx, eventPath, func(cal *ical.Calendar) error {
+		return ExcludeOccurrence(cal, recurrenceID)
+	}, opts)
+}
+
+// SplitSeriesOptions contains options for Client.SplitSeries.
+type SplitSeriesOptions struct {
+	// NewUID is the UID of the new series. When empty, a random UID is
+	// generated.
+	NewUID string
+
+	// Calendar is passed to CreateEventWithOptions.
+	Calendar *Calendar
+
+	// Update contains the options used to update the whole series when
+	// splitting at its first occurrence. Only its ScheduleReply field is used
+	// to truncate the original series otherwise.
+	Update *UpdateCalendarObjectOptions
+}
+
+// SplitSeriesResult is the result of Client.SplitSeries.
+type SplitSeriesResult struct {
+	// Original is the truncated original series.
+	Original *CalendarObject
+	// New is the new series, holding the modified occurrences. It's nil if
+	// the series was split at its first occurrence.
+	New *CalendarObject
+}
+
+// SplitSeries changes an occurrence of the recurring event stored at
+// eventPath and all the following ones. The series is split with SplitSeries
+// and mutate is called on the new series, which is created next to the
+// original one with CreateEventWithOptions. The original series is then
+// truncated with If-Match set to the ETag of the version the new series was
+// built from. If truncating fails, e.g. because the original series was
+// modified concurrently, the new series is deleted so that no occurrence is
+// duplicated, and the error is returned without retrying.
+//
+// When recurrenceID is the first occurrence, mutate is applied to the whole
+// series instead and only Original is set in the result.
+func (c *Client) SplitSeries(ctx context.Context, eventPath string, recurrenceID *ical.Prop, mutate func(*ical.Calendar) error, opts *SplitSeriesOptions) (*SplitSeriesResult, error) {
+	if opts == nil {
+		opts = new(SplitSeriesOptions)
+	}
+	newUID := opts.NewUID
+	if newUID == "" {
+		newUID = ical.NewUID()
+	}
+
+	current, err := c.GetCalendarObject(ctx, eventPath)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := current.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	_, set, split, err := occurrenceTime(cal, recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	if !split.After(set.Start) {
+		co, err := c.UpdateCalendarObject(ctx, eventPath, mutate, opts.Update)
+		if err != nil {
+			return nil, err
+		}
+		return &SplitSeriesResult{Original: co}, nil
+	}
+
+	if current.ETag == "" {
+		return nil, fmt.Errorf("caldav: server didn't return an ETag for %s, cannot split it safely", eventPath)
+	}
+
+	future, err := SplitSeries(&ical.Calendar{Component: cal.Clone()}, recurrenceID, newUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(future); err != nil {
+		return nil, err
+	}
+	// Both series are built from the same version of the original one
+	if _, err := SplitSeries(cal, recurrenceID, newUID); err != nil {
+		return nil, err
+	}
+	var truncated CalendarObject
+	if err := truncated.SetCalendar(cal); err != nil {
+		return nil, err
+	}
+	putOpts := &PutCalendarObjectOptions{IfMatch: current.ETag}
+	if opts.Update != nil {
+		putOpts.ScheduleReply = opts.Update.ScheduleReply
+	}
+
+	created, err := c.CreateEventWithOptions(ctx, path.Dir(eventPath), future, &CreateEventOptions{
+		Calendar: opts.Calendar,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := c.PutCalendarObject(ctx, eventPath, bytes.NewReader(truncated.Data), putOpts)
+	if err != nil {
+		delErr := c.DeleteCalendarObject(ctx, created.Path, &DeleteCalendarObjectOptions{IfMatch: created.ETag})
+		if delErr != nil {
+			return nil, fmt.Errorf("%w (and failed to delete the new series at %s: %v)", err, created.Path, delErr)
+		}
+		return nil, err
+	}
+	original.ContentType = truncated.ContentType
+	original.Data = truncated.Data
+	return &SplitSeriesResult{Original: original, New: created}, nil
+}
@@ -0,0 +1,415 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+const recurringEvent = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:series1\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240101T090000\r\n" +
+	"DTEND;TZID=Europe/Paris:20240101T093000\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=10\r\n" +
+	"EXDATE;TZID=Europe/Paris:20240103T090000,20240108T090000\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:series1\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Paris:20240102T090000\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240102T100000\r\n" +
+	"DTEND;TZID=Europe/Paris:20240102T103000\r\n" +
+	"SUMMARY:Late standup\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:series1\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Paris:20240107T090000\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240107T110000\r\n" +
+	"DTEND;TZID=Europe/Paris:20240107T113000\r\n" +
+	"SUMMARY:Sunday standup\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func decodeRecurringEvent(t *testing.T) *ical.Calendar {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(recurringEvent)).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return cal
+}
+
+func parisRecurrenceID(value string) *ical.Prop {
+	rid := ical.NewProp(ical.PropRecurrenceID)
+	rid.Params.Set(ical.ParamTimezoneID, "Europe/Paris")
+	rid.Value = value
+	return rid
+}
+
+func occurrences(t *testing.T, cal *ical.Calendar) []string {
+	t.Helper()
+	set, err := cal.Master().RecurrenceSet(nil)
+	if err != nil {
+		t.Fatalf("RecurrenceSet: %v", err)
+	}
+	var l []string
+	for occ := range set.All() {
+		l = append(l, occ.Format("20060102T150405"))
+		if len(l) > 20 {
+			break
+		}
+	}
+	return l
+}
+
+func TestOverrideOccurrence(t *testing.T) {
+	cal := decodeRecurringEvent(t)
+
+	existing, err := OverrideOccurrence(cal, parisRecurrenceID("20240102T090000"))
+	if err != nil {
+		t.Fatalf("OverrideOccurrence: %v", err)
+	}
+	if summary, _ := existing.Props.Text(ical.PropSummary); summary != "Late standup" {
+		t.Errorf("existing override wasn't returned, SUMMARY = %q", summary)
+	}
+
+	// RECURRENCE-ID values are compared as instants
+	rid := ical.NewProp(ical.PropRecurrenceID)
+	rid.Value = "20240104T080000Z"
+	override, err := OverrideOccurrence(cal, rid)
+	if err != nil {
+		t.Fatalf("OverrideOccurrence: %v", err)
+	}
+	if len(cal.Children) != 4 || cal.Children[3] != override {
+		t.Errorf("override wasn't added to the calendar")
+	}
+
+	for _, value := range []string{"20240103T090000", "20240104T100000", "20240111T090000"} {
+		if _, err := OverrideOccurrence(cal, parisRecurrenceID(value)); !errors.Is(err, ErrNoOccurrence) {
+			t.Errorf("OverrideOccurrence(%v) = %v, want ErrNoOccurrence", value, err)
+		}
+	}
+}
+
+func TestExcludeOccurrence(t *testing.T) {
+	cal := decodeRecurringEvent(t)
+
+	if err := ExcludeOccurrence(cal, parisRecurrenceID("20240102T090000")); err != nil {
+		t.Fatalf("ExcludeOccurrence: %v", err)
+	}
+	if len(cal.Children) != 2 {
+		t.Errorf("override of the excluded occurrence wasn't removed")
+	}
+	exdates := cal.Master().Props.Values(ical.PropExceptionDates)
+	if last := exdates[len(exdates)-1]; last.Value != "20240102T090000" || last.Params.Get(ical.ParamTimezoneID) != "Europe/Paris" {
+		t.Errorf("unexpected EXDATE %v %v", last.Params, last.Value)
+	}
+	want := "20240101T090000,20240104T090000,20240105T090000,20240106T090000,20240107T090000,20240109T090000,20240110T090000"
+	if got := strings.Join(occurrences(t, cal), ","); got != want {
+		t.Errorf("occurrences = %v, want %v", got, want)
+	}
+
+	if err := ExcludeOccurrence(cal, parisRecurrenceID("20240102T090000")); !errors.Is(err, ErrNoOccurrence) {
+		t.Errorf("excluding an occurrence twice = %v, want ErrNoOccurrence", err)
+	}
+}
+
+func TestSplitSeries(t *testing.T) {
+	cal := decodeRecurringEvent(t)
+
+	if _, err := SplitSeries(cal, parisRecurrenceID("20240101T090000"), "series2"); err == nil {
+		t.Errorf("splitting at the first occurrence succeeded")
+	}
+
+	future, err := SplitSeries(cal, parisRecurrenceID("20240106T090000"), "series2")
+	if err != nil {
+		t.Fatalf("SplitSeries: %v", err)
+	}
+
+	master := cal.Master()
+	if rrule := master.Props.Get(ical.PropRecurrenceRule).Value; rrule != "FREQ=DAILY;UNTIL=20240106T075959Z" {
+		t.Errorf("original RRULE = %v", rrule)
+	}
+	if exdate := master.Props.Get(ical.PropExceptionDates).Value; exdate != "20240103T090000" {
+		t.Errorf("original EXDATE = %v", exdate)
+	}
+	if seq, _ := master.Props.Get(ical.PropSequence).Int(); seq != 1 {
+		t.Errorf("original SEQUENCE = %v", seq)
+	}
+	if len(cal.Children) != 2 {
+		t.Errorf("original series has %d components, want 2", len(cal.Children))
+	}
+	if got, want := strings.Join(occurrences(t, cal), ","), "20240101T090000,20240102T090000,20240104T090000,20240105T090000"; got != want {
+		t.Errorf("original occurrences = %v, want %v", got, want)
+	}
+
+	futureMaster := future.Master()
+	if uid, _ := futureMaster.Props.Text(ical.PropUID); uid != "series2" {
+		t.Errorf("new series UID = %q", uid)
+	}
+	if rrule := futureMaster.Props.Get(ical.PropRecurrenceRule).Value; rrule != "FREQ=DAILY;COUNT=5" {
+		t.Errorf("new RRULE = %v", rrule)
+	}
+	if end := futureMaster.Props.Get(ical.PropDateTimeEnd).Value; end != "20240106T093000" {
+		t.Errorf("new DTEND = %v", end)
+	}
+	if len(future.Children) != 2 {
+		t.Fatalf("new series has %d components, want 2", len(future.Children))
+	}
+	if uid, _ := future.Children[1].Props.Text(ical.PropUID); uid != "series2" {
+		t.Errorf("moved override UID = %q", uid)
+	}
+	if got, want := strings.Join(occurrences(t, future), ","), "20240106T090000,20240107T090000,20240109T090000,20240110T090000"; got != want {
+		t.Errorf("new occurrences = %v, want %v", got, want)
+	}
+}
+
+func TestSplitSeriesDate(t *testing.T) {
+	cal, err := ical.NewDecoder(strings.NewReader("BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Test//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:birthday\r\n" +
+		"DTSTAMP:20240101T000000Z\r\n" +
+		"DTSTART;VALUE=DATE:20200301\r\n" +
+		"RRULE:FREQ=YEARLY\r\n" +
+		"RDATE;VALUE=DATE:20230401\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n")).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	rid := ical.NewProp(ical.PropRecurrenceID)
+	rid.SetValueType(ical.ValueDate)
+	rid.Value = "20230301"
+	future, err := SplitSeries(cal, rid, "birthday2")
+	if err != nil {
+		t.Fatalf("SplitSeries: %v", err)
+	}
+	if rrule := cal.Master().Props.Get(ical.PropRecurrenceRule).Value; rrule != "FREQ=YEARLY;UNTIL=20230228" {
+		t.Errorf("original RRULE = %v", rrule)
+	}
+	if cal.Master().Props.Get(ical.PropRecurrenceDates) != nil {
+		t.Errorf("RDATE after the split wasn't removed from the original series")
+	}
+	if start := future.Master().Props.Get(ical.PropDateTimeStart).Value; start != "20230301" {
+		t.Errorf("new DTSTART = %v", start)
+	}
+	if rdate := future.Master().Props.Get(ical.PropRecurrenceDates); rdate == nil || rdate.Value != "20230401" {
+		t.Errorf("RDATE after the split wasn't moved to the new series")
+	}
+}
+
+// collectionStore is a minimal server storing several calendar objects.
+type collectionStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+	version int
+	etags   map[string]string
+	// failPut makes PUT requests to the path fail.
+	failPut string
+	// afterPut is called after a successful PUT, to simulate concurrent
+	// modifications.
+	afterPut func(s *collectionStore, p string)
+}
+
+func (s *collectionStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := r.URL.Path
+	data, exists := s.objects[p]
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", MIMEType)
+		w.Header().Set("ETag", s.etags[p])
+		io.WriteString(w, data)
+	case http.MethodPut:
+		if p == s.failPut {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if (r.Header.Get("If-None-Match") == "*" && exists) || (r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != s.etags[p]) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		s.version++
+		s.objects[p] = string(b)
+		s.etags[p] = fmt.Sprintf(`"v%d"`, s.version)
+		w.Header().Set("ETag", s.etags[p])
+		w.WriteHeader(http.StatusCreated)
+		if s.afterPut != nil {
+			s.afterPut(s, p)
+		}
+	case http.MethodDelete:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != s.etags[p] {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		delete(s.objects, p)
+		delete(s.etags, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClientSplitSeries(t *testing.T) {
+	store := &collectionStore{
+		objects: map[string]string{"/cal/series1.ics": recurringEvent},
+		etags:   map[string]string{"/cal/series1.ics": `"v0"`},
+	}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	client, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("newTestClient: %v", err)
+	}
+
+	rename := func(cal *ical.Calendar) error {
+		cal.Master().Props.SetText(ical.PropSummary, "Planning")
+		return nil
+	}
+	opts := &SplitSeriesOptions{NewUID: "series2", Calendar: &Calendar{}}
+
+	store.failPut = "/cal/series1.ics"
+	if _, err := client.SplitSeries(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240106T090000"), rename, opts); err == nil {
+		t.Fatalf("SplitSeries succeeded although the original series couldn't be updated")
+	}
+	if len(store.objects) != 1 || store.objects["/cal/series1.ics"] != recurringEvent {
+		t.Fatalf("failed split wasn't rolled back: %v", store.objects)
+	}
+
+	// The original series is modified while the new series is created
+	store.failPut = ""
+	store.afterPut = func(s *collectionStore, p string) {
+		if p != "/cal/series1.ics" {
+			s.version++
+			s.etags["/cal/series1.ics"] = fmt.Sprintf(`"v%d"`, s.version)
+		}
+	}
+	_, err = client.SplitSeries(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240106T090000"), rename, opts)
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("SplitSeries = %v, want ErrPreconditionFailed", err)
+	}
+	if len(store.objects) != 1 || store.objects["/cal/series1.ics"] != recurringEvent {
+		t.Fatalf("split based on a stale version wasn't rolled back: %v", store.objects)
+	}
+
+	store.afterPut = nil
+	res, err := client.SplitSeries(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240106T090000"), rename, opts)
+	if err != nil {
+		t.Fatalf("SplitSeries: %v", err)
+	}
+	if res.New == nil || res.New.Path != "/cal/series2.ics" || res.Original.Path != "/cal/series1.ics" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if !strings.Contains(store.objects["/cal/series2.ics"], "SUMMARY:Planning") {
+		t.Errorf("mutation wasn't applied to the new series:\n%s", store.objects["/cal/series2.ics"])
+	}
+	if original := store.objects["/cal/series1.ics"]; !strings.Contains(original, "UNTIL=20240106T075959Z") || strings.Contains(original, "Planning") {
+		t.Errorf("original series wasn't truncated:\n%s", original)
+	}
+
+	// Splitting at the first occurrence modifies the whole series
+	res, err = client.SplitSeries(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240101T090000"), rename, opts)
+	if err != nil {
+		t.Fatalf("SplitSeries: %v", err)
+	}
+	if res.New != nil || !strings.Contains(store.objects["/cal/series1.ics"], "SUMMARY:Planning") || len(store.objects) != 2 {
+		t.Errorf("whole series wasn't modified: %+v", res)
+	}
+}
+
+func TestCancelOccurrence(t *testing.T) {
+	store := &objectStore{data: recurringEvent}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	client, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("newTestClient: %v", err)
+	}
+
+	if _, err := client.UpdateOccurrence(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240105T090000"), func(comp *ical.Component) error {
+		comp.Props.SetText(ical.PropLocation, "Room 2")
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("UpdateOccurrence: %v", err)
+	}
+	if !strings.Contains(store.data, "RECURRENCE-ID;TZID=Europe/Paris:20240105T090000") || !strings.Contains(store.data, "LOCATION:Room 2") {
+		t.Errorf("override wasn't stored:\n%s", store.data)
+	}
+
+	if _, err := client.CancelOccurrence(context.Background(), "/cal/series1.ics", parisRecurrenceID("20240105T090000"), nil); err != nil {
+		t.Fatalf("CancelOccurrence: %v", err)
+	}
+	if strings.Contains(store.data, "LOCATION:Room 2") || !strings.Contains(store.data, "EXDATE;TZID=Europe/Paris:20240105T090000") {
+		t.Errorf("occurrence wasn't cancelled:\n%s", store.data)
+	}
+}
+
+func TestOccurrenceTimezones(t *testing.T) {
+	if _, err := ical.LoadLocation("Europe/Paris"); err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	// Exchange uses Windows timezone names, Thunderbird prefixed IANA
+	// names, and other clients TZIDs only defined by a VTIMEZONE
+	for _, tzid := range []string{"Romance Standard Time", "/mozilla.org/20050126_1/Europe/Paris", "Custom"} {
+		data := strings.ReplaceAll(recurringEvent, "TZID=Europe/Paris", "TZID="+tzid)
+		data = strings.Replace(data, "BEGIN:VEVENT\r\n", "BEGIN:VTIMEZONE\r\n"+
+			"TZID:Custom\r\n"+
+			"BEGIN:STANDARD\r\n"+
+			"DTSTART:19700101T000000\r\n"+
+			"TZOFFSETFROM:+0100\r\n"+
+			"TZOFFSETTO:+0100\r\n"+
+			"END:STANDARD\r\n"+
+			"END:VTIMEZONE\r\n"+
+			"BEGIN:VEVENT\r\n", 1)
+		cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+
+		rid := parisRecurrenceID("20240104T090000")
+		rid.Params.Set(ical.ParamTimezoneID, tzid)
+		override, err := OverrideOccurrence(cal, rid)
+		if err != nil {
+			t.Errorf("OverrideOccurrence() with TZID %q = %v", tzid, err)
+			continue
+		}
+		if start := override.Props.Get(ical.PropDateTimeStart); start.Value != "20240104T090000" || start.Params.Get(ical.ParamTimezoneID) != tzid {
+			t.Errorf("unexpected DTSTART %v %v", start.Params, start.Value)
+		}
+
+		if _, err := OverrideOccurrence(cal, parisRecurrenceID("20240104T080000")); !errors.Is(err, ErrNoOccurrence) {
+			t.Errorf("OverrideOccurrence() with TZID %q = %v, want ErrNoOccurrence", tzid, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PushTransportWebPush is the type of WebDAV-Push Web Push transports.
+const PushTransportWebPush = "web-push"
+
+// PushTransport describes a push notification transport supported by the
+// server.
+//
+// WebDAV-Push transports are advertised by collections, CalendarServer ones
+// by the calendar home.
+type PushTransport struct {
+	// Type is PushTransportWebPush for WebDAV-Push, or the CalendarServer
+	// transport type, e.g. "APSD" or "XMPPServer".
+	Type string
+	// VAPIDPublicKey is the VAPID public key used by the server to sign Web
+	// Push messages, if any.
+	VAPIDPublicKey string
+
+	// The following fields are only set for CalendarServer transports.
+	SubscriptionURL string
+	APSBundleID     string
+	APSEnvironment  string
+	RefreshInterval time.Duration
+	XMPPServer      string
+	XMPPURI         string
+}
+
+func newWebPushTransports(transports *pushTransports) []PushTransport {
+	if transports.WebPush == nil {
+		return nil
+	}
+	t := PushTransport{Type: PushTransportWebPush}
+	if transports.WebPush.VAPIDPublicKey != nil {
+		t.VAPIDPublicKey = strings.TrimSpace(transports.WebPush.VAPIDPublicKey.Key)
+	}
+	return []PushTransport{t}
+}
+
+func newCalendarServerPushTransports(transports *calendarServerPushTransports) []PushTransport {
+	var l []PushTransport
+	for _, raw := range transports.Transports {
+		t := PushTransport{
+			Type:           raw.Type,
+			APSBundleID:    strings.TrimSpace(raw.APSBundleID),
+			APSEnvironment: strings.TrimSpace(raw.Env),
+			XMPPServer:     strings.TrimSpace(raw.XMPPServer),
+			XMPPURI:        strings.TrimSpace(raw.XMPPURI),
+		}
+		if raw.SubscriptionURL != nil {
+			t.SubscriptionURL = raw.SubscriptionURL.Href.String()
+		}
+		if secs, err := strconv.Atoi(strings.TrimSpace(raw.RefreshInterval)); err == nil {
+			t.RefreshInterval = time.Duration(secs) * time.Second
+		}
+		l = append(l, t)
+	}
+	return l
+}
+
+// PushSubscriptionOptions contains options for RegisterPush.
+type PushSubscriptionOptions struct {
+	// Expires is the requested expiration time of the subscription. The
+	// server may pick a different one.
+	Expires time.Time
+	// PublicKey and AuthSecret are the base64url-encoded P-256 public key and
+	// authentication secret used by the server to encrypt push messages, as
+	// defined in RFC 8291. When empty, push messages are sent unencrypted.
+	// PushKeys.SubscriptionOptions fills them in for a PushHandler.
+	PublicKey  string
+	AuthSecret string
+}
+
+// PushSubscription is a WebDAV-Push subscription registered on the server.
+type PushSubscription struct {
+	// URL identifies the subscription. It can be passed to UnregisterPush.
+	URL string
+	// Expires is the expiration time of the subscription, zero if unknown.
+	Expires time.Time
+}
+
+// RegisterPush subscribes pushResource to WebDAV-Push messages for the
+// collection at path. pushResource is typically a Web Push endpoint or a
+// webhook served by a PushHandler.
+//
+// Registering an existing push resource again updates the subscription.
+func (c *Client) RegisterPush(ctx context.Context, path, pushResource string, opts *PushSubscriptionOptions) (*PushSubscription, error) {
+	if opts == nil {
+		opts = new(PushSubscriptionOptions)
+	}
+
+	sub := &webPushSubscription{
+		PushResource: pushResource,
+		AuthSecret:   opts.AuthSecret,
+	}
+	if opts.PublicKey != "" {
+		sub.SubscriptionPublicKey = &subscriptionPublicKey{Type: "p256dh", Key: opts.PublicKey}
+	}
+	body := pushRegister{Subscription: pushSubscription{WebPush: sub}}
+	if !opts.Expires.IsZero() {
+		body.Expires = opts.Expires.UTC().Format(http.TimeFormat)
+	}
+
+	req, err := c.ic.NewXMLRequest(http.MethodPost, path, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.ic.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	loc, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("caldav: push subscription registration response has no Location: %w", err)
+	}
+	result := &PushSubscription{URL: loc.String()}
+	if s := resp.Header.Get("Expires"); s != "" {
+		if t, err := http.ParseTime(s); err == nil {
+			result.Expires = t
+		}
+	}
+	return result, nil
+}
+
+// UnregisterPush removes a subscription registered with RegisterPush.
+func (c *Client) UnregisterPush(ctx context.Context, subscriptionURL string) error {
+	u, err := c.ic.ResolveHref("/").Parse(subscriptionURL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.ic.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PushNotification is a WebDAV-Push message received by a PushHandler.
+type PushNotification struct {
+	Topic string
+	// Path is the path of the collection registered for Topic.
+	Path string
+	// ContentChanged is set if members of the collection have changed.
+	// SyncToken is the new sync token of the collection, if the server
+	// provided it.
+	ContentChanged bool
+	SyncToken      string
+	// PropertiesChanged is set if properties of the collection have changed.
+	PropertiesChanged bool
+}
+
+const defaultPushMaxBodySize = 64 * 1024
+
+// PushHandler is an http.Handler receiving WebDAV-Push messages delivered to
+// a webhook registered with RegisterPush.
+//
+// Messages are validated and mapped back to the collection path registered
+// for their topic with AddTopic. Messages for unknown topics are rejected
+// with 404 Not Found, which asks the server to drop the subscription.
+//
+// Without Keys, messages aren't authenticated: anyone able to reach the
+// handler can forge them, so they should only be used as a hint to sync.
+type PushHandler struct {
+	// MaxBodySize is the maximum size of a push message in bytes. It
+	// defaults to 64 KiB.
+	MaxBodySize int64
+	// Keys decrypts messages encrypted with the aes128gcm content encoding,
+	// as defined in RFC 8291, which also authenticates them. When set,
+	// unencrypted messages are rejected. When nil, encrypted messages are
+	// rejected.
+	Keys *PushKeys
+
+	fn     func(ctx context.Context, n *PushNotification)
+	mu     sync.RWMutex
+	topics map[string]string
+}
+
+// NewPushHandler creates a PushHandler calling fn for each valid push
+// message. fn is called synchronously and should return quickly.
+func NewPushHandler(fn func(ctx context.Context, n *PushNotification)) *PushHandler {
+	return &PushHandler{fn: fn, topics: make(map[string]string)}
+}
+
+// AddTopic registers the collection path for a push topic, as reported in
+// Calendar.PushTopic.
+func (h *PushHandler) AddTopic(topic, path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.topics[topic] = path
+}
+
+// RemoveTopic unregisters a push topic.
+func (h *PushHandler) RemoveTopic(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.topics, topic)
+}
+
+func (h *PushHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Only accept the encoding matching the configuration: unencrypted
+	// messages would bypass authentication
+	encrypted := h.Keys != nil
+	switch enc := r.Header.Get("Content-Encoding"); {
+	case (enc == "" || strings.EqualFold(enc, "identity")) && !encrypted:
+	case strings.EqualFold(enc, pushContentEncoding) && encrypted:
+	default:
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" && !encrypted {
+		t, _, err := mime.ParseMediaType(ct)
+		if err != nil || (t != "application/xml" && t != "text/xml") {
+			http.Error(w, "unsupported media type", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	maxBodySize := h.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultPushMaxBodySize
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "push message too large", http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, "failed to read push message", http.StatusBadRequest)
+		return
+	}
+	if encrypted {
+		if body, err = h.Keys.decrypt(body); err != nil {
+			http.Error(w, "failed to decrypt push message", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var msg pushMessage
+	if err := xml.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "malformed push message", http.StatusBadRequest)
+		return
+	}
+
+	topic := strings.TrimSpace(msg.Topic)
+	if topic == "" {
+		http.Error(w, "missing push topic", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.RLock()
+	path, ok := h.topics[topic]
+	h.mu.RUnlock()
+	if !ok {
+		http.Error(w, "unknown push topic", http.StatusNotFound)
+		return
+	}
+
+	n := &PushNotification{
+		Topic:             topic,
+		Path:              path,
+		PropertiesChanged: msg.PropertyUpdate != nil,
+	}
+	if msg.ContentUpdate != nil {
+		n.ContentChanged = true
+		n.SyncToken = strings.TrimSpace(msg.ContentUpdate.SyncToken)
+	}
+	// Older servers don't say what changed
+	if !n.ContentChanged && !n.PropertiesChanged {
+		n.ContentChanged = true
+	}
+
+	if h.fn != nil {
+		h.fn(r.Context(), n)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,313 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushDiscoveryAndRegistration(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	var unregistered bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PROPFIND" && r.URL.Path == "/home/":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:response>
+    <d:href>/home/</d:href>
+    <d:propstat>
+      <d:prop>
+        <cs:push-transports>
+          <cs:transport type="APSD">
+            <cs:subscription-url><d:href>https://example.com/apns</d:href></cs:subscription-url>
+            <cs:apsbundleid>com.example.calendar</cs:apsbundleid>
+            <cs:env>PRODUCTION</cs:env>
+            <cs:refresh-interval>172800</cs:refresh-interval>
+          </cs:transport>
+        </cs:push-transports>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/home/work/":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav" xmlns:p="https://bitfire.at/webdav-push" xmlns:cs="http://calendarserver.org/ns/">
+  <d:response>
+    <d:href>/home/work/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <p:transports><p:web-push><p:vapid-public-key type="p256ecdsa">BF5oEo0xDUpg</p:vapid-public-key></p:web-push></p:transports>
+        <p:topic>topic-1</p:topic>
+        <cs:pushkey>/CalDAV/example.com/work/</cs:pushkey>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`))
+		case r.Method == http.MethodPost && r.URL.Path == "/home/work/":
+			var reg pushRegister
+			if err := xml.NewDecoder(r.Body).Decode(&reg); err != nil {
+				t.Errorf("decode push-register: %v", err)
+			}
+			sub := reg.Subscription.WebPush
+			if sub == nil || sub.PushResource != "https://hooks.example.com/push" {
+				t.Errorf("unexpected subscription: %+v", sub)
+			}
+			if reg.Expires != expires.Format(http.TimeFormat) {
+				t.Errorf("got expires %q", reg.Expires)
+			}
+			w.Header().Set("Location", "/push/sub-1")
+			w.Header().Set("Expires", expires.Format(http.TimeFormat))
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/push/sub-1":
+			unregistered = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	home, err := c.GetCalendarHome(ctx, "/home/")
+	if err != nil {
+		t.Fatalf("GetCalendarHome error: %v", err)
+	}
+	if len(home.PushTransports) != 1 {
+		t.Fatalf("unexpected transports: %+v", home.PushTransports)
+	}
+	if tr := home.PushTransports[0]; tr.Type != "APSD" || tr.SubscriptionURL != "https://example.com/apns" || tr.APSBundleID != "com.example.calendar" || tr.RefreshInterval != 48*time.Hour {
+		t.Errorf("unexpected transport: %+v", tr)
+	}
+
+	cal, err := c.GetCalendar(ctx, "/home/work/")
+	if err != nil {
+		t.Fatalf("GetCalendar error: %v", err)
+	}
+	if cal.PushTopic != "topic-1" || cal.PushKey != "/CalDAV/example.com/work/" {
+		t.Errorf("unexpected calendar: %+v", cal)
+	}
+	if len(cal.PushTransports) != 1 || cal.PushTransports[0].Type != PushTransportWebPush || cal.PushTransports[0].VAPIDPublicKey != "BF5oEo0xDUpg" {
+		t.Errorf("unexpected transports: %+v", cal.PushTransports)
+	}
+
+	sub, err := c.RegisterPush(ctx, "/home/work/", "https://hooks.example.com/push", &PushSubscriptionOptions{Expires: expires})
+	if err != nil {
+		t.Fatalf("RegisterPush error: %v", err)
+	}
+	if sub.URL != ts.URL+"/push/sub-1" || !sub.Expires.Equal(expires) {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+
+	if err := c.UnregisterPush(ctx, sub.URL); err != nil {
+		t.Fatalf("UnregisterPush error: %v", err)
+	}
+	if !unregistered {
+		t.Errorf("subscription wasn't deleted")
+	}
+}
+
+func TestPushHandler(t *testing.T) {
+	var got []*PushNotification
+	h := NewPushHandler(func(ctx context.Context, n *PushNotification) {
+		got = append(got, n)
+	})
+	h.AddTopic("topic-1", "/home/work/")
+
+	for _, tc := range []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"content update", http.MethodPost, "application/xml", `<push-message xmlns="https://bitfire.at/webdav-push" xmlns:d="DAV:"><topic>topic-1</topic><content-update><d:sync-token>tok-2</d:sync-token></content-update></push-message>`, http.StatusNoContent},
+		{"property update", http.MethodPost, "", `<push-message xmlns="https://bitfire.at/webdav-push"><topic>topic-1</topic><property-update/></push-message>`, http.StatusNoContent},
+		{"unknown topic", http.MethodPost, "text/xml", `<push-message xmlns="https://bitfire.at/webdav-push"><topic>other</topic></push-message>`, http.StatusNotFound},
+		{"malformed", http.MethodPost, "text/xml", `<push-message`, http.StatusBadRequest},
+		{"missing topic", http.MethodPost, "text/xml", `<push-message xmlns="https://bitfire.at/webdav-push"/>`, http.StatusBadRequest},
+		{"wrong type", http.MethodPost, "application/json", `{}`, http.StatusUnsupportedMediaType},
+		{"wrong method", http.MethodGet, "", ``, http.StatusMethodNotAllowed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/webhook", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("got status %v, want %v", rec.Code, tc.status)
+			}
+		})
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(got))
+	}
+	if n := got[0]; n.Path != "/home/work/" || !n.ContentChanged || n.SyncToken != "tok-2" || n.PropertiesChanged {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+	if n := got[1]; n.ContentChanged || !n.PropertiesChanged {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+
+	h.RemoveTopic("topic-1")
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`<push-message xmlns="https://bitfire.at/webdav-push"><topic>topic-1</topic></push-message>`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %v after RemoveTopic", rec.Code)
+	}
+}
+
+// encryptPushMessage encrypts a push message in a single record, as done by
+// the server.
+func encryptPushMessage(t *testing.T, keys *PushKeys, plaintext []byte) []byte {
+	senderKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := senderKey.ECDH(keys.PrivateKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := make([]byte, pushSaltSize)
+	rand.Read(salt)
+
+	keyInfo := append([]byte("WebPush: info\x00"), keys.PrivateKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, senderKey.PublicKey().Bytes()...)
+	ikm := hkdf(keys.AuthSecret, secret, keyInfo, 32)
+	block, _ := aes.NewCipher(hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), pushKeySize))
+	aead, _ := cipher.NewGCM(block)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), pushNonceSize)
+	record := aead.Seal(nil, nonce, append(plaintext, 2, 0, 0), nil)
+
+	var buf bytes.Buffer
+	buf.Write(salt)
+	binary.Write(&buf, binary.BigEndian, uint32(len(record)))
+	buf.WriteByte(byte(len(senderKey.PublicKey().Bytes())))
+	buf.Write(senderKey.PublicKey().Bytes())
+	buf.Write(record)
+	return buf.Bytes()
+}
+
+// TestPushKeysDecrypt checks the example from RFC 8291 section 5.
+func TestPushKeysDecrypt(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	priv, err := ecdh.P256().NewPrivateKey(decode("q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &PushKeys{PrivateKey: priv, AuthSecret: decode("BTBZMqHH6r4Tts7J_aSIgg")}
+
+	opts := keys.SubscriptionOptions()
+	if opts.PublicKey != "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4" || opts.AuthSecret != "BTBZMqHH6r4Tts7J_aSIgg" {
+		t.Errorf("unexpected subscription options: %+v", opts)
+	}
+
+	body := decode("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+	plaintext, err := keys.decrypt(body)
+	if err != nil {
+		t.Fatalf("decrypt() = %v", err)
+	}
+	if string(plaintext) != "When I grow up, I want to be a watermelon" {
+		t.Errorf("decrypt() = %q", plaintext)
+	}
+
+	body[len(body)-1] ^= 1
+	if _, err := keys.decrypt(body); err == nil {
+		t.Errorf("decrypt() succeeded on a corrupted message")
+	}
+}
+
+func TestPushHandlerEncrypted(t *testing.T) {
+	keys, err := GeneratePushKeys()
+	if err != nil {
+		t.Fatalf("GeneratePushKeys() = %v", err)
+	}
+
+	var got []*PushNotification
+	h := NewPushHandler(func(ctx context.Context, n *PushNotification) {
+		got = append(got, n)
+	})
+	h.AddTopic("topic-1", "/home/work/")
+
+	body := encryptPushMessage(t, keys, []byte(`<push-message xmlns="https://bitfire.at/webdav-push"><topic>topic-1</topic><content-update/></push-message>`))
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", "aes128gcm")
+		req.Header.Set("Content-Type", "application/octet-stream")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(); code != http.StatusUnsupportedMediaType {
+		t.Errorf("got status %v without keys, want %v", code, http.StatusUnsupportedMediaType)
+	}
+
+	h.Keys = keys
+	if code := serve(); code != http.StatusNoContent {
+		t.Errorf("got status %v, want %v", code, http.StatusNoContent)
+	}
+	if len(got) != 1 || got[0].Path != "/home/work/" || !got[0].ContentChanged {
+		t.Errorf("unexpected notifications: %+v", got)
+	}
+
+	// Unencrypted messages could be forged
+	for _, enc := range []string{"", "identity"} {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`<push-message xmlns="https://bitfire.at/webdav-push"><topic>topic-1</topic></push-message>`))
+		if enc != "" {
+			req.Header.Set("Content-Encoding", enc)
+		}
+		req.Header.Set("Content-Type", "application/xml")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("got status %v for an unencrypted message with Content-Encoding %q, want %v", rec.Code, enc, http.StatusUnsupportedMediaType)
+		}
+	}
+	if len(got) != 1 {
+		t.Errorf("unexpected notifications: %+v", got)
+	}
+
+	other, err := GeneratePushKeys()
+	if err != nil {
+		t.Fatalf("GeneratePushKeys() = %v", err)
+	}
+	h.Keys = other
+	if code := serve(); code != http.StatusBadRequest {
+		t.Errorf("got status %v with the wrong keys, want %v", code, http.StatusBadRequest)
+	}
+}
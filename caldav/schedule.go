@@ -0,0 +1,97 @@
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/itip"
+)
+
+// ApplyReply applies an iTIP REPLY message to the organizer's copy of an
+// event stored at path, with itip.ApplyReply, and writes it back with
+// If-Match. Concurrent modifications are handled like UpdateCalendarObject.
+//
+// The returned error wraps itip.ErrOutdated if the reply was superseded.
+func (c *Client) ApplyReply(ctx context.Context, path string, reply *itip.Message, opts *UpdateCalendarObjectOptions) (*CalendarObject, error) {
+	return c.UpdateCalendarObject(ctx, path, func(cal *ical.Calendar) error {
+		return itip.ApplyReply(cal, reply)
+	}, opts)
+}
+
+// RespondToInvitationOptions contains options for RespondToInvitation.
+type RespondToInvitationOptions struct {
+	// RecurrenceID restricts the response to a single occurrence of a
+	// recurring event. An overridden component is created for the occurrence
+	// if needed. When nil, the response applies to the whole series.
+	RecurrenceID *ical.Prop
+
+	// Comment is an optional note to the organizer, set as the COMMENT of the
+	// updated components.
+	Comment string
+
+	// ScheduleReply is sent as the Schedule-Reply header. Leave it nil to
+	// let a server supporting scheduling (RFC 6638) deliver the reply to the
+	// organizer, or set it to false when the reply is delivered by other
+	// means, e.g. with iMIP.
+	ScheduleReply *bool
+
+	// MaxRetries is passed to UpdateCalendarObject.
+	MaxRetries int
+}
+
+// RespondToInvitation sets the participation status of an attendee of the
+// event stored at eventPath, bumps its DTSTAMP and writes it back with
+// If-Match, retrying on concurrent modifications like UpdateCalendarObject.
+//
+// For the whole series, all components the attendee is invited to are
+// updated. To deliver the reply by email, build it from the returned object
+// with itip.NewReply.
+func (c *Client) RespondToInvitation(ctx context.Context, eventPath, attendeeAddress string, partstat itip.PartStat, opts *RespondToInvitationOptions) (*CalendarObject, error) {
+	if opts == nil {
+		opts = new(RespondToInvitationOptions)
+	}
+
+	return c.UpdateCalendarObject(ctx, eventPath, func(cal *ical.Calendar) error {
+		var targets []*ical.Component
+		if opts.RecurrenceID == nil {
+			for _, comp := range cal.Children {
+				if comp.Name != ical.CompTimezone && itip.FindAttendee(comp, attendeeAddress) != nil {
+					targets = append(targets, comp)
+				}
+			}
+			if len(targets) == 0 {
+				return fmt.Errorf("%w: %s", itip.ErrUnknownAttendee, attendeeAddress)
+			}
+		} else {
+			target := cal.Override(opts.RecurrenceID)
+			if target == nil {
+				master := cal.Master()
+				if master == nil {
+					return fmt.Errorf("caldav: %s has no occurrence with RECURRENCE-ID %s", eventPath, opts.RecurrenceID.Value)
+				}
+				target = ical.NewOverride(master, opts.RecurrenceID)
+				cal.Children = append(cal.Children, target)
+			}
+			targets = append(targets, target)
+		}
+
+		now := time.Now().UTC()
+		for _, comp := range targets {
+			if err := itip.SetPartStat(comp, attendeeAddress, partstat); err != nil {
+				return err
+			}
+			stamp := ical.NewProp(ical.PropDateTimeStamp)
+			stamp.SetDateTime(now)
+			comp.Props.Set(stamp)
+			if opts.Comment != "" {
+				comp.Props.SetText(ical.PropComment, opts.Comment)
+			}
+		}
+		return nil
+	}, &UpdateCalendarObjectOptions{
+		MaxRetries:    opts.MaxRetries,
+		ScheduleReply: opts.ScheduleReply,
+	})
+}
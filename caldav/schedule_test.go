@@ -0,0 +1,129 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/itip"
+)
+
+const scheduledEvent = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:event1\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"DTSTART:20240102T090000Z\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"ORGANIZER:mailto:alice@example.com\r\n" +
+	"ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:bob@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestApplyReply(t *testing.T) {
+	store := &objectStore{data: scheduledEvent}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	client, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("newTestClient: %v", err)
+	}
+
+	cal, err := ical.NewDecoder(strings.NewReader(scheduledEvent)).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if err := itip.SetPartStat(cal.Children[0], "mailto:bob@example.com", itip.PartStatAccepted); err != nil {
+		t.Fatalf("SetPartStat: %v", err)
+	}
+	reply, err := itip.NewReply(cal, "mailto:bob@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewReply: %v", err)
+	}
+
+	co, err := client.ApplyReply(context.Background(), "/cal/event1.ics", reply, nil)
+	if err != nil {
+		t.Fatalf("ApplyReply: %v", err)
+	}
+	if co.ETag != "v1" || !strings.Contains(store.data, "PARTSTAT=ACCEPTED") {
+		t.Errorf("reply wasn't written back: ETag %q, data:\n%s", co.ETag, store.data)
+	}
+
+	// Applying the same reply again is a no-op
+	if _, err := client.ApplyReply(context.Background(), "/cal/event1.ics", reply, nil); !errors.Is(err, itip.ErrOutdated) {
+		t.Errorf("expected ErrOutdated, got %v", err)
+	}
+	if store.puts != 1 {
+		t.Errorf("expected 1 PUT, got %d", store.puts)
+	}
+}
+
+func TestRespondToInvitation(t *testing.T) {
+	store := &objectStore{data: strings.Replace(scheduledEvent, "SUMMARY:Standup\r\n", "SUMMARY:Standup\r\nRRULE:FREQ=DAILY\r\n", 1)}
+	var scheduleReply string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			scheduleReply = r.Header.Get("Schedule-Reply")
+		}
+		store.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("newTestClient: %v", err)
+	}
+
+	rid := ical.NewProp(ical.PropRecurrenceID)
+	rid.Value = "20240103T090000Z"
+	noReply := false
+	co, err := client.RespondToInvitation(context.Background(), "/cal/event1.ics", "mailto:Bob@example.com", itip.PartStatDeclined, &RespondToInvitationOptions{
+		RecurrenceID:  rid,
+		ScheduleReply: &noReply,
+	})
+	if err != nil {
+		t.Fatalf("RespondToInvitation: %v", err)
+	}
+	if scheduleReply != "F" {
+		t.Errorf("Schedule-Reply = %q, want F", scheduleReply)
+	}
+
+	cal, err := co.Calendar()
+	if err != nil {
+		t.Fatalf("Calendar: %v", err)
+	}
+	override := cal.Override(rid)
+	if override == nil {
+		t.Fatalf("no override was created:\n%s", co.Data)
+	}
+	if att := itip.FindAttendee(override, "mailto:bob@example.com"); att.Params.Get(ical.ParamParticipationStatus) != "DECLINED" {
+		t.Errorf("override PARTSTAT = %q", att.Params.Get(ical.ParamParticipationStatus))
+	}
+	if stamp := override.Props.Get(ical.PropDateTimeStamp); stamp.Value == "20240101T000000Z" {
+		t.Errorf("DTSTAMP wasn't bumped")
+	}
+	if att := itip.FindAttendee(cal.Master(), "mailto:bob@example.com"); att.Params.Get(ical.ParamParticipationStatus) != "NEEDS-ACTION" {
+		t.Errorf("master PARTSTAT = %q", att.Params.Get(ical.ParamParticipationStatus))
+	}
+
+	// Responding to the whole series updates all components
+	if _, err := client.RespondToInvitation(context.Background(), "/cal/event1.ics", "mailto:bob@example.com", itip.PartStatAccepted, nil); err != nil {
+		t.Fatalf("RespondToInvitation: %v", err)
+	}
+	if scheduleReply != "" {
+		t.Errorf("Schedule-Reply = %q, want none", scheduleReply)
+	}
+	if n := strings.Count(store.data, "PARTSTAT=ACCEPTED"); n != 2 {
+		t.Errorf("expected 2 accepted components, got %d:\n%s", n, store.data)
+	}
+
+	if _, err := client.RespondToInvitation(context.Background(), "/cal/event1.ics", "mailto:carol@example.com", itip.PartStatAccepted, nil); !errors.Is(err, itip.ErrUnknownAttendee) {
+		t.Errorf("expected ErrUnknownAttendee, got %v", err)
+	}
+}
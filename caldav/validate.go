@@ -0,0 +1,262 @@
+package caldav
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// Diagnostic is a problem found by ValidateCalendarObject.
+type Diagnostic struct {
+	// Line is the line number the problem was found on, zero if unknown.
+	Line int
+	// Component and Property are the names of the offending component and
+	// property, if any.
+	Component string
+	Property  string
+	Message   string
+}
+
+func (d Diagnostic) String() string {
+	var sb strings.Builder
+	if d.Line > 0 {
+		fmt.Fprintf(&sb, "line %d: ", d.Line)
+	}
+	if d.Component != "" {
+		sb.WriteString(d.Component)
+		if d.Property != "" {
+			sb.WriteString(" ")
+			sb.WriteString(d.Property)
+		}
+		sb.WriteString(": ")
+	} else if d.Property != "" {
+		sb.WriteString(d.Property)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(d.Message)
+	return sb.String()
+}
+
+// ValidationError is returned when a calendar object fails validation.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (err *ValidationError) Error() string {
+	if len(err.Diagnostics) == 1 {
+		return "caldav: invalid calendar object: " + err.Diagnostics[0].String()
+	}
+	return fmt.Sprintf("caldav: invalid calendar object: %s (and %d more problems)", err.Diagnostics[0].String(), len(err.Diagnostics)-1)
+}
+
+// ValidateCalendarData parses iCalendar data and checks it with
+// ValidateCalendarObject. Syntax errors are reported as diagnostics too.
+func ValidateCalendarData(data []byte) []Diagnostic {
+	dec := ical.NewDecoder(bytes.NewReader(data))
+	cal, err := dec.Decode()
+	if err != nil {
+		return []Diagnostic{syntaxDiagnostic(err)}
+	}
+
+	diags := ValidateCalendarObject(cal)
+	if _, err := dec.Decode(); err != io.EOF {
+		d := Diagnostic{Message: "calendar object resources must contain a single VCALENDAR"}
+		if err != nil {
+			d = syntaxDiagnostic(err)
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func syntaxDiagnostic(err error) Diagnostic {
+	var syntaxErr *ical.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Diagnostic{Line: syntaxErr.Line, Message: syntaxErr.Msg}
+	}
+	if err == io.EOF {
+		return Diagnostic{Message: "no VCALENDAR component"}
+	}
+	return Diagnostic{Message: strings.TrimPrefix(err.Error(), "ical: ")}
+}
+
+// ValidateCalendarObject checks that cal is a valid calendar object resource,
+// as defined in RFC 5545 and RFC 4791 section 4.1:
+//
+//   - VERSION and PRODID are present, METHOD is absent;
+//   - all components other than VTIMEZONE have the same type and UID, and at
+//     most one of them isn't an overridden instance;
+//   - UID and DTSTAMP are present;
+//   - DTEND or DUE are after DTSTART;
+//   - all TZID parameters refer to a VTIMEZONE component.
+//
+// It returns nil if no problem was found.
+func ValidateCalendarObject(cal *ical.Calendar) []Diagnostic {
+	v := validator{timezones: make(map[string]bool)}
+	v.validate(cal.Component)
+	return v.diags
+}
+
+type validator struct {
+	diags     []Diagnostic
+	timezones map[string]bool
+}
+
+func (v *validator) addf(comp *ical.Component, prop *ical.Prop, format string, args ...interface{}) {
+	d := Diagnostic{Message: fmt.Sprintf(format, args...)}
+	if comp != nil {
+		d.Component = comp.Name
+		d.Line = comp.Line
+	}
+	if prop != nil {
+		d.Property = prop.Name
+		d.Line = prop.Line
+	}
+	v.diags = append(v.diags, d)
+}
+
+func (v *validator) validate(cal *ical.Component) {
+	if cal.Name != ical.CompCalendar {
+		v.addf(cal, nil, "expected %s component", ical.CompCalendar)
+		return
+	}
+	for _, name := range []string{ical.PropVersion, ical.PropProductID} {
+		if cal.Props.Get(name) == nil {
+			v.addf(cal, nil, "missing required %s property", name)
+		}
+	}
+	if prop := cal.Props.Get(ical.PropMethod); prop != nil {
+		v.addf(cal, prop, "METHOD isn't allowed in calendar object resources")
+	}
+
+	for _, tz := range cal.ChildrenByName(ical.CompTimezone) {
+		tzid, _ := tz.Props.Text(ical.PropTimezoneID)
+		if tzid == "" {
+			v.addf(tz, nil, "missing required TZID property")
+			continue
+		}
+		v.timezones[tzid] = true
+	}
+
+	var (
+		compType  string
+		uid       string
+		master    *ical.Component
+		instances = make(map[string]bool)
+	)
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompTimezone {
+			continue
+		}
+
+		if compType == "" {
+			compType = comp.Name
+		} else if comp.Name != compType {
+			v.addf(comp, nil, "calendar object resources can't mix %s and %s components", compType, comp.Name)
+		}
+
+		compUID, _ := comp.Props.Text(ical.PropUID)
+		if uid == "" {
+			uid = compUID
+		} else if compUID != "" && compUID != uid {
+			v.addf(comp, comp.Props.Get(ical.PropUID), "UID %q differs from %q, calendar object resources can only contain one UID", compUID, uid)
+		}
+
+		if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil {
+			key := strings.TrimSpace(rid.Value)
+			if instances[key] {
+				v.addf(comp, rid, "duplicate RECURRENCE-ID %s", key)
+			}
+			instances[key] = true
+		} else if master != nil {
+			v.addf(comp, nil, "several components without RECURRENCE-ID")
+		} else {
+			master = comp
+		}
+
+		v.validateComponent(comp)
+	}
+	if compType == "" {
+		v.addf(cal, nil, "calendar object resources must contain a component other than VTIMEZONE")
+	}
+}
+
+func (v *validator) validateComponent(comp *ical.Component) {
+	switch comp.Name {
+	case ical.CompEvent, ical.CompToDo, ical.CompJournal, ical.CompFreeBusy:
+		for _, name := range []string{ical.PropUID, ical.PropDateTimeStamp} {
+			if prop := comp.Props.Get(name); prop == nil || strings.TrimSpace(prop.Value) == "" {
+				v.addf(comp, nil, "missing required %s property", name)
+			}
+		}
+	}
+
+	switch comp.Name {
+	case ical.CompEvent:
+		v.validateEnd(comp, ical.PropDateTimeEnd)
+	case ical.CompToDo:
+		v.validateEnd(comp, ical.PropDue)
+	}
+
+	v.validateTimezoneRefs(comp)
+}
+
+// validateEnd checks that the end property (DTEND or DUE) of a component is
+// after its DTSTART.
+func (v *validator) validateEnd(comp *ical.Component, endName string) {
+	end := comp.Props.Get(endName)
+	if end == nil {
+		return
+	}
+	if comp.Props.Get(ical.PropDuration) != nil {
+		v.addf(comp, end, "%s and DURATION can't both be set", endName)
+	}
+	start := comp.Props.Get(ical.PropDateTimeStart)
+	if start == nil {
+		if comp.Name == ical.CompEvent {
+			v.addf(comp, end, "%s requires DTSTART", endName)
+		}
+		return
+	}
+	if start.IsDate() != end.IsDate() {
+		v.addf(comp, end, "%s and DTSTART must have the same value type", endName)
+		return
+	}
+
+	startTime, startErr := start.DateTime(nil)
+	endTime, endErr := end.DateTime(nil)
+	if startErr != nil || endErr != nil {
+		// Unknown TZIDs can only be compared if they're the same
+		if start.Params.Get(ical.ParamTimezoneID) != end.Params.Get(ical.ParamTimezoneID) {
+			return
+		}
+		startTime, startErr = start.DateTime(time.UTC)
+		endTime, endErr = end.DateTime(time.UTC)
+	}
+	if startErr != nil {
+		v.addf(comp, start, "invalid value: %v", startErr)
+	}
+	if endErr != nil {
+		v.addf(comp, end, "invalid value: %v", endErr)
+	}
+	if startErr == nil && endErr == nil && !endTime.After(startTime) {
+		v.addf(comp, end, "%s must be after DTSTART", endName)
+	}
+}
+
+func (v *validator) validateTimezoneRefs(comp *ical.Component) {
+	for _, prop := range comp.Props {
+		tzid := prop.Params.Get(ical.ParamTimezoneID)
+		if tzid != "" && !v.timezones[tzid] {
+			v.addf(comp, prop, "TZID %q isn't defined by a VTIMEZONE component", tzid)
+		}
+	}
+	for _, child := range comp.Children {
+		v.validateTimezoneRefs(child)
+	}
+}
@@ -0,0 +1,159 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const validCalendarData = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//example//test//EN
+BEGIN:VTIMEZONE
+TZID:Europe/Paris
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:event-1
+DTSTAMP:20240101T000000Z
+DTSTART;TZID=Europe/Paris:20240102T100000
+DTEND;TZID=Europe/Paris:20240102T110000
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:event-1
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID;TZID=Europe/Paris:20240103T100000
+DTSTART;TZID=Europe/Paris:20240103T120000
+DURATION:PT1H
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestValidateCalendarData(t *testing.T) {
+	if diags := ValidateCalendarData([]byte(validCalendarData)); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics for valid data: %v", diags)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data string
+		want []Diagnostic
+	}{
+		{
+			name: "method and missing properties",
+			data: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:REQUEST
+BEGIN:VEVENT
+DTSTART:20240102T100000Z
+END:VEVENT
+END:VCALENDAR
+`,
+			want: []Diagnostic{
+				{Line: 1, Component: "VCALENDAR", Message: "missing required PRODID property"},
+				{Line: 3, Component: "VCALENDAR", Property: "METHOD", Message: "METHOD isn't allowed in calendar object resources"},
+				{Line: 4, Component: "VEVENT", Message: "missing required UID property"},
+				{Line: 4, Component: "VEVENT", Message: "missing required DTSTAMP property"},
+			},
+		},
+		{
+			name: "mixed components and UIDs",
+			data: `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:x
+BEGIN:VEVENT
+UID:a
+DTSTAMP:20240101T000000Z
+END:VEVENT
+BEGIN:VTODO
+UID:b
+DTSTAMP:20240101T000000Z
+END:VTODO
+END:VCALENDAR
+`,
+			want: []Diagnostic{
+				{Line: 8, Component: "VTODO", Message: "calendar object resources can't mix VEVENT and VTODO components"},
+				{Line: 9, Component: "VTODO", Property: "UID", Message: `UID "b" differs from "a", calendar object resources can only contain one UID`},
+				{Line: 8, Component: "VTODO", Message: "several components without RECURRENCE-ID"},
+			},
+		},
+		{
+			name: "end before start and undefined TZID",
+			data: `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:x
+BEGIN:VEVENT
+UID:a
+DTSTAMP:20240101T000000Z
+DTSTART;TZID=Custom/Zone:20240102T100000
+DTEND;TZID=Custom/Zone:20240102T
+ 090000
+END:VEVENT
+END:VCALENDAR
+`,
+			want: []Diagnostic{
+				{Line: 8, Component: "VEVENT", Property: "DTEND", Message: "DTEND must be after DTSTART"},
+				{Line: 7, Component: "VEVENT", Property: "DTSTART", Message: `TZID "Custom/Zone" isn't defined by a VTIMEZONE component`},
+				{Line: 8, Component: "VEVENT", Property: "DTEND", Message: `TZID "Custom/Zone" isn't defined by a VTIMEZONE component`},
+			},
+		},
+		{
+			name: "syntax error",
+			data: "BEGIN:VCALENDAR\nVERSION:2.0\nEND:VEVENT\n",
+			want: []Diagnostic{
+				{Line: 3, Message: "expected END:VCALENDAR, got END:VEVENT"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ValidateCalendarData([]byte(tc.data))
+			if len(got) != len(tc.want) {
+				t.Fatalf("got diagnostics %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("diagnostic %d: got %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPutCalendarObjectValidate(t *testing.T) {
+	var puts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		puts++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	invalid := strings.Replace(validCalendarData, "UID:event-1\n", "", 1)
+	_, err = c.PutCalendarObject(context.Background(), "/cal/a.ics", strings.NewReader(invalid), &PutCalendarObjectOptions{Validate: true})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Diagnostics) != 1 {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if puts != 0 {
+		t.Errorf("invalid object was uploaded")
+	}
+
+	if _, err := c.PutCalendarObject(context.Background(), "/cal/a.ics", strings.NewReader(validCalendarData), &PutCalendarObjectOptions{Validate: true}); err != nil {
+		t.Fatalf("PutCalendarObject error: %v", err)
+	}
+	if puts != 1 {
+		t.Errorf("valid object wasn't uploaded")
+	}
+}
@@ -0,0 +1,210 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	webdav "github.com/yinjun1991/caldav-client-go"
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+const (
+	defaultWatchMinInterval = 15 * time.Second
+	defaultWatchMaxInterval = 5 * time.Minute
+)
+
+var watchPropFind = internal.NewPropNamePropFind(
+	internal.SyncTokenName,
+	CalendarServerGetCTagName,
+)
+
+// WatchOptions contains options for Watch
+type WatchOptions struct {
+	// SyncToken is the sync token to start from. When empty, the first event
+	// contains all calendar objects and has Reset set.
+	SyncToken string
+	// Limit is passed to SyncCalendar, see SyncQuery.
+	Limit int
+
+	// MinInterval is the polling interval used right after a change, 15
+	// seconds by default. The interval grows by BackoffFactor (2 by default)
+	// each time nothing changed, or an error occurred, up to MaxInterval (5
+	// minutes by default).
+	MinInterval   time.Duration
+	MaxInterval   time.Duration
+	BackoffFactor float64
+
+	// Trigger forces an immediate check when it receives a value, e.g. when
+	// a push notification arrives.
+	Trigger <-chan struct{}
+
+	// Events receives change events. Watch blocks while the channel is full.
+	Events chan<- *WatchEvent
+	// OnChange is called synchronously for each change event.
+	OnChange func(*WatchEvent)
+	// OnError is called when a check fails, or when some resources couldn't
+	// be fetched. The check is retried after the next polling interval.
+	OnError func(error)
+}
+
+// WatchEvent describes changes to a calendar detected by Watch.
+type WatchEvent struct {
+	Path string
+	// SyncToken is the sync token of the calendar after the changes. It's
+	// unchanged if some resources couldn't be fetched, see Errors.
+	SyncToken string
+	// Reset is set if the event contains the full calendar contents rather
+	// than incremental changes, e.g. because the server invalidated the
+	// previous sync token. Local state should be replaced.
+	Reset    bool
+	Calendar *Calendar
+	Updated  []*CalendarObject
+	Deleted  []string
+	// Errors contains an entry for each resource which couldn't be fetched.
+	// The next check syncs again from the previous sync token, and may
+	// report the other changes again.
+	Errors []*HrefError
+}
+
+// Watch polls a calendar for changes until ctx is cancelled, and delivers
+// them to opts.Events and opts.OnChange.
+//
+// Each check first fetches the calendar sync token and CTag, and only runs
+// SyncCalendar if they changed. The polling interval adapts to the activity
+// of the calendar, see WatchOptions.
+//
+// Watch returns ctx.Err() once ctx is cancelled.
+func (c *Client) Watch(ctx context.Context, path string, opts *WatchOptions) error {
+	if opts == nil {
+		opts = new(WatchOptions)
+	}
+	minInterval := opts.MinInterval
+	if minInterval <= 0 {
+		minInterval = defaultWatchMinInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWatchMaxInterval
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	factor := opts.BackoffFactor
+	if factor < 1 {
+		factor = 2
+	}
+
+	w := &watcher{c: c, path: path, opts: opts, syncToken: opts.SyncToken}
+	interval := minInterval
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		case <-opts.Trigger:
+			timer.Stop()
+		}
+
+		changed, err := w.check(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+
+		if changed {
+			interval = minInterval
+		} else {
+			interval = time.Duration(float64(interval) * factor)
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+		timer.Reset(interval)
+	}
+}
+
+type watcher struct {
+	c         *Client
+	path      string
+	opts      *WatchOptions
+	syncToken string
+	ctag      string
+}
+
+// check looks for changes and delivers them. It reports whether the calendar
+// changed.
+func (w *watcher) check(ctx context.Context) (bool, error) {
+	var ctag string
+	if w.syncToken != "" {
+		resp, err := w.c.ic.PropFindFlat(ctx, w.path, watchPropFind)
+		if err != nil {
+			return false, err
+		}
+		cal, err := parseCalendarFromResponse(resp)
+		if err != nil {
+			return false, err
+		}
+		if cal != nil {
+			ctag = cal.CTag
+			if cal.SyncToken == w.syncToken || (ctag != "" && ctag == w.ctag) {
+				return false, nil
+			}
+		}
+	}
+
+	reset := w.syncToken == ""
+	resp, err := w.c.SyncCalendar(ctx, w.path, &SyncQuery{
+		SyncToken: w.syncToken,
+		Limit:     w.opts.Limit,
+	})
+	if err != nil && !reset && webdav.IsInvalidSyncToken(err) {
+		reset = true
+		resp, err = w.c.SyncCalendar(ctx, w.path, &SyncQuery{Limit: w.opts.Limit})
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var syncErr error
+	if len(resp.Errors) > 0 {
+		// Keep the previous sync token, so that the next check retries the
+		// resources which couldn't be fetched
+		errs := make([]error, len(resp.Errors))
+		for i, hrefErr := range resp.Errors {
+			errs[i] = hrefErr
+		}
+		syncErr = errors.Join(errs...)
+	} else {
+		w.syncToken = resp.SyncToken
+		w.ctag = ctag
+	}
+	if !reset && len(resp.Updated) == 0 && len(resp.Deleted) == 0 {
+		return false, syncErr
+	}
+
+	event := &WatchEvent{
+		Path:      w.path,
+		SyncToken: w.syncToken,
+		Reset:     reset,
+		Calendar:  resp.Calendar,
+		Updated:   resp.Updated,
+		Deleted:   resp.Deleted,
+		Errors:    resp.Errors,
+	}
+	if w.opts.OnChange != nil {
+		w.opts.OnChange(event)
+	}
+	if w.opts.Events != nil {
+		select {
+		case w.opts.Events <- event:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+	return true, syncErr
+}
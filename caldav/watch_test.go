@@ -0,0 +1,231 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		token     = "t1"
+		propfinds int
+	)
+	syncTokenRE := regexp.MustCompile(`<sync-token[^>]*>([^<]*)</sync-token>`)
+
+	writeMultiStatus := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">%s</d:multistatus>`, body)
+	}
+	objectResponse := func(name string) string {
+		return fmt.Sprintf(`<d:response><d:href>/cal/%s</d:href><d:propstat><d:prop><d:getetag>"1"</d:getetag><cal:calendar-data>BEGIN:VCALENDAR
+END:VCALENDAR</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, name)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case "PROPFIND":
+			propfinds++
+			// The calendar changes after a few idle checks
+			if propfinds == 3 {
+				token = "t2"
+			}
+			writeMultiStatus(w, fmt.Sprintf(`<d:response><d:href>/cal/</d:href><d:propstat><d:prop><d:sync-token>%s</d:sync-token></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat><d:propstat><d:prop><cs:getctag/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat></d:response>`, token))
+		case "REPORT":
+			body, _ := io.ReadAll(r.Body)
+			var reqToken string
+			if m := syncTokenRE.FindSubmatch(body); m != nil {
+				reqToken = string(m[1])
+			}
+			switch reqToken {
+			case "":
+				writeMultiStatus(w, objectResponse("a.ics")+`<d:sync-token>t1</d:sync-token>`)
+			case "t1":
+				writeMultiStatus(w, objectResponse("b.ics")+`<d:response><d:href>/cal/a.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response><d:sync-token>t2</d:sync-token>`)
+				token = "t3"
+			default:
+				w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`<d:error xmlns:d="DAV:"><d:valid-sync-token/></d:error>`))
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *WatchEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Watch(ctx, "/cal/", &WatchOptions{
+			MinInterval: time.Millisecond,
+			MaxInterval: 4 * time.Millisecond,
+			Events:      events,
+		})
+	}()
+
+	next := func() *WatchEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for event")
+			return nil
+		}
+	}
+
+	ev := next()
+	if !ev.Reset || ev.SyncToken != "t1" || len(ev.Updated) != 1 || ev.Updated[0].Path != "/cal/a.ics" {
+		t.Errorf("unexpected first event: %+v", ev)
+	}
+
+	ev = next()
+	if ev.Reset || ev.SyncToken != "t2" || len(ev.Updated) != 1 || ev.Updated[0].Path != "/cal/b.ics" || len(ev.Deleted) != 1 || ev.Deleted[0] != "/cal/a.ics" {
+		t.Errorf("unexpected second event: %+v", ev)
+	}
+
+	// The server rejects token t2, Watch starts over
+	ev = next()
+	if !ev.Reset || ev.SyncToken != "t1" {
+		t.Errorf("unexpected third event: %+v", ev)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch returned %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if propfinds < 3 {
+		t.Errorf("expected idle checks, got %d PROPFIND requests", propfinds)
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []string
+	)
+	syncTokenRE := regexp.MustCompile(`<sync-token[^>]*>([^<]*)</sync-token>`)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body string
+		switch r.Method {
+		case "PROPFIND":
+			body = `<d:response><d:href>/cal/</d:href><d:propstat><d:prop><d:sync-token>t2</d:sync-token></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`
+		case "REPORT":
+			b, _ := io.ReadAll(r.Body)
+			var reqToken string
+			if m := syncTokenRE.FindSubmatch(b); m != nil {
+				reqToken = string(m[1])
+			}
+			reports = append(reports, reqToken)
+
+			// b.ics fails the first time
+			status := "HTTP/1.1 500 Internal Server Error"
+			if len(reports) > 1 {
+				status = "HTTP/1.1 404 Not Found"
+			}
+			body = `<d:response><d:href>/cal/a.ics</d:href><d:propstat><d:prop><d:getetag>"1"</d:getetag><cal:calendar-data>BEGIN:VCALENDAR
+END:VCALENDAR</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>` +
+				`<d:response><d:href>/cal/b.ics</d:href><d:status>` + status + `</d:status></d:response><d:sync-token>t2</d:sync-token>`
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">%s</d:multistatus>`, body)
+	}))
+	defer ts.Close()
+
+	c, err := newTestClient(ts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *WatchEvent)
+	syncErrs := make(chan error, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Watch(ctx, "/cal/", &WatchOptions{
+			SyncToken:   "t1",
+			MinInterval: time.Millisecond,
+			MaxInterval: 4 * time.Millisecond,
+			Events:      events,
+			OnError: func(err error) {
+				syncErrs <- err
+			},
+		})
+	}()
+
+	next := func() *WatchEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for event")
+			return nil
+		}
+	}
+
+	ev := next()
+	if ev.SyncToken != "t1" || len(ev.Updated) != 1 || len(ev.Errors) != 1 || ev.Errors[0].Href != "/cal/b.ics" || ev.Errors[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected first event: %+v", ev)
+	}
+	select {
+	case err := <-syncErrs:
+		var hrefErr *HrefError
+		if !errors.As(err, &hrefErr) || hrefErr.Href != "/cal/b.ics" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for error")
+	}
+
+	// The failed resource is synced again from the previous token
+	ev = next()
+	if ev.SyncToken != "t2" || len(ev.Errors) != 0 || len(ev.Deleted) != 1 || ev.Deleted[0] != "/cal/b.ics" {
+		t.Errorf("unexpected second event: %+v", ev)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch returned %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) != 2 || reports[0] != "t1" || reports[1] != "t1" {
+		t.Errorf("unexpected sync tokens in REPORT requests: %q", reports)
+	}
+}
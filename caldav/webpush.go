@@ -0,0 +1,148 @@
+package caldav
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// pushContentEncoding is the content encoding of encrypted Web Push
+// messages, defined in RFC 8188.
+const pushContentEncoding = "aes128gcm"
+
+const (
+	pushAuthSecretSize = 16
+	pushSaltSize       = 16
+	pushKeySize        = 16
+	pushNonceSize      = 12
+)
+
+// PushKeys contains the keys used to decrypt Web Push messages, as defined in
+// RFC 8291. The public key and authentication secret are sent to the server
+// with RegisterPush, the private key is used by PushHandler.
+type PushKeys struct {
+	PrivateKey *ecdh.PrivateKey
+	AuthSecret []byte
+}
+
+// GeneratePushKeys generates a new P-256 key pair and authentication secret.
+func GeneratePushKeys() (*PushKeys, error) {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, pushAuthSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &PushKeys{PrivateKey: priv, AuthSecret: secret}, nil
+}
+
+// SubscriptionOptions returns the options for RegisterPush advertising the
+// keys to the server.
+func (keys *PushKeys) SubscriptionOptions() *PushSubscriptionOptions {
+	return &PushSubscriptionOptions{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(keys.PrivateKey.PublicKey().Bytes()),
+		AuthSecret: base64.RawURLEncoding.EncodeToString(keys.AuthSecret),
+	}
+}
+
+// decrypt decrypts a message encrypted with the aes128gcm content encoding.
+func (keys *PushKeys) decrypt(body []byte) ([]byte, error) {
+	// Header: salt, record size, key ID length and key ID, which is the
+	// sender's public key
+	if len(body) < pushSaltSize+5 {
+		return nil, errors.New("caldav: truncated encrypted push message")
+	}
+	salt := body[:pushSaltSize]
+	rs := int(binary.BigEndian.Uint32(body[pushSaltSize:]))
+	idLen := int(body[pushSaltSize+4])
+	body = body[pushSaltSize+5:]
+	if len(body) < idLen {
+		return nil, errors.New("caldav: truncated encrypted push message")
+	}
+	keyID, body := body[:idLen], body[idLen:]
+	// A record holds at least the authentication tag and a delimiter
+	if rs < 18 {
+		return nil, fmt.Errorf("caldav: invalid push message record size %d", rs)
+	}
+
+	senderKey, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("caldav: invalid push message sender key: %w", err)
+	}
+	secret, err := keys.PrivateKey.ECDH(senderKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyInfo bytes.Buffer
+	keyInfo.WriteString("WebPush: info\x00")
+	keyInfo.Write(keys.PrivateKey.PublicKey().Bytes())
+	keyInfo.Write(keyID)
+	ikm := hkdf(keys.AuthSecret, secret, keyInfo.Bytes(), 32)
+
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), pushKeySize)
+	baseNonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), pushNonceSize)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	for seq := uint64(0); len(body) > 0; seq++ {
+		n := min(rs, len(body))
+		record := body[:n]
+		body = body[n:]
+		last := len(body) == 0
+
+		nonce := make([]byte, pushNonceSize)
+		copy(nonce, baseNonce)
+		for i := 0; i < 8; i++ {
+			nonce[pushNonceSize-1-i] ^= byte(seq >> (8 * i))
+		}
+		data, err := aead.Open(nil, nonce, record, nil)
+		if err != nil {
+			return nil, fmt.Errorf("caldav: failed to decrypt push message: %w", err)
+		}
+
+		// Strip the padding: zeros preceded by a delimiter, 2 for the last
+		// record and 1 for the others
+		data = bytes.TrimRight(data, "\x00")
+		if len(data) == 0 {
+			return nil, errors.New("caldav: push message record has no padding delimiter")
+		}
+		delim := data[len(data)-1]
+		if (last && delim != 2) || (!last && delim != 1) {
+			return nil, errors.New("caldav: invalid push message padding delimiter")
+		}
+		plaintext = append(plaintext, data[:len(data)-1]...)
+	}
+	return plaintext, nil
+}
+
+// hkdf implements HKDF with SHA-256, as defined in RFC 5869, for outputs of
+// at most 32 bytes.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	prk := mac.Sum(nil)
+
+	mac = hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{1})
+	return mac.Sum(nil)[:length]
+}
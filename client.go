@@ -2,9 +2,12 @@ package webdav
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/yinjun1991/caldav-client-go/internal"
@@ -83,6 +86,32 @@ var fileInfoPropFind = internal.NewPropNamePropFind(
 	internal.GetETagName,
 )
 
+// fileInfoQuotaPropFind is like fileInfoPropFind, but also requests the quota
+// properties, which some servers are slow to compute.
+var fileInfoQuotaPropFind = internal.NewPropNamePropFind(
+	internal.ResourceTypeName,
+	internal.GetContentLengthName,
+	internal.GetLastModifiedName,
+	internal.GetContentTypeName,
+	internal.GetETagName,
+	internal.QuotaUsedBytesName,
+	internal.QuotaAvailableBytesName,
+)
+
+var quotaPropFind = internal.NewPropNamePropFind(
+	internal.QuotaUsedBytesName,
+	internal.QuotaAvailableBytesName,
+)
+
+// quotaFromResponse returns the quota reported in a response, or nil.
+func quotaFromResponse(resp *internal.Response) *Quota {
+	used, available, ok := resp.DecodeQuota()
+	if !ok {
+		return nil
+	}
+	return &Quota{Used: used, Available: available}
+}
+
 func fileInfoFromResponse(resp *internal.Response) (*FileInfo, error) {
 	path, err := resp.Path()
 	if err != nil {
@@ -124,13 +153,47 @@ func fileInfoFromResponse(resp *internal.Response) (*FileInfo, error) {
 		return nil, err
 	}
 	fi.ModTime = time.Time(getMod.LastModified)
+	fi.Quota = quotaFromResponse(resp)
 
 	return fi, nil
 }
 
+// Quota fetches the storage quota of a collection, as defined in RFC 4331.
+//
+// If the server doesn't report quota for the collection,
+// ErrQuotaNotSupported is returned.
+func (c *Client) Quota(ctx context.Context, name string) (*Quota, error) {
+	resp, err := c.ic.PropFindFlat(ctx, name, quotaPropFind)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+
+	quota := quotaFromResponse(resp)
+	if quota == nil {
+		return nil, ErrQuotaNotSupported
+	}
+	return quota, nil
+}
+
 // Stat fetches a FileInfo for a single file.
 func (c *Client) Stat(ctx context.Context, name string) (*FileInfo, error) {
-	resp, err := c.ic.PropFindFlat(ctx, name, fileInfoPropFind)
+	return c.StatWithOptions(ctx, name, nil)
+}
+
+// StatWithOptions is like Stat, but accepts options.
+func (c *Client) StatWithOptions(ctx context.Context, name string, opts *StatOptions) (*FileInfo, error) {
+	if opts == nil {
+		opts = new(StatOptions)
+	}
+
+	propfind := fileInfoPropFind
+	if opts.Quota {
+		propfind = fileInfoQuotaPropFind
+	}
+	resp, err := c.ic.PropFindFlat(ctx, name, propfind)
 	if err != nil {
 		return nil, err
 	}
@@ -154,12 +217,25 @@ func (c *Client) Open(ctx context.Context, name string) (io.ReadCloser, error) {
 
 // ReadDir lists files in a directory.
 func (c *Client) ReadDir(ctx context.Context, name string, recursive bool) ([]FileInfo, error) {
+	return c.ReadDirWithOptions(ctx, name, recursive, nil)
+}
+
+// ReadDirWithOptions is like ReadDir, but accepts options.
+func (c *Client) ReadDirWithOptions(ctx context.Context, name string, recursive bool, opts *ReadDirOptions) ([]FileInfo, error) {
+	if opts == nil {
+		opts = new(ReadDirOptions)
+	}
+
 	depth := internal.DepthOne
 	if recursive {
 		depth = internal.DepthInfinity
 	}
 
-	ms, err := c.ic.PropFind(ctx, name, depth, fileInfoPropFind)
+	propfind := fileInfoPropFind
+	if opts.Quota {
+		propfind = fileInfoQuotaPropFind
+	}
+	ms, err := c.ic.PropFind(ctx, name, depth, propfind)
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +269,9 @@ func (fw *fileWriter) Close() error {
 }
 
 // Create writes a file's contents.
+//
+// To write a file conditionally, or to get its new ETag, use
+// CreateWithOptions instead.
 func (c *Client) Create(ctx context.Context, name string) (io.WriteCloser, error) {
 	pr, pw := io.Pipe()
 
@@ -216,15 +295,57 @@ func (c *Client) Create(ctx context.Context, name string) (io.WriteCloser, error
 	return &fileWriter{pw, done}, nil
 }
 
+// CreateWithOptions writes a file's contents, honouring the conditions in
+// opts. It returns the information about the written file provided by the
+// server in the response headers: the ETag is empty if the server didn't
+// return one.
+//
+// If a condition isn't met, a *PreconditionFailedError is returned.
+func (c *Client) CreateWithOptions(ctx context.Context, name string, body io.Reader, opts *CreateOptions) (*FileInfo, error) {
+	if opts == nil {
+		opts = new(CreateOptions)
+	}
+
+	req, err := c.ic.NewRequest(http.MethodPut, name, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := setConditionalHeaders(req, opts.IfMatch, opts.IfNoneMatch, opts.If); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doConditional(ctx, req, name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return c.fileInfoFromHeader(name, resp.Header), nil
+}
+
 // RemoveAll deletes a file. If the file is a directory, all of its descendants
 // are recursively deleted as well.
 func (c *Client) RemoveAll(ctx context.Context, name string) error {
+	return c.RemoveAllWithOptions(ctx, name, nil)
+}
+
+// RemoveAllWithOptions is like RemoveAll, but honours the conditions in opts.
+//
+// If a condition isn't met, a *PreconditionFailedError is returned.
+func (c *Client) RemoveAllWithOptions(ctx context.Context, name string, opts *RemoveAllOptions) error {
+	if opts == nil {
+		opts = new(RemoveAllOptions)
+	}
+
 	req, err := c.ic.NewRequest(http.MethodDelete, name, nil)
 	if err != nil {
 		return err
 	}
+	if err := setConditionalHeaders(req, opts.IfMatch, opts.IfNoneMatch, opts.If); err != nil {
+		return err
+	}
 
-	resp, err := c.ic.Do(req.WithContext(ctx))
+	resp, err := c.doConditional(ctx, req, name)
 	if err != nil {
 		return err
 	}
@@ -234,31 +355,60 @@ func (c *Client) RemoveAll(ctx context.Context, name string) error {
 
 // Mkdir creates a new directory.
 func (c *Client) Mkdir(ctx context.Context, name string) error {
+	_, err := c.MkdirWithOptions(ctx, name, nil)
+	return err
+}
+
+// MkdirWithOptions is like Mkdir, but honours the conditions in opts. It
+// returns the information about the new directory provided by the server in
+// the response headers.
+//
+// If a condition isn't met, a *PreconditionFailedError is returned.
+func (c *Client) MkdirWithOptions(ctx context.Context, name string, opts *MkdirOptions) (*FileInfo, error) {
+	if opts == nil {
+		opts = new(MkdirOptions)
+	}
+
 	req, err := c.ic.NewRequest("MKCOL", name, nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := setConditionalHeaders(req, opts.IfMatch, opts.IfNoneMatch, opts.If); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.ic.Do(req.WithContext(ctx))
+	resp, err := c.doConditional(ctx, req, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	resp.Body.Close()
-	return nil
+
+	fi := c.fileInfoFromHeader(name, resp.Header)
+	fi.IsDir = true
+	return fi, nil
 }
 
 // Copy copies a file.
 //
 // By default, if the file is a directory, all descendants are recursively
 // copied as well.
+//
+// If a condition in options isn't met, a *PreconditionFailedError is returned.
 func (c *Client) Copy(ctx context.Context, name, dest string, options *CopyOptions) error {
+	_, err := c.CopyWithOptions(ctx, name, dest, options)
+	return err
+}
+
+// CopyWithOptions is like Copy, but returns the information about the
+// destination provided by the server in the response headers.
+func (c *Client) CopyWithOptions(ctx context.Context, name, dest string, options *CopyOptions) (*FileInfo, error) {
 	if options == nil {
 		options = new(CopyOptions)
 	}
 
 	req, err := c.ic.NewRequest("COPY", name, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	depth := internal.DepthInfinity
@@ -269,28 +419,162 @@ func (c *Client) Copy(ctx context.Context, name, dest string, options *CopyOptio
 	req.Header.Set("Destination", c.ic.ResolveHref(dest).String())
 	req.Header.Set("Overwrite", internal.FormatOverwrite(!options.NoOverwrite))
 	req.Header.Set("Depth", depth.String())
+	if err := setConditionalHeaders(req, options.IfMatch, options.IfNoneMatch, options.If); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.ic.Do(req.WithContext(ctx))
+	resp, err := c.doConditional(ctx, req, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	resp.Body.Close()
-	return nil
+
+	return c.fileInfoFromHeader(dest, resp.Header), nil
 }
 
 // Move moves a file.
+//
+// If a condition in options isn't met, a *PreconditionFailedError is returned.
 func (c *Client) Move(ctx context.Context, name, dest string, options *MoveOptions) error {
+	_, err := c.MoveWithOptions(ctx, name, dest, options)
+	return err
+}
+
+// MoveWithOptions is like Move, but returns the information about the
+// destination provided by the server in the response headers.
+func (c *Client) MoveWithOptions(ctx context.Context, name, dest string, options *MoveOptions) (*FileInfo, error) {
 	if options == nil {
 		options = new(MoveOptions)
 	}
 
 	req, err := c.ic.NewRequest("MOVE", name, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Destination", c.ic.ResolveHref(dest).String())
 	req.Header.Set("Overwrite", internal.FormatOverwrite(!options.NoOverwrite))
+	if err := setConditionalHeaders(req, options.IfMatch, options.IfNoneMatch, options.If); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doConditional(ctx, req, name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return c.fileInfoFromHeader(dest, resp.Header), nil
+}
+
+func setConditionalHeaders(req *http.Request, ifMatch, ifNoneMatch ConditionalMatch, ifHeader IfHeader) error {
+	if ifMatch.IsSet() {
+		req.Header.Set("If-Match", string(ifMatch))
+	}
+	if ifNoneMatch.IsSet() {
+		req.Header.Set("If-None-Match", string(ifNoneMatch))
+	}
+	if len(ifHeader) > 0 {
+		v, err := ifHeader.Format()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("If", v)
+	}
+	return nil
+}
+
+// doConditional sends a request which may carry conditional headers, turning
+// 412 Precondition Failed responses into a *PreconditionFailedError.
+func (c *Client) doConditional(ctx context.Context, req *http.Request, name string) (*http.Response, error) {
+	resp, err := c.ic.Do(req.WithContext(ctx))
+	if IsPreconditionFailed(err) {
+		return nil, newPreconditionFailedError(name, err)
+	}
+	return resp, err
+}
+
+// fileInfoFromHeader builds a FileInfo from the headers of a response to a
+// request which created or modified the file.
+func (c *Client) fileInfoFromHeader(name string, h http.Header) *FileInfo {
+	fi := &FileInfo{Path: c.ic.ResolveHref(name).Path}
+	fi.ETag = internal.ParseETagHeader(h)
+	if lastModified := h.Get("Last-Modified"); lastModified != "" {
+		if t, err := http.ParseTime(lastModified); err == nil {
+			fi.ModTime = t
+		}
+	}
+	return fi
+}
+
+// Lock takes a write lock on a file. If the file doesn't exist, an empty file
+// is created.
+//
+// If the resource is already locked, an HTTPError with the status code 423
+// Locked is returned.
+func (c *Client) Lock(ctx context.Context, name string, opts *LockOptions) (*Lock, error) {
+	if opts == nil {
+		opts = new(LockOptions)
+	}
+
+	lockInfo := internal.LockInfo{
+		LockType: internal.LockType{Write: &struct{}{}},
+	}
+	if opts.Scope == LockShared {
+		lockInfo.LockScope.Shared = &struct{}{}
+	} else {
+		lockInfo.LockScope.Exclusive = &struct{}{}
+	}
+	if opts.Owner != "" {
+		lockInfo.Owner = &internal.Owner{Text: opts.Owner}
+	}
+
+	req, err := c.ic.NewXMLRequest("LOCK", name, &lockInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := internal.DepthInfinity
+	if opts.NoRecursive {
+		depth = internal.DepthZero
+	}
+	req.Header.Set("Depth", depth.String())
+	if opts.Timeout != 0 {
+		req.Header.Set("Timeout", formatTimeout(opts.Timeout))
+	}
+	if err := setConditionalHeaders(req, "", "", opts.If); err != nil {
+		return nil, err
+	}
+
+	return c.doLock(ctx, req, name, "")
+}
+
+// Refresh resets the timeout of a lock held on a file. timeout is the
+// requested new timeout, zero lets the server pick it.
+func (c *Client) Refresh(ctx context.Context, name string, token LockToken, timeout time.Duration) (*Lock, error) {
+	req, err := c.ic.NewRequest("LOCK", name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout != 0 {
+		req.Header.Set("Timeout", formatTimeout(timeout))
+	}
+	if err := setConditionalHeaders(req, "", "", IfHeader{{Conditions: []IfCondition{{Token: token}}}}); err != nil {
+		return nil, err
+	}
+
+	return c.doLock(ctx, req, name, token)
+}
+
+// Unlock releases a lock held on a file.
+func (c *Client) Unlock(ctx context.Context, name string, token LockToken) error {
+	req, err := c.ic.NewRequest("UNLOCK", name, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Lock-Token", "<"+string(token)+">")
 
 	resp, err := c.ic.Do(req.WithContext(ctx))
 	if err != nil {
@@ -299,3 +583,179 @@ func (c *Client) Move(ctx context.Context, name, dest string, options *MoveOptio
 	resp.Body.Close()
 	return nil
 }
+
+// doLock sends a LOCK request and decodes the lock from the response. When
+// refreshing a lock, token is the lock token, otherwise the token is read
+// from the Lock-Token header.
+func (c *Client) doLock(ctx context.Context, req *http.Request, name string, token LockToken) (*Lock, error) {
+	resp, err := c.doConditional(ctx, req, name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if token == "" {
+		h := strings.TrimSpace(resp.Header.Get("Lock-Token"))
+		token = LockToken(strings.TrimSuffix(strings.TrimPrefix(h, "<"), ">"))
+		if token == "" {
+			return nil, fmt.Errorf("webdav: missing Lock-Token in LOCK response")
+		}
+	}
+
+	var prop internal.Prop
+	if err := xml.NewDecoder(resp.Body).Decode(&prop); err != nil {
+		return nil, fmt.Errorf("webdav: failed to decode LOCK response: %w", err)
+	}
+	var discovery internal.LockDiscovery
+	if err := prop.Decode(&discovery); err != nil {
+		return nil, err
+	}
+
+	for _, active := range discovery.ActiveLocks {
+		if active.LockToken == nil || LockToken(active.LockToken.Href) != token {
+			continue
+		}
+		return lockFromActiveLock(&active, c.ic.ResolveHref(name).Path)
+	}
+	return nil, fmt.Errorf("webdav: lock %v missing from LOCK response", token)
+}
+
+// lockFromActiveLock builds a Lock from a DAV:activelock element. root is the
+// resolved path of the locked resource, used if the server didn't report the
+// lock root.
+func lockFromActiveLock(active *internal.ActiveLock, root string) (*Lock, error) {
+	l := &Lock{
+		Token: LockToken(active.LockToken.Href),
+		Root:  root,
+	}
+	if active.LockRoot != nil {
+		l.Root = active.LockRoot.Href.Path
+	}
+	if active.LockScope.Shared != nil {
+		l.Scope = LockShared
+	}
+	if active.Owner != nil {
+		l.Owner = active.Owner.Href
+		if l.Owner == "" {
+			l.Owner = strings.TrimSpace(active.Owner.Text)
+		}
+	}
+
+	depth, err := internal.ParseDepth(strings.TrimSpace(active.Depth))
+	if err != nil {
+		return nil, err
+	}
+	l.Recursive = depth == internal.DepthInfinity
+
+	if active.Timeout != "" {
+		timeout, err := parseTimeout(active.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		l.Timeout = timeout
+	}
+
+	return l, nil
+}
+
+// formatTimeout formats a Timeout header, defined in RFC 4918 section 10.7.
+func formatTimeout(timeout time.Duration) string {
+	if timeout < 0 {
+		return "Infinite"
+	}
+	return fmt.Sprintf("Second-%d", int64(timeout/time.Second))
+}
+
+func parseTimeout(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "Infinite") {
+		return InfiniteLockTimeout, nil
+	}
+	if len(s) > len("Second-") && strings.EqualFold(s[:len("Second-")], "Second-") {
+		n, err := strconv.ParseUint(s[len("Second-"):], 10, 32)
+		if err == nil {
+			return time.Duration(n) * time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("webdav: invalid timeout %q", s)
+}
+
+// PropFind fetches properties of a resource and, depending on depth, of its
+// members. When no names are specified, all properties the server is willing
+// to return are fetched (DAV:allprop).
+//
+// Failures for individual resources and properties don't abort the request:
+// they're reported in PropFindResponse.Err and Property.Status.
+func (c *Client) PropFind(ctx context.Context, name string, depth Depth, names ...xml.Name) ([]PropFindResponse, error) {
+	var propfind *internal.PropFind
+	if len(names) > 0 {
+		propfind = internal.NewPropNamePropFind(names...)
+	} else {
+		propfind = &internal.PropFind{AllProp: &struct{}{}}
+	}
+
+	ms, err := c.ic.PropFind(ctx, name, depth, propfind)
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]PropFindResponse, 0, len(ms.Responses))
+	for _, resp := range ms.Responses {
+		for _, href := range resp.Hrefs {
+			l = append(l, PropFindResponse{
+				Path:  href.Path,
+				Props: propertiesFromPropStats(resp.PropStats, true),
+				Err:   resp.Err(),
+			})
+		}
+	}
+	return l, nil
+}
+
+// PropPatch sets and removes properties of a resource. Properties are removed
+// before being set.
+//
+// The server applies either all changes or none of them. The returned
+// properties contain the status reported for each of them: if a change was
+// rejected, e.g. with 403 Forbidden, the other changes are usually reported
+// with 424 Failed Dependency.
+func (c *Client) PropPatch(ctx context.Context, name string, set []*RawXMLValue, remove []xml.Name) ([]Property, error) {
+	if len(set) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("webdav: no properties to update")
+	}
+
+	var update internal.PropertyUpdate
+	if len(remove) > 0 {
+		var prop internal.Prop
+		for _, n := range remove {
+			prop.Raw = append(prop.Raw, *internal.NewRawXMLElement(n, nil, nil))
+		}
+		update.Remove = []internal.Remove{{Prop: prop}}
+	}
+	if len(set) > 0 {
+		var prop internal.Prop
+		for _, v := range set {
+			prop.Raw = append(prop.Raw, *v)
+		}
+		update.Set = []internal.Set{{Prop: prop}}
+	}
+
+	req, err := c.ic.NewXMLRequest("PROPPATCH", name, &update)
+	if err != nil {
+		return nil, err
+	}
+
+	ms, err := c.ic.DoMultiStatus(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(ms.Responses) != 1 {
+		return nil, fmt.Errorf("webdav: expected 1 response to PROPPATCH, got %d", len(ms.Responses))
+	}
+
+	resp := &ms.Responses[0]
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return propertiesFromPropStats(resp.PropStats, false), nil
+}
@@ -0,0 +1,495 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+func TestClientConditionalRequests(t *testing.T) {
+	const etag = `"v1"`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifMatch := r.Header.Get("If-Match")
+		ifNoneMatch := r.Header.Get("If-None-Match")
+
+		switch r.Method {
+		case http.MethodPut:
+			if ifNoneMatch != "*" {
+				t.Errorf("PUT: got If-None-Match %q, want *", ifNoneMatch)
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete, "COPY", "MOVE":
+			if ifMatch != etag {
+				w.Header().Set("ETag", etag)
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			if r.Method == "COPY" {
+				w.Header().Set("ETag", `"v2"`)
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "MKCOL":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected %v request", r.Method)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(nil, ts.URL)
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	ctx := context.Background()
+
+	fi, err := c.CreateWithOptions(ctx, "/dir/file.txt", strings.NewReader("hello"), &CreateOptions{IfNoneMatch: MatchAny})
+	if err != nil {
+		t.Fatalf("CreateWithOptions() = %v", err)
+	}
+	if fi.Path != "/dir/file.txt" || fi.ETag != "v1" || fi.ModTime.IsZero() {
+		t.Errorf("CreateWithOptions() = %+v", fi)
+	}
+
+	if err := c.RemoveAllWithOptions(ctx, "/dir/file.txt", &RemoveAllOptions{IfMatch: ETagMatch("v1")}); err != nil {
+		t.Errorf("RemoveAllWithOptions() = %v", err)
+	}
+	err = c.RemoveAllWithOptions(ctx, "/dir/file.txt", &RemoveAllOptions{IfMatch: ETagMatch("v0")})
+	var pfErr *PreconditionFailedError
+	if !errors.As(err, &pfErr) || pfErr.Path != "/dir/file.txt" || pfErr.ETag != "v1" {
+		t.Errorf("RemoveAllWithOptions() = %v, want *PreconditionFailedError", err)
+	}
+	if !errors.Is(err, ErrPreconditionFailed) || !IsPreconditionFailed(err) {
+		t.Errorf("RemoveAllWithOptions() = %v, want precondition failure", err)
+	}
+
+	fi, err = c.CopyWithOptions(ctx, "/dir/file.txt", "/dir/copy.txt", &CopyOptions{IfMatch: ETagMatch("v1")})
+	if err != nil {
+		t.Errorf("CopyWithOptions() = %v", err)
+	} else if fi.Path != "/dir/copy.txt" || fi.ETag != "v2" {
+		t.Errorf("CopyWithOptions() = %+v", fi)
+	}
+	if err := c.Copy(ctx, "/dir/file.txt", "/dir/copy.txt", &CopyOptions{IfMatch: ETagMatch("v1")}); err != nil {
+		t.Errorf("Copy() = %v", err)
+	}
+	if err := c.Move(ctx, "/dir/file.txt", "/dir/moved.txt", &MoveOptions{IfMatch: ETagMatch("v0")}); !IsPreconditionFailed(err) {
+		t.Errorf("Move() = %v, want precondition failure", err)
+	}
+	if _, err := c.MoveWithOptions(ctx, "/dir/file.txt", "/dir/moved.txt", &MoveOptions{IfMatch: ETagMatch("v0")}); !IsPreconditionFailed(err) {
+		t.Errorf("MoveWithOptions() = %v, want precondition failure", err)
+	}
+
+	fi, err = c.MkdirWithOptions(ctx, "/new/", nil)
+	if err != nil {
+		t.Fatalf("MkdirWithOptions() = %v", err)
+	}
+	if !fi.IsDir || fi.Path != "/new/" {
+		t.Errorf("MkdirWithOptions() = %+v", fi)
+	}
+}
+
+func TestClientLock(t *testing.T) {
+	const token = "opaquelocktoken:e71d4fae-5dec-22d6-fea5-00a0c91e6be4"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "LOCK":
+			if ifHeader := r.Header.Get("If"); ifHeader != "" {
+				if ifHeader != "(<"+token+">)" {
+					t.Errorf("LOCK refresh: got If %q", ifHeader)
+				}
+			} else {
+				var lockInfo internal.LockInfo
+				if err := xml.NewDecoder(r.Body).Decode(&lockInfo); err != nil {
+					t.Errorf("LOCK: failed to decode body: %v", err)
+				}
+				if lockInfo.LockScope.Shared == nil || lockInfo.LockType.Write == nil {
+					t.Errorf("LOCK: unexpected lockinfo %+v", lockInfo)
+				}
+				if lockInfo.Owner == nil || lockInfo.Owner.Text != "Jane" {
+					t.Errorf("LOCK: unexpected owner %+v", lockInfo.Owner)
+				}
+				if depth := r.Header.Get("Depth"); depth != "0" {
+					t.Errorf("LOCK: got Depth %q, want 0", depth)
+				}
+				if timeout := r.Header.Get("Timeout"); timeout != "Second-600" {
+					t.Errorf("LOCK: got Timeout %q", timeout)
+				}
+				w.Header().Set("Lock-Token", "<"+token+">")
+			}
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<D:prop xmlns:D="DAV:">
+  <D:lockdiscovery>
+    <D:activelock>
+      <D:locktype><D:write/></D:locktype>
+      <D:lockscope><D:shared/></D:lockscope>
+      <D:depth>0</D:depth>
+      <D:owner>Jane</D:owner>
+      <D:timeout>Second-600</D:timeout>
+      <D:locktoken><D:href>` + token + `</D:href></D:locktoken>
+      <D:lockroot><D:href>/dir/file.txt</D:href></D:lockroot>
+    </D:activelock>
+  </D:lockdiscovery>
+</D:prop>`))
+		case http.MethodPut:
+			if ifHeader := r.Header.Get("If"); ifHeader != "</dir/file.txt> (<"+token+">)" {
+				t.Errorf("PUT: got If %q", ifHeader)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "UNLOCK":
+			if lockToken := r.Header.Get("Lock-Token"); lockToken != "<"+token+">" {
+				t.Errorf("UNLOCK: got Lock-Token %q", lockToken)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %v request", r.Method)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(nil, ts.URL)
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	ctx := context.Background()
+
+	lock, err := c.Lock(ctx, "/dir/file.txt", &LockOptions{
+		Scope:       LockShared,
+		NoRecursive: true,
+		Timeout:     10 * time.Minute,
+		Owner:       "Jane",
+	})
+	if err != nil {
+		t.Fatalf("Lock() = %v", err)
+	}
+	want := &Lock{
+		Token:   token,
+		Root:    "/dir/file.txt",
+		Scope:   LockShared,
+		Timeout: 10 * time.Minute,
+		Owner:   "Jane",
+	}
+	if *lock != *want {
+		t.Errorf("Lock() = %+v, want %+v", lock, want)
+	}
+
+	if _, err := c.Refresh(ctx, "/dir/file.txt", lock.Token, 0); err != nil {
+		t.Errorf("Refresh() = %v", err)
+	}
+
+	_, err = c.CreateWithOptions(ctx, "/dir/file.txt", strings.NewReader("hello"), &CreateOptions{
+		If: IfHeader{lock.IfList()},
+	})
+	if err != nil {
+		t.Errorf("CreateWithOptions() = %v", err)
+	}
+
+	if err := c.Unlock(ctx, "/dir/file.txt", lock.Token); err != nil {
+		t.Errorf("Unlock() = %v", err)
+	}
+}
+
+func TestClientLockRoot(t *testing.T) {
+	const token = "urn:uuid:a"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dav/file.txt" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+		// The server doesn't report the lock root
+		w.Header().Set("Lock-Token", "<"+token+">")
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<D:prop xmlns:D="DAV:">
+  <D:lockdiscovery>
+    <D:activelock>
+      <D:locktype><D:write/></D:locktype>
+      <D:lockscope><D:exclusive/></D:lockscope>
+      <D:depth>infinity</D:depth>
+      <D:locktoken><D:href>` + token + `</D:href></D:locktoken>
+    </D:activelock>
+  </D:lockdiscovery>
+</D:prop>`))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(nil, ts.URL+"/dav/")
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+
+	lock, err := c.Lock(context.Background(), "file.txt", nil)
+	if err != nil {
+		t.Fatalf("Lock() = %v", err)
+	}
+	if lock.Root != "/dav/file.txt" {
+		t.Errorf("Lock() root = %q, want /dav/file.txt", lock.Root)
+	}
+}
+
+func TestIfHeader(t *testing.T) {
+	tcs := []struct {
+		header IfHeader
+		want   string
+	}{
+		{
+			header: IfHeader{{Conditions: []IfCondition{{Token: "urn:uuid:a"}, {ETag: "v1"}}}},
+			want:   `(<urn:uuid:a> ["v1"])`,
+		},
+		{
+			header: IfHeader{
+				{Conditions: []IfCondition{{Token: "urn:uuid:a"}}},
+				{Conditions: []IfCondition{{Not: true, Token: "urn:uuid:b"}}},
+			},
+			want: `(<urn:uuid:a>) (Not <urn:uuid:b>)`,
+		},
+		{
+			header: IfHeader{
+				{Resource: "/a", Conditions: []IfCondition{{Token: "urn:uuid:a"}}},
+				{Resource: "/a", Conditions: []IfCondition{{ETag: "v1"}}},
+				{Resource: "/b", Conditions: []IfCondition{{Token: "urn:uuid:b"}}},
+			},
+			want: `</a> (<urn:uuid:a>) (["v1"]) </b> (<urn:uuid:b>)`,
+		},
+	}
+	for _, tc := range tcs {
+		if got := tc.header.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+
+	mixed := IfHeader{
+		{Conditions: []IfCondition{{Token: "urn:uuid:a"}}},
+		{Resource: "/b", Conditions: []IfCondition{{Token: "urn:uuid:b"}}},
+	}
+	if s, err := mixed.Format(); err == nil {
+		t.Errorf("Format() = %q, want an error for mixed lists", s)
+	}
+	c, err := NewClient(nil, "http://example.org/")
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	if err := c.RemoveAllWithOptions(context.Background(), "/b", &RemoveAllOptions{If: mixed}); err == nil {
+		t.Errorf("RemoveAllWithOptions() succeeded with mixed If lists")
+	}
+}
+
+func TestClientQuota(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PROPFIND" {
+			t.Errorf("unexpected %v request", r.Method)
+			return
+		}
+
+		var quota string
+		switch r.URL.Path {
+		case "/full/":
+			quota = `
+      <D:quota-used-bytes>4096</D:quota-used-bytes>
+      <D:quota-available-bytes>1024</D:quota-available-bytes>`
+		case "/unlimited/":
+			quota = `
+      <D:quota-used-bytes>4096</D:quota-used-bytes>`
+		}
+		// Only report quota when it's requested
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "quota-used-bytes") {
+			quota = ""
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:">
+  <D:response>
+    <D:href>` + r.URL.Path + `</D:href>
+    <D:propstat>
+      <D:prop>
+        <D:resourcetype><D:collection/></D:resourcetype>` + quota + `
+      </D:prop>
+      <D:status>HTTP/1.1 200 OK</D:status>
+    </D:propstat>
+    <D:propstat>
+      <D:prop><D:quota-available-bytes/></D:prop>
+      <D:status>HTTP/1.1 404 Not Found</D:status>
+    </D:propstat>
+  </D:response>
+</D:multistatus>`))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(nil, ts.URL)
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	ctx := context.Background()
+
+	quota, err := c.Quota(ctx, "/full/")
+	if err != nil {
+		t.Fatalf("Quota() = %v", err)
+	}
+	if *quota != (Quota{Used: 4096, Available: 1024}) || quota.Total() != 5120 {
+		t.Errorf("Quota() = %+v", quota)
+	}
+
+	quota, err = c.Quota(ctx, "/unlimited/")
+	if err != nil {
+		t.Fatalf("Quota() = %v", err)
+	}
+	if *quota != (Quota{Used: 4096, Available: QuotaUnknown}) || quota.Total() != QuotaUnknown {
+		t.Errorf("Quota() = %+v", quota)
+	}
+
+	if _, err := c.Quota(ctx, "/none/"); !errors.Is(err, ErrQuotaNotSupported) {
+		t.Errorf("Quota() = %v, want ErrQuotaNotSupported", err)
+	}
+
+	fi, err := c.StatWithOptions(ctx, "/full/", &StatOptions{Quota: true})
+	if err != nil {
+		t.Fatalf("StatWithOptions() = %v", err)
+	}
+	if fi.Quota == nil || fi.Quota.Used != 4096 {
+		t.Errorf("StatWithOptions() quota = %+v", fi.Quota)
+	}
+	fi, err = c.StatWithOptions(ctx, "/none/", &StatOptions{Quota: true})
+	if err != nil {
+		t.Fatalf("StatWithOptions() = %v", err)
+	}
+	if fi.Quota != nil {
+		t.Errorf("StatWithOptions() quota = %+v, want nil", fi.Quota)
+	}
+	fi, err = c.Stat(ctx, "/full/")
+	if err != nil {
+		t.Fatalf("Stat() = %v", err)
+	}
+	if fi.Quota != nil {
+		t.Errorf("Stat() quota = %+v, want nil", fi.Quota)
+	}
+
+	files, err := c.ReadDirWithOptions(ctx, "/full/", false, &ReadDirOptions{Quota: true})
+	if err != nil {
+		t.Fatalf("ReadDirWithOptions() = %v", err)
+	}
+	if len(files) != 1 || files[0].Quota == nil || files[0].Quota.Available != 1024 {
+		t.Errorf("ReadDirWithOptions() = %+v", files)
+	}
+}
+
+func TestClientPropFindPropPatch(t *testing.T) {
+	orderName := xml.Name{Space: "http://apple.com/ns/ical/", Local: "calendar-order"}
+	flagName := xml.Name{Space: "urn:x-example", Local: "flag"}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		switch r.Method {
+		case "PROPFIND":
+			if depth := r.Header.Get("Depth"); depth != "1" {
+				t.Errorf("PROPFIND: got Depth %q, want 1", depth)
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:" xmlns:A="http://apple.com/ns/ical/" xmlns:X="urn:x-example">
+  <D:response>
+    <D:href>/cal/</D:href>
+    <D:propstat>
+      <D:prop><A:calendar-order>3</A:calendar-order></D:prop>
+      <D:status>HTTP/1.1 200 OK</D:status>
+    </D:propstat>
+    <D:propstat>
+      <D:prop><X:flag/></D:prop>
+      <D:status>HTTP/1.1 404 Not Found</D:status>
+    </D:propstat>
+  </D:response>
+  <D:response>
+    <D:href>/cal/gone/</D:href>
+    <D:status>HTTP/1.1 403 Forbidden</D:status>
+  </D:response>
+</D:multistatus>`))
+		case "PROPPATCH":
+			var update internal.PropertyUpdate
+			if err := xml.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Errorf("PROPPATCH: failed to decode body: %v", err)
+			}
+			if len(update.Set) != 1 || update.Set[0].Prop.Get(orderName) == nil {
+				t.Errorf("PROPPATCH: unexpected set %+v", update.Set)
+			}
+			if len(update.Remove) != 1 || update.Remove[0].Prop.Get(flagName) == nil {
+				t.Errorf("PROPPATCH: unexpected remove %+v", update.Remove)
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:" xmlns:A="http://apple.com/ns/ical/" xmlns:X="urn:x-example">
+  <D:response>
+    <D:href>/cal/</D:href>
+    <D:propstat>
+      <D:prop><A:calendar-order/></D:prop>
+      <D:status>HTTP/1.1 424 Failed Dependency</D:status>
+    </D:propstat>
+    <D:propstat>
+      <D:prop><X:flag/></D:prop>
+      <D:status>HTTP/1.1 403 Forbidden</D:status>
+    </D:propstat>
+  </D:response>
+</D:multistatus>`))
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(nil, ts.URL)
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	ctx := context.Background()
+
+	resps, err := c.PropFind(ctx, "/cal/", DepthOne, orderName, flagName)
+	if err != nil {
+		t.Fatalf("PropFind() = %v", err)
+	}
+	if len(resps) != 2 {
+		t.Fatalf("PropFind() returned %d responses, want 2", len(resps))
+	}
+
+	if order := resps[0].Get(orderName); order == nil {
+		t.Errorf("PropFind(): missing calendar-order")
+	} else if text, err := order.Text(); err != nil || text != "3" {
+		t.Errorf("calendar-order Text() = %q, %v", text, err)
+	}
+	if flag := resps[0].Get(flagName); flag == nil || flag.Status != http.StatusNotFound || flag.Value != nil {
+		t.Errorf("PropFind(): unexpected flag %+v", flag)
+	} else if _, err := flag.Text(); !IsNotFound(err) {
+		t.Errorf("flag Text() = %v, want not found", err)
+	}
+	if resps[1].Path != "/cal/gone/" || StatusCode(resps[1].Err) != http.StatusForbidden {
+		t.Errorf("PropFind(): unexpected second response %+v", resps[1])
+	}
+
+	props, err := c.PropPatch(ctx, "/cal/", []*RawXMLValue{NewTextProperty(orderName, "4")}, []xml.Name{flagName})
+	if err != nil {
+		t.Fatalf("PropPatch() = %v", err)
+	}
+	want := []Property{
+		{Name: orderName, Status: http.StatusFailedDependency},
+		{Name: flagName, Status: http.StatusForbidden},
+	}
+	if len(props) != len(want) {
+		t.Fatalf("PropPatch() returned %d properties, want %d", len(props), len(want))
+	}
+	for i := range want {
+		if props[i] != want[i] {
+			t.Errorf("PropPatch() property %d = %+v, want %+v", i, props[i], want[i])
+		}
+	}
+}
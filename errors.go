@@ -0,0 +1,141 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/yinjun1991/caldav-client-go/internal"
+)
+
+// HTTPError is an error associated with an HTTP status code, returned by the
+// methods of Client and caldav.Client when the server replies with an error
+// status. Err may wrap an Error describing the failed precondition.
+type HTTPError = internal.HTTPError
+
+// Error is a DAV:error element, defined in RFC 4918 section 14.5. Servers use
+// it to report the precondition or postcondition which failed.
+type Error = internal.Error
+
+// ErrPreconditionFailed is matched by errors returned when a conditional
+// request fails with 412 Precondition Failed.
+var ErrPreconditionFailed = errors.New("webdav: precondition failed")
+
+// ErrQuotaNotSupported is returned by Client.Quota when the server doesn't
+// report quota for a resource.
+var ErrQuotaNotSupported = errors.New("webdav: server doesn't report quota")
+
+// PreconditionFailedError is returned when the server rejects a conditional
+// request with 412 Precondition Failed, e.g. because the file was modified
+// concurrently.
+type PreconditionFailedError struct {
+	Path string
+	// ETag is the current ETag of the file, if the server reported it in the
+	// 412 response.
+	ETag string
+	// Err is the underlying HTTP error.
+	Err error
+}
+
+func newPreconditionFailedError(path string, err error) *PreconditionFailedError {
+	pfErr := &PreconditionFailedError{Path: path, Err: err}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Header != nil {
+		pfErr.ETag = internal.ParseETagHeader(httpErr.Header)
+	}
+	return pfErr
+}
+
+func (err *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("webdav: precondition failed for %s", err.Path)
+}
+
+func (err *PreconditionFailedError) Unwrap() error {
+	return err.Err
+}
+
+func (err *PreconditionFailedError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+var (
+	// ValidSyncTokenName is the precondition reported when a sync-collection
+	// request contains an invalid or expired sync token (RFC 6578 section
+	// 3.2).
+	ValidSyncTokenName = xml.Name{internal.Namespace, "valid-sync-token"}
+	// NumberOfMatchesWithinLimitsName is the postcondition reported when a
+	// request matched more results than the server is willing to return
+	// (RFC 6578 section 3.6).
+	NumberOfMatchesWithinLimitsName = xml.Name{internal.Namespace, "number-of-matches-within-limits"}
+	// LockTokenSubmittedName is the precondition reported when a request
+	// didn't submit the lock token of a locked resource (RFC 4918 section
+	// 16).
+	LockTokenSubmittedName = xml.Name{internal.Namespace, "lock-token-submitted"}
+	// NoConflictingLockName is the precondition reported when a LOCK request
+	// conflicts with an existing lock (RFC 4918 section 16).
+	NoConflictingLockName = xml.Name{internal.Namespace, "no-conflicting-lock"}
+)
+
+// StatusCode returns the HTTP status code associated with err, or zero if err
+// isn't an HTTP error.
+func StatusCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return 0
+}
+
+// Conditions returns the names of the precondition and postcondition elements
+// reported by the server in the DAV:error element wrapped by err, if any.
+func Conditions(err error) []xml.Name {
+	var davErr *Error
+	if errors.As(err, &davErr) {
+		return davErr.Conditions()
+	}
+	return nil
+}
+
+// HasCondition reports whether the server reported the precondition or
+// postcondition name in the DAV:error element wrapped by err.
+func HasCondition(err error, name xml.Name) bool {
+	for _, n := range Conditions(err) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an HTTP error with the status code 404
+// Not Found.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsPreconditionFailed reports whether err is an HTTP error with the status
+// code 412 Precondition Failed, e.g. because an If-Match condition didn't
+// match.
+func IsPreconditionFailed(err error) bool {
+	return StatusCode(err) == http.StatusPreconditionFailed
+}
+
+// IsLocked reports whether err is an HTTP error with the status code 423
+// Locked, e.g. because a lock token wasn't submitted for a locked resource.
+func IsLocked(err error) bool {
+	return StatusCode(err) == http.StatusLocked
+}
+
+// IsInsufficientStorage reports whether err is an HTTP error with the status
+// code 507 Insufficient Storage.
+func IsInsufficientStorage(err error) bool {
+	return StatusCode(err) == http.StatusInsufficientStorage
+}
+
+// IsInvalidSyncToken reports whether err indicates that the sync token used
+// in a sync-collection request is invalid or has expired. The client should
+// then perform a full synchronization.
+func IsInvalidSyncToken(err error) bool {
+	return HasCondition(err, ValidSyncTokenName)
+}
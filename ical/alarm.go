@@ -0,0 +1,520 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alarm actions as defined in RFC 5545 section 3.8.6.1.
+const (
+	ActionAudio   = "AUDIO"
+	ActionDisplay = "DISPLAY"
+	ActionEmail   = "EMAIL"
+)
+
+// Alarm properties as defined in RFC 9074, and the equivalent extensions
+// used by Mozilla clients.
+const (
+	PropAcknowledged = "ACKNOWLEDGED"
+	PropProximity    = "PROXIMITY"
+
+	// PropMozLastAck is the time the alarms of a component were last
+	// acknowledged.
+	PropMozLastAck = "X-MOZ-LASTACK"
+	// PropMozSnoozeTime is the time a snoozed alarm fires again. For
+	// recurring components, it's suffixed with "-" and the start of the
+	// occurrence in microseconds since the Unix epoch.
+	PropMozSnoozeTime = "X-MOZ-SNOOZE-TIME"
+)
+
+// RelTypeSnooze is the RELTYPE of the RELATED-TO property linking a snooze
+// alarm to the snoozed alarm, as defined in RFC 9074 section 7.
+const RelTypeSnooze = "SNOOZE"
+
+// Alarm is a VALARM component.
+type Alarm struct {
+	*Component
+}
+
+// NewAlarm creates a new VALARM component with the specified ACTION.
+func NewAlarm(action string) *Alarm {
+	alarm := &Alarm{NewComponent(CompAlarm)}
+	alarm.Props.SetText(PropAction, action)
+	return alarm
+}
+
+// Alarms returns the VALARM components of a VEVENT or VTODO component.
+func (comp *Component) Alarms() []Alarm {
+	l := comp.ChildrenByName(CompAlarm)
+	alarms := make([]Alarm, len(l))
+	for i, child := range l {
+		alarms[i] = Alarm{child}
+	}
+	return alarms
+}
+
+// Trigger is the value of a TRIGGER property, as defined in RFC 5545 section
+// 3.8.6.3.
+type Trigger struct {
+	// Absolute is the time of an absolute trigger. It's zero for relative
+	// triggers.
+	Absolute time.Time
+	// Duration is the offset of a relative trigger from the start of the
+	// component, or from its end if RelatedEnd is set.
+	Duration   time.Duration
+	RelatedEnd bool
+}
+
+// IsAbsolute reports whether the trigger is absolute.
+func (trigger *Trigger) IsAbsolute() bool {
+	return !trigger.Absolute.IsZero()
+}
+
+// Trigger returns the TRIGGER value.
+func (prop *Prop) Trigger() (*Trigger, error) {
+	if prop.ValueType() == ValueDateTime {
+		t, err := prop.DateTime(time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		return &Trigger{Absolute: t}, nil
+	}
+	d, err := prop.Duration()
+	if err != nil {
+		return nil, err
+	}
+	return &Trigger{
+		Duration:   d,
+		RelatedEnd: strings.EqualFold(prop.Params.Get(ParamRelated), "END"),
+	}, nil
+}
+
+// SetTrigger sets a TRIGGER value. Absolute triggers are written in UTC.
+func (prop *Prop) SetTrigger(trigger *Trigger) {
+	prop.Params.Del(ParamRelated)
+	if trigger.IsAbsolute() {
+		prop.SetDateTime(trigger.Absolute.UTC())
+		return
+	}
+	prop.Params.Del(ParamValue)
+	prop.SetDuration(trigger.Duration)
+	if trigger.RelatedEnd {
+		prop.Params.Set(ParamRelated, "END")
+	}
+}
+
+// Action returns the ACTION of the alarm.
+func (alarm Alarm) Action() string {
+	action, _ := alarm.Props.Text(PropAction)
+	return strings.ToUpper(action)
+}
+
+// Trigger returns the TRIGGER of the alarm.
+func (alarm Alarm) Trigger() (*Trigger, error) {
+	prop := alarm.Props.Get(PropTrigger)
+	if prop == nil {
+		return nil, fmt.Errorf("ical: VALARM has no TRIGGER")
+	}
+	return prop.Trigger()
+}
+
+// SetTrigger sets the TRIGGER of the alarm.
+func (alarm Alarm) SetTrigger(trigger *Trigger) {
+	prop := NewProp(PropTrigger)
+	prop.SetTrigger(trigger)
+	alarm.Props.Set(prop)
+}
+
+// Repeat returns the number of additional repetitions of the alarm and the
+// delay between them, from the REPEAT and DURATION properties.
+func (alarm Alarm) Repeat() (count int, interval time.Duration, err error) {
+	repeat := alarm.Props.Get(PropRepeat)
+	duration := alarm.Props.Get(PropDuration)
+	if repeat == nil || duration == nil {
+		return 0, 0, nil
+	}
+	if count, err = repeat.Int(); err != nil {
+		return 0, 0, err
+	}
+	if interval, err = duration.Duration(); err != nil {
+		return 0, 0, err
+	}
+	if count < 0 || interval <= 0 {
+		return 0, 0, fmt.Errorf("ical: invalid VALARM repetition: REPEAT %d, DURATION %v", count, duration.Value)
+	}
+	return count, interval, nil
+}
+
+// Acknowledged returns the time the alarm was last acknowledged, from the
+// ACKNOWLEDGED property. It returns the zero time if the alarm was never
+// acknowledged.
+func (alarm Alarm) Acknowledged() (time.Time, error) {
+	prop := alarm.Props.Get(PropAcknowledged)
+	if prop == nil {
+		return time.Time{}, nil
+	}
+	return prop.DateTime(time.UTC)
+}
+
+// Acknowledge sets the ACKNOWLEDGED property of the alarm. All the
+// occurrences of the alarm triggering at or before t are acknowledged.
+func (alarm Alarm) Acknowledge(t time.Time) {
+	prop := NewProp(PropAcknowledged)
+	prop.SetDateTime(t.UTC())
+	alarm.Props.Set(prop)
+}
+
+// SnoozedUID returns the UID of the alarm snoozed by this alarm, or an empty
+// string if it isn't a snooze alarm.
+func (alarm Alarm) SnoozedUID() string {
+	for _, prop := range alarm.Props.Values(PropRelatedTo) {
+		if strings.EqualFold(prop.Params.Get(ParamRelationshipType), RelTypeSnooze) {
+			return strings.TrimSpace(prop.Value)
+		}
+	}
+	return ""
+}
+
+// SnoozeAlarm snoozes an alarm of comp until the specified time, following
+// RFC 9074 section 7: alarm is acknowledged at now and a snooze alarm
+// triggering at until is added to comp, replacing previous snooze alarms of
+// the same alarm. A UID is added to alarm if it has none.
+func (comp *Component) SnoozeAlarm(alarm Alarm, until, now time.Time) Alarm {
+	uid, _ := alarm.Props.Text(PropUID)
+	if uid == "" {
+		uid = NewUID()
+		alarm.Props.SetText(PropUID, uid)
+	}
+	alarm.Acknowledge(now)
+
+	children := comp.Children[:0]
+	for _, child := range comp.Children {
+		if child.Name == CompAlarm && (Alarm{child}).SnoozedUID() == uid {
+			continue
+		}
+		children = append(children, child)
+	}
+	comp.Children = children
+
+	snooze := Alarm{alarm.Clone()}
+	for _, name := range []string{PropAcknowledged, PropRepeat, PropDuration, PropRelatedTo} {
+		snooze.Props.Del(name)
+	}
+	snooze.Props.SetText(PropUID, NewUID())
+	related := NewProp(PropRelatedTo)
+	related.Params.Set(ParamRelationshipType, RelTypeSnooze)
+	related.Value = uid
+	snooze.Props.Add(related)
+	snooze.SetTrigger(&Trigger{Absolute: until})
+	comp.Children = append(comp.Children, snooze.Component)
+	return snooze
+}
+
+// AlarmInstance is a time at which an alarm fires.
+type AlarmInstance struct {
+	// Time is when the alarm fires.
+	Time time.Time
+	// Alarm is the VALARM component.
+	Alarm Alarm
+	// Component is the VEVENT or VTODO component containing the alarm: the
+	// master component or an overridden component.
+	Component *Component
+	// RecurrenceID identifies the occurrence of a recurring component the
+	// alarm is for. It's zero for non-recurring components and for absolute
+	// triggers of the master component.
+	RecurrenceID time.Time
+	// Start is the start of the occurrence the alarm is for, if any.
+	Start time.Time
+	// Repetition is the index of the repetition defined by REPEAT and
+	// DURATION, zero for the initial trigger.
+	Repetition int
+	// Snoozed reports whether the alarm was snoozed, either with an RFC 9074
+	// snooze alarm or with X-MOZ-SNOOZE-TIME.
+	Snoozed bool
+}
+
+// UpcomingAlarms returns the alarms of the VEVENT and VTODO components of
+// the calendar firing in the window [start, end), sorted by time.
+//
+// Relative triggers are evaluated for each occurrence of recurring
+// components, taking overridden components into account, and absolute
+// triggers fire once. Alarm instances firing at or before the ACKNOWLEDGED
+// time of their alarm or the X-MOZ-LASTACK time of their component are
+// skipped, as well as location-based alarms (RFC 9074 PROXIMITY), cancelled
+// components and completed to-dos. loc is used like in Prop.DateTime.
+func (cal *Calendar) UpcomingAlarms(start, end time.Time, loc *time.Location) ([]AlarmInstance, error) {
+	// Occurrences of recurring components handled by an overridden component
+	overridden := make(map[string]map[int64]bool)
+	for _, comp := range cal.Children {
+		rid := comp.Props.Get(PropRecurrenceID)
+		if rid == nil || comp.Name == CompTimezone {
+			continue
+		}
+		t, err := rid.DateTime(cal.propLocation(rid, loc))
+		if err != nil {
+			return nil, err
+		}
+		uid, _ := comp.Props.Text(PropUID)
+		if overridden[uid] == nil {
+			overridden[uid] = make(map[int64]bool)
+		}
+		overridden[uid][t.UnixNano()] = true
+	}
+
+	ev := &alarmEvaluator{cal: cal, start: start, end: end, loc: loc}
+	for _, comp := range cal.Children {
+		if comp.Name != CompEvent && comp.Name != CompToDo {
+			continue
+		}
+		uid, _ := comp.Props.Text(PropUID)
+		if err := ev.component(comp, overridden[uid]); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(ev.instances, func(i, j int) bool {
+		return ev.instances[i].Time.Before(ev.instances[j].Time)
+	})
+	return ev.instances, nil
+}
+
+type alarmEvaluator struct {
+	cal        *Calendar
+	start, end time.Time
+	loc        *time.Location
+	instances  []AlarmInstance
+}
+
+// parsedAlarm is an alarm with its parsed properties.
+type parsedAlarm struct {
+	alarm    Alarm
+	trigger  *Trigger
+	repeat   int
+	interval time.Duration
+	ack      time.Time
+}
+
+func isInactive(comp *Component) bool {
+	status, _ := comp.Props.Text(PropStatus)
+	switch strings.ToUpper(status) {
+	case "CANCELLED":
+		return true
+	case "COMPLETED":
+		return comp.Name == CompToDo
+	}
+	return comp.Name == CompToDo && comp.Props.Get(PropCompleted) != nil
+}
+
+func (ev *alarmEvaluator) component(comp *Component, overridden map[int64]bool) error {
+	if isInactive(comp) {
+		return nil
+	}
+
+	var lastAck time.Time
+	if prop := comp.Props.Get(PropMozLastAck); prop != nil {
+		t, err := prop.DateTime(time.UTC)
+		if err != nil {
+			return err
+		}
+		lastAck = t
+	}
+
+	var alarms []*parsedAlarm
+	for _, alarm := range comp.Alarms() {
+		if alarm.Props.Get(PropProximity) != nil {
+			continue
+		}
+		pa := &parsedAlarm{alarm: alarm}
+		var err error
+		if pa.trigger, err = alarm.Trigger(); err != nil {
+			return err
+		}
+		if pa.repeat, pa.interval, err = alarm.Repeat(); err != nil {
+			return err
+		}
+		if pa.ack, err = alarm.Acknowledged(); err != nil {
+			return err
+		}
+		if lastAck.After(pa.ack) {
+			pa.ack = lastAck
+		}
+		alarms = append(alarms, pa)
+	}
+
+	var recurrenceID time.Time
+	if rid := comp.Props.Get(PropRecurrenceID); rid != nil {
+		t, err := rid.DateTime(ev.cal.propLocation(rid, ev.loc))
+		if err != nil {
+			return err
+		}
+		recurrenceID = t
+	}
+
+	// Absolute triggers fire once
+	for _, pa := range alarms {
+		if pa.trigger.IsAbsolute() {
+			ev.add(pa, comp, recurrenceID, time.Time{}, pa.trigger.Absolute)
+		}
+	}
+
+	if err := ev.mozSnoozes(comp, alarms, lastAck, recurrenceID); err != nil {
+		return err
+	}
+
+	// Relative triggers fire for each occurrence
+	var relative []*parsedAlarm
+	for _, pa := range alarms {
+		if !pa.trigger.IsAbsolute() {
+			relative = append(relative, pa)
+		}
+	}
+	if len(relative) == 0 {
+		return nil
+	}
+
+	if comp.Props.Get(PropDateTimeStart) == nil {
+		// To-dos may only have a DUE, both START and END triggers are
+		// relative to it
+		due := comp.Props.Get(PropDue)
+		if due == nil {
+			return fmt.Errorf("ical: %s with alarms has no DTSTART", comp.Name)
+		}
+		t, err := due.DateTime(ev.cal.propLocation(due, ev.loc))
+		if err != nil {
+			return err
+		}
+		for _, pa := range relative {
+			ev.add(pa, comp, recurrenceID, t, t.Add(pa.trigger.Duration))
+		}
+		return nil
+	}
+
+	set, err := ev.cal.RecurrenceSet(comp, ev.loc)
+	if err != nil {
+		return err
+	}
+	duration, err := componentDuration(comp, set.Start)
+	if err != nil {
+		return err
+	}
+
+	// Occurrences starting after end-minOffset can't fire in the window
+	var minOffset time.Duration
+	for i, pa := range relative {
+		offset := pa.trigger.Duration
+		if pa.trigger.RelatedEnd {
+			offset += duration
+		}
+		if i == 0 || offset < minOffset {
+			minOffset = offset
+		}
+	}
+
+	if !recurrenceID.IsZero() {
+		// Overridden components have a single occurrence
+		set = &RecurrenceSet{Start: set.Start}
+	}
+	for occ := range set.All() {
+		if !occ.Add(minOffset).Before(ev.end) {
+			break
+		}
+		if overridden[occ.UnixNano()] && recurrenceID.IsZero() {
+			continue
+		}
+		rid := recurrenceID
+		if set.IsRecurring() {
+			rid = occ
+		}
+		for _, pa := range relative {
+			base := occ
+			if pa.trigger.RelatedEnd {
+				base = occ.Add(duration)
+			}
+			ev.add(pa, comp, rid, occ, base.Add(pa.trigger.Duration))
+		}
+	}
+	return nil
+}
+
+// add records the instances of an alarm triggering at t, with its
+// repetitions, which fire in the window and weren't acknowledged.
+func (ev *alarmEvaluator) add(pa *parsedAlarm, comp *Component, rid, start, t time.Time) {
+	for i := 0; i <= pa.repeat; i++ {
+		at := t.Add(time.Duration(i) * pa.interval)
+		if !at.Before(ev.end) {
+			break
+		}
+		if at.Before(ev.start) || !at.After(pa.ack) {
+			continue
+		}
+		ev.instances = append(ev.instances, AlarmInstance{
+			Time:         at,
+			Alarm:        pa.alarm,
+			Component:    comp,
+			RecurrenceID: rid,
+			Start:        start,
+			Repetition:   i,
+			Snoozed:      pa.alarm.SnoozedUID() != "",
+		})
+	}
+}
+
+// mozSnoozes records the alarms snoozed with X-MOZ-SNOOZE-TIME properties.
+// Mozilla clients snooze all the alarms of a component at once, the first
+// alarm is reported.
+func (ev *alarmEvaluator) mozSnoozes(comp *Component, alarms []*parsedAlarm, lastAck, recurrenceID time.Time) error {
+	if len(alarms) == 0 {
+		return nil
+	}
+	for _, prop := range comp.Props {
+		if prop.Name != PropMozSnoozeTime && !strings.HasPrefix(prop.Name, PropMozSnoozeTime+"-") {
+			continue
+		}
+		rid := recurrenceID
+		if suffix := strings.TrimPrefix(prop.Name, PropMozSnoozeTime); suffix != "" {
+			micros, err := strconv.ParseInt(suffix[1:], 10, 64)
+			if err != nil {
+				continue
+			}
+			rid = time.UnixMicro(micros)
+		}
+		t, err := prop.DateTime(time.UTC)
+		if err != nil {
+			return err
+		}
+		if t.Before(ev.start) || !t.Before(ev.end) || !t.After(lastAck) {
+			continue
+		}
+		ev.instances = append(ev.instances, AlarmInstance{
+			Time:         t,
+			Alarm:        alarms[0].alarm,
+			Component:    comp,
+			RecurrenceID: rid,
+			Start:        rid,
+			Snoozed:      true,
+		})
+	}
+	return nil
+}
+
+// componentDuration returns the duration of a component starting at start.
+func componentDuration(comp *Component, start time.Time) (time.Duration, error) {
+	if prop := comp.Props.Get(PropDuration); prop != nil {
+		return prop.Duration()
+	}
+	for _, name := range []string{PropDateTimeEnd, PropDue} {
+		if prop := comp.Props.Get(name); prop != nil {
+			t, err := prop.DateTime(start.Location())
+			if err != nil {
+				return 0, err
+			}
+			return t.Sub(start), nil
+		}
+	}
+	if comp.Name == CompEvent && comp.Props.Get(PropDateTimeStart).IsDate() {
+		return 24 * time.Hour, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,234 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const alarmCalendarStr = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20240101T000000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T093000Z
+RRULE:FREQ=DAILY;COUNT=5
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Standup
+TRIGGER:-PT15M
+REPEAT:1
+DURATION:PT10M
+ACKNOWLEDGED:20240102T084500Z
+END:VALARM
+BEGIN:VALARM
+ACTION:AUDIO
+TRIGGER;RELATED=END:PT0S
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;VALUE=DATE-TIME:20240101T120000Z
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;VALUE=DATE-TIME:19760401T005545Z
+PROXIMITY:ARRIVE
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID:20240103T090000Z
+DTSTART:20240103T140000Z
+DTEND:20240103T143000Z
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT5M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID:20240104T090000Z
+DTSTART:20240104T090000Z
+DTEND:20240104T093000Z
+STATUS:CANCELLED
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT5M
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestUpcomingAlarms(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(alarmCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)
+	instances, err := cal.UpcomingAlarms(start, end, time.UTC)
+	if err != nil {
+		t.Fatalf("UpcomingAlarms() = %v", err)
+	}
+
+	var got []string
+	for _, inst := range instances {
+		s := inst.Time.Format(utcDateTimeFormat) + " " + inst.Alarm.Action()
+		if inst.Repetition > 0 {
+			s += " repeat"
+		}
+		if !inst.RecurrenceID.IsZero() {
+			s += " " + inst.RecurrenceID.Format(utcDateTimeFormat)
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		// The first alarm is acknowledged up to the second occurrence
+		"20240101T093000Z AUDIO 20240101T090000Z",
+		"20240101T120000Z DISPLAY",
+		"20240102T085500Z DISPLAY repeat 20240102T090000Z",
+		"20240102T093000Z AUDIO 20240102T090000Z",
+		// The third occurrence is overridden and the fourth one cancelled
+		"20240103T135500Z DISPLAY 20240103T090000Z",
+		"20240105T084500Z DISPLAY 20240105T090000Z",
+		"20240105T085500Z DISPLAY repeat 20240105T090000Z",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("UpcomingAlarms() =\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSnoozeAlarm(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(alarmCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	master := cal.Master()
+	alarm := master.Alarms()[1]
+
+	now := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	master.SnoozeAlarm(alarm, now.Add(5*time.Minute), now)
+	snooze := master.SnoozeAlarm(alarm, now.Add(10*time.Minute), now)
+	if len(master.Alarms()) != 5 {
+		t.Fatalf("expected previous snooze alarm to be replaced, got %d alarms", len(master.Alarms()))
+	}
+	uid, _ := alarm.Props.Text(PropUID)
+	if uid == "" || snooze.SnoozedUID() != uid {
+		t.Errorf("snooze alarm isn't related to the snoozed alarm")
+	}
+
+	instances, err := cal.UpcomingAlarms(now, now.Add(time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("UpcomingAlarms() = %v", err)
+	}
+	if len(instances) != 1 || !instances[0].Snoozed || !instances[0].Time.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("unexpected instances after snoozing: %+v", instances)
+	}
+}
+
+func TestUpcomingAlarmsMozilla(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VTODO
+UID:task
+DTSTAMP:20240101T000000Z
+DUE:20240110T170000Z
+X-MOZ-LASTACK:20240110T163100Z
+X-MOZ-SNOOZE-TIME:20240110T164500Z
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;RELATED=END:-PT30M
+END:VALARM
+END:VTODO
+END:VCALENDAR
+`)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	instances, err := cal.UpcomingAlarms(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), time.UTC)
+	if err != nil {
+		t.Fatalf("UpcomingAlarms() = %v", err)
+	}
+	if len(instances) != 1 || !instances[0].Snoozed || instances[0].Time.Format(utcDateTimeFormat) != "20240110T164500Z" {
+		t.Errorf("unexpected instances: %+v", instances)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	prop := NewProp(PropTrigger)
+	prop.SetTrigger(&Trigger{Duration: -15 * time.Minute, RelatedEnd: true})
+	if prop.Value != "-PT15M" || prop.Params.Get(ParamRelated) != "END" {
+		t.Errorf("SetTrigger() = %v %v", prop.Params, prop.Value)
+	}
+
+	abs := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	prop.SetTrigger(&Trigger{Absolute: abs})
+	trigger, err := prop.Trigger()
+	if err != nil {
+		t.Fatalf("Trigger() = %v", err)
+	}
+	if !trigger.IsAbsolute() || !trigger.Absolute.Equal(abs) || prop.Params.Get(ParamRelated) != "" {
+		t.Errorf("Trigger() = %+v", trigger)
+	}
+}
+
+func TestUpcomingAlarmsTimezones(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VTIMEZONE
+TZID:Custom
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:custom
+DTSTAMP:20240101T000000Z
+DTSTART;TZID=Custom:20240101T090000
+DTEND;TZID=Custom:20240101T093000
+RRULE:FREQ=DAILY;COUNT=2
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:custom
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID;TZID=Custom:20240102T090000
+DTSTART;TZID=Custom:20240102T100000
+DTEND;TZID=Custom:20240102T103000
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	instances, err := cal.UpcomingAlarms(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		t.Fatalf("UpcomingAlarms() = %v", err)
+	}
+	var got []string
+	for _, inst := range instances {
+		got = append(got, inst.Time.UTC().Format(utcDateTimeFormat))
+	}
+	want := []string{"20240101T074500Z", "20240102T084500Z"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got alarms %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SyntaxError is returned by Decoder when a line of the iCalendar stream is
+// malformed.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("ical: line %d: %s", err.Line, err.Msg)
+}
+
+// Decoder reads iCalendar streams.
+type Decoder struct {
+	br       *bufio.Reader
+	lineNum  int
+	next     string
+	nextLine int
+	hasNext  bool
+}
+
+// NewDecoder creates a new iCalendar decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{br: bufio.NewReader(r)}
+}
+
+// readPhysicalLine reads a single line, without its terminating CRLF or LF.
+func (dec *Decoder) readPhysicalLine() (string, error) {
+	l, err := dec.br.ReadString('\n')
+	if err == io.EOF && l != "" {
+		err = nil
+	} else if err != nil {
+		return "", err
+	}
+	dec.lineNum++
+	l = strings.TrimSuffix(l, "\n")
+	l = strings.TrimSuffix(l, "\r")
+	return l, nil
+}
+
+// readContentLine reads an unfolded content line and the number of the
+// physical line it starts on.
+func (dec *Decoder) readContentLine() (string, int, error) {
+	var (
+		line    string
+		lineNum int
+	)
+	if dec.hasNext {
+		line, lineNum = dec.next, dec.nextLine
+		dec.hasNext = false
+	} else {
+		for {
+			l, err := dec.readPhysicalLine()
+			if err != nil {
+				return "", 0, err
+			}
+			if l != "" {
+				line, lineNum = l, dec.lineNum
+				break
+			}
+		}
+	}
+
+	for {
+		l, err := dec.readPhysicalLine()
+		if err == io.EOF {
+			return line, lineNum, nil
+		} else if err != nil {
+			return "", 0, err
+		}
+		if l == "" {
+			continue
+		}
+		if l[0] != ' ' && l[0] != '\t' {
+			dec.next, dec.nextLine, dec.hasNext = l, dec.lineNum, true
+			return line, lineNum, nil
+		}
+		line += l[1:]
+	}
+}
+
+// Decode reads the next VCALENDAR component. It returns io.EOF when the
+// stream doesn't contain any more components.
+func (dec *Decoder) Decode() (*Calendar, error) {
+	var stack []*Component
+	for {
+		line, lineNum, err := dec.readContentLine()
+		if err == io.EOF {
+			if len(stack) > 0 {
+				return nil, fmt.Errorf("ical: unexpected EOF in %v component", stack[len(stack)-1].Name)
+			}
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, err
+		}
+
+		prop, err := parseContentLine(line)
+		if err != nil {
+			return nil, &SyntaxError{Line: lineNum, Msg: err.Error()}
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			name := strings.ToUpper(prop.Value)
+			if len(stack) == 0 && name != CompCalendar {
+				return nil, &SyntaxError{Line: lineNum, Msg: fmt.Sprintf("expected %v component, got %v", CompCalendar, name)}
+			}
+			comp := NewComponent(name)
+			comp.Line = lineNum
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, comp)
+			}
+			stack = append(stack, comp)
+		case "END":
+			if len(stack) == 0 {
+				return nil, &SyntaxError{Line: lineNum, Msg: "unexpected END"}
+			}
+			comp := stack[len(stack)-1]
+			if name := strings.ToUpper(prop.Value); name != comp.Name {
+				return nil, &SyntaxError{Line: lineNum, Msg: fmt.Sprintf("expected END:%v, got END:%v", comp.Name, name)}
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return &Calendar{comp}, nil
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, &SyntaxError{Line: lineNum, Msg: fmt.Sprintf("property %v outside of a component", prop.Name)}
+			}
+			prop.Line = lineNum
+			comp := stack[len(stack)-1]
+			comp.Props = append(comp.Props, prop)
+		}
+	}
+}
+
+func parseContentLine(line string) (*Prop, error) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return nil, fmt.Errorf("malformed content line %q", line)
+	}
+	prop := NewProp(line[:i])
+	if !isValidName(prop.Name) {
+		return nil, fmt.Errorf("invalid property name %q", line[:i])
+	}
+
+	rest := line[i:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("malformed parameter in %v", prop.Name)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		for {
+			var value string
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated quoted parameter value in %v", prop.Name)
+				}
+				value = rest[1 : end+1]
+				rest = rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ",;:")
+				if end < 0 {
+					return nil, fmt.Errorf("missing value in %v", prop.Name)
+				}
+				value = rest[:end]
+				rest = rest[end:]
+			}
+			prop.Params.Add(name, value)
+
+			if rest == "" {
+				return nil, fmt.Errorf("missing value in %v", prop.Name)
+			}
+			if rest[0] != ',' {
+				break
+			}
+			rest = rest[1:]
+		}
+	}
+	if rest[0] != ':' {
+		return nil, fmt.Errorf("malformed content line for %v", prop.Name)
+	}
+	prop.Value = rest[1:]
+	return prop, nil
+}
+
+func isValidName(name string) bool {
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineLen is the maximum length of a content line in octets, excluding the
+// line break, as defined in RFC 5545 section 3.1.
+const maxLineLen = 75
+
+// Encoder writes iCalendar streams.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder creates a new iCalendar encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w}
+}
+
+// Encode writes a VCALENDAR component.
+func (enc *Encoder) Encode(cal *Calendar) error {
+	if cal == nil || cal.Component == nil {
+		return fmt.Errorf("ical: cannot encode a nil calendar")
+	}
+	if cal.Name != CompCalendar {
+		return fmt.Errorf("ical: expected %v component, got %v", CompCalendar, cal.Name)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeComponent(&buf, cal.Component); err != nil {
+		return err
+	}
+	_, err := enc.w.Write(buf.Bytes())
+	return err
+}
+
+func encodeComponent(buf *bytes.Buffer, comp *Component) error {
+	if !isValidName(comp.Name) {
+		return fmt.Errorf("ical: invalid component name %q", comp.Name)
+	}
+
+	writeFoldedLine(buf, "BEGIN:"+comp.Name)
+	for _, prop := range comp.Props {
+		line, err := formatContentLine(prop)
+		if err != nil {
+			return err
+		}
+		writeFoldedLine(buf, line)
+	}
+	for _, child := range comp.Children {
+		if err := encodeComponent(buf, child); err != nil {
+			return err
+		}
+	}
+	writeFoldedLine(buf, "END:"+comp.Name)
+	return nil
+}
+
+func formatContentLine(prop *Prop) (string, error) {
+	if !isValidName(prop.Name) {
+		return "", fmt.Errorf("ical: invalid property name %q", prop.Name)
+	}
+	if strings.ContainsAny(prop.Value, "\r\n") {
+		return "", fmt.Errorf("ical: property %v value contains a line break", prop.Name)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prop.Name)
+
+	names := make([]string, 0, len(prop.Params))
+	for name := range prop.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !isValidName(name) {
+			return "", fmt.Errorf("ical: invalid parameter name %q in %v", name, prop.Name)
+		}
+		sb.WriteByte(';')
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		for i, value := range prop.Params[name] {
+			if strings.ContainsAny(value, "\"\r\n") {
+				return "", fmt.Errorf("ical: invalid parameter %v value %q in %v", name, value, prop.Name)
+			}
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if strings.ContainsAny(value, ";:,") {
+				sb.WriteByte('"')
+				sb.WriteString(value)
+				sb.WriteByte('"')
+			} else {
+				sb.WriteString(value)
+			}
+		}
+	}
+
+	sb.WriteByte(':')
+	sb.WriteString(prop.Value)
+	return sb.String(), nil
+}
+
+// writeFoldedLine writes a content line, folding it so that no physical line
+// exceeds maxLineLen octets. Multi-octet UTF-8 sequences are never split.
+func writeFoldedLine(buf *bytes.Buffer, line string) {
+	limit := maxLineLen
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		buf.WriteString(line[:i])
+		buf.WriteString("\r\n ")
+		line = line[i:]
+		// The leading space counts towards the line length
+		limit = maxLineLen - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
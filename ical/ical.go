@@ -0,0 +1,368 @@
+// Package ical implements the iCalendar object model.
+//
+// iCalendar is defined in RFC 5545.
+package ical
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// Components as defined in RFC 5545 section 3.6.
+const (
+	CompCalendar         = "VCALENDAR"
+	CompEvent            = "VEVENT"
+	CompToDo             = "VTODO"
+	CompJournal          = "VJOURNAL"
+	CompFreeBusy         = "VFREEBUSY"
+	CompTimezone         = "VTIMEZONE"
+	CompAlarm            = "VALARM"
+	CompTimezoneStandard = "STANDARD"
+	CompTimezoneDaylight = "DAYLIGHT"
+)
+
+// Properties as defined in RFC 5545 section 3.7 and 3.8.
+const (
+	PropCalendarScale      = "CALSCALE"
+	PropMethod             = "METHOD"
+	PropProductID          = "PRODID"
+	PropVersion            = "VERSION"
+	PropAttach             = "ATTACH"
+	PropCategories         = "CATEGORIES"
+	PropClass              = "CLASS"
+	PropComment            = "COMMENT"
+	PropDescription        = "DESCRIPTION"
+	PropGeo                = "GEO"
+	PropLocation           = "LOCATION"
+	PropPercentComplete    = "PERCENT-COMPLETE"
+	PropPriority           = "PRIORITY"
+	PropResources          = "RESOURCES"
+	PropStatus             = "STATUS"
+	PropSummary            = "SUMMARY"
+	PropCompleted          = "COMPLETED"
+	PropDateTimeEnd        = "DTEND"
+	PropDue                = "DUE"
+	PropDateTimeStart      = "DTSTART"
+	PropDuration           = "DURATION"
+	PropFreeBusy           = "FREEBUSY"
+	PropTransparency       = "TRANSP"
+	PropTimezoneID         = "TZID"
+	PropTimezoneName       = "TZNAME"
+	PropTimezoneOffsetFrom = "TZOFFSETFROM"
+	PropTimezoneOffsetTo   = "TZOFFSETTO"
+	PropTimezoneURL        = "TZURL"
+	PropAttendee           = "ATTENDEE"
+	PropContact            = "CONTACT"
+	PropOrganizer          = "ORGANIZER"
+	PropRecurrenceID       = "RECURRENCE-ID"
+	PropRelatedTo          = "RELATED-TO"
+	PropURL                = "URL"
+	PropUID                = "UID"
+	PropExceptionDates     = "EXDATE"
+	PropRecurrenceDates    = "RDATE"
+	PropRecurrenceRule     = "RRULE"
+	PropAction             = "ACTION"
+	PropRepeat             = "REPEAT"
+	PropTrigger            = "TRIGGER"
+	PropCreated            = "CREATED"
+	PropDateTimeStamp      = "DTSTAMP"
+	PropLastModified       = "LAST-MODIFIED"
+	PropSequence           = "SEQUENCE"
+	PropRequestStatus      = "REQUEST-STATUS"
+)
+
+// Parameters as defined in RFC 5545 section 3.2.
+const (
+	ParamAltRep              = "ALTREP"
+	ParamCommonName          = "CN"
+	ParamCalendarUserType    = "CUTYPE"
+	ParamDelegatedFrom       = "DELEGATED-FROM"
+	ParamDelegatedTo         = "DELEGATED-TO"
+	ParamDir                 = "DIR"
+	ParamEncoding            = "ENCODING"
+	ParamFormatType          = "FMTTYPE"
+	ParamFreeBusyType        = "FBTYPE"
+	ParamLanguage            = "LANGUAGE"
+	ParamMember              = "MEMBER"
+	ParamParticipationStatus = "PARTSTAT"
+	ParamRange               = "RANGE"
+	ParamRelated             = "RELATED"
+	ParamRelationshipType    = "RELTYPE"
+	ParamRole                = "ROLE"
+	ParamRSVP                = "RSVP"
+	ParamSentBy              = "SENT-BY"
+	ParamTimezoneID          = "TZID"
+	ParamValue               = "VALUE"
+)
+
+// Params is a set of property parameters. Parameter names are
+// case-insensitive and stored in upper-case.
+type Params map[string][]string
+
+// Values returns all values of the parameter with the specified name.
+func (params Params) Values(name string) []string {
+	return params[strings.ToUpper(name)]
+}
+
+// Get returns the first value of the parameter with the specified name.
+func (params Params) Get(name string) string {
+	if values := params.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set replaces all values of the parameter with the specified name.
+func (params Params) Set(name, value string) {
+	params[strings.ToUpper(name)] = []string{value}
+}
+
+// Add appends a value to the parameter with the specified name.
+func (params Params) Add(name, value string) {
+	name = strings.ToUpper(name)
+	params[name] = append(params[name], value)
+}
+
+// Del removes the parameter with the specified name.
+func (params Params) Del(name string) {
+	delete(params, strings.ToUpper(name))
+}
+
+// Prop is a component property.
+type Prop struct {
+	Name   string
+	Params Params
+	// Value is the raw property value, as it appears in iCalendar text. Use
+	// the typed accessors to decode it.
+	Value string
+	// Line is the number of the line the property starts on when it was
+	// decoded from iCalendar text, zero otherwise.
+	Line int
+}
+
+// NewProp creates a new property with the specified name.
+func NewProp(name string) *Prop {
+	return &Prop{
+		Name:   strings.ToUpper(name),
+		Params: make(Params),
+	}
+}
+
+// Props is an ordered list of component properties.
+type Props []*Prop
+
+// Get returns the first property with the specified name, or nil.
+func (props Props) Get(name string) *Prop {
+	name = strings.ToUpper(name)
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// Values returns all properties with the specified name.
+func (props Props) Values(name string) []*Prop {
+	name = strings.ToUpper(name)
+	var l []*Prop
+	for _, prop := range props {
+		if prop.Name == name {
+			l = append(l, prop)
+		}
+	}
+	return l
+}
+
+// Text returns the unescaped text value of the first property with the
+// specified name. An empty string is returned if the property is missing.
+func (props Props) Text(name string) (string, error) {
+	prop := props.Get(name)
+	if prop == nil {
+		return "", nil
+	}
+	return prop.Text()
+}
+
+// Set replaces all properties named like prop with prop. The position of the
+// first existing property is preserved.
+func (props *Props) Set(prop *Prop) {
+	l := make(Props, 0, len(*props)+1)
+	replaced := false
+	for _, p := range *props {
+		if p.Name != prop.Name {
+			l = append(l, p)
+		} else if !replaced {
+			l = append(l, prop)
+			replaced = true
+		}
+	}
+	if !replaced {
+		l = append(l, prop)
+	}
+	*props = l
+}
+
+// SetText replaces all properties with the specified name with a single TEXT
+// property.
+func (props *Props) SetText(name, text string) {
+	prop := NewProp(name)
+	prop.SetText(text)
+	props.Set(prop)
+}
+
+// Add appends a property.
+func (props *Props) Add(prop *Prop) {
+	*props = append(*props, prop)
+}
+
+// Del removes all properties with the specified name.
+func (props *Props) Del(name string) {
+	name = strings.ToUpper(name)
+	l := (*props)[:0]
+	for _, p := range *props {
+		if p.Name != name {
+			l = append(l, p)
+		}
+	}
+	*props = l
+}
+
+// Component is an iCalendar component.
+type Component struct {
+	Name     string
+	Props    Props
+	Children []*Component
+	// Line is the number of the line of the BEGIN property when the
+	// component was decoded from iCalendar text, zero otherwise.
+	Line int
+}
+
+// NewComponent creates a new component with the specified name.
+func NewComponent(name string) *Component {
+	return &Component{Name: strings.ToUpper(name)}
+}
+
+// ChildrenByName returns all direct children with the specified name.
+func (comp *Component) ChildrenByName(name string) []*Component {
+	name = strings.ToUpper(name)
+	var l []*Component
+	for _, child := range comp.Children {
+		if child.Name == name {
+			l = append(l, child)
+		}
+	}
+	return l
+}
+
+// Clone returns a deep copy of the component.
+func (comp *Component) Clone() *Component {
+	clone := &Component{
+		Name:     comp.Name,
+		Props:    make(Props, len(comp.Props)),
+		Children: make([]*Component, len(comp.Children)),
+		Line:     comp.Line,
+	}
+	for i, prop := range comp.Props {
+		clone.Props[i] = prop.Clone()
+	}
+	for i, child := range comp.Children {
+		clone.Children[i] = child.Clone()
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the property.
+func (prop *Prop) Clone() *Prop {
+	clone := &Prop{
+		Name:   prop.Name,
+		Params: make(Params, len(prop.Params)),
+		Value:  prop.Value,
+		Line:   prop.Line,
+	}
+	for k, v := range prop.Params {
+		clone.Params[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// Calendar is the top-level VCALENDAR component.
+type Calendar struct {
+	*Component
+}
+
+// NewCalendar creates a new empty VCALENDAR component.
+func NewCalendar() *Calendar {
+	return &Calendar{NewComponent(CompCalendar)}
+}
+
+// Events returns the VEVENT components of the calendar.
+func (cal *Calendar) Events() []Event {
+	l := cal.ChildrenByName(CompEvent)
+	events := make([]Event, len(l))
+	for i, comp := range l {
+		events[i] = Event{comp}
+	}
+	return events
+}
+
+// Event is a VEVENT component.
+type Event struct {
+	*Component
+}
+
+// NewEvent creates a new empty VEVENT component.
+func NewEvent() *Event {
+	return &Event{NewComponent(CompEvent)}
+}
+
+// NewUID returns a random UUID, suitable as a UID.
+func NewUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err) // rand.Read never fails
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Equal reports whether two properties have the same name, parameters and
+// value. The order of parameter values is significant.
+func (prop *Prop) Equal(other *Prop) bool {
+	if prop.Name != other.Name || prop.Value != other.Value || len(prop.Params) != len(other.Params) {
+		return false
+	}
+	for name, values := range prop.Params {
+		otherValues, ok := other.Params[name]
+		if !ok || len(values) != len(otherValues) {
+			return false
+		}
+		for i := range values {
+			if values[i] != otherValues[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Equal reports whether two components have the same name, properties and
+// children, in the same order.
+func (comp *Component) Equal(other *Component) bool {
+	if comp.Name != other.Name || len(comp.Props) != len(other.Props) || len(comp.Children) != len(other.Children) {
+		return false
+	}
+	for i := range comp.Props {
+		if !comp.Props[i].Equal(other.Props[i]) {
+			return false
+		}
+	}
+	for i := range comp.Children {
+		if !comp.Children[i].Equal(other.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
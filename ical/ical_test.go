@@ -0,0 +1,180 @@
+package ical
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exampleCalendarStr = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example Corp.//CalDAV Client//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:20240101T120000Z-1@example.com\r\n" +
+	"DTSTAMP:20240101T120000Z\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240102T090000\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"SUMMARY:Planning\\, part 1\r\n" +
+	"DESCRIPTION:A long description which needs to be folded because it is longe\r\n" +
+	" r than seventy-five octets.\r\n" +
+	"ATTENDEE;CN=\"Doe, Jane\";PARTSTAT=ACCEPTED:mailto:jane@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(exampleCalendarStr))
+	cal, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("second Decode() = %v, want io.EOF", err)
+	}
+
+	events := cal.Events()
+	if len(events) != 1 {
+		t.Fatalf("len(Events()) = %v, want 1", len(events))
+	}
+	ev := events[0]
+
+	summary, err := ev.Props.Text(PropSummary)
+	if err != nil {
+		t.Fatalf("Text(SUMMARY) = %v", err)
+	}
+	if summary != "Planning, part 1" {
+		t.Errorf("SUMMARY = %q", summary)
+	}
+
+	desc, _ := ev.Props.Text(PropDescription)
+	if !strings.HasSuffix(desc, "longer than seventy-five octets.") {
+		t.Errorf("DESCRIPTION wasn't unfolded: %q", desc)
+	}
+
+	attendee := ev.Props.Get(PropAttendee)
+	if cn := attendee.Params.Get(ParamCommonName); cn != "Doe, Jane" {
+		t.Errorf("CN = %q", cn)
+	}
+
+	start, err := ev.Props.Get(PropDateTimeStart).DateTime(nil)
+	if err != nil {
+		t.Fatalf("DateTime() = %v", err)
+	}
+	if want := time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("DTSTART = %v, want %v", start, want)
+	}
+
+	d, err := ev.Props.Get(PropDuration).Duration()
+	if err != nil {
+		t.Fatalf("Duration() = %v", err)
+	}
+	if d != 90*time.Minute {
+		t.Errorf("DURATION = %v", d)
+	}
+}
+
+func TestEncoder_roundTrip(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(exampleCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(cal); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	if buf.String() != exampleCalendarStr {
+		t.Errorf("output doesn't match input:\n%v\nvs.\n%v", buf.String(), exampleCalendarStr)
+	}
+}
+
+func TestDecoder_errors(t *testing.T) {
+	tcs := []struct {
+		name string
+		data string
+	}{
+		{"unterminated", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"},
+		{"mismatchedEnd", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n"},
+		{"notCalendar", "BEGIN:VEVENT\r\nEND:VEVENT\r\n"},
+		{"noColon", "BEGIN:VCALENDAR\r\nVERSION\r\nEND:VCALENDAR\r\n"},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewDecoder(strings.NewReader(tc.data)).Decode(); err == nil {
+				t.Errorf("Decode() succeeded, expected an error")
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tcs := []struct {
+		s string
+		d time.Duration
+	}{
+		{"PT15M", 15 * time.Minute},
+		{"-PT15M", -15 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"P2W", 14 * 24 * time.Hour},
+		{"P1DT2H3M4S", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"PT0S", 0},
+	}
+	for _, tc := range tcs {
+		d, err := ParseDuration(tc.s)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) = %v", tc.s, err)
+			continue
+		}
+		if d != tc.d {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tc.s, d, tc.d)
+		}
+		if s := FormatDuration(tc.d); s != tc.s {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tc.d, s, tc.s)
+		}
+	}
+}
+
+func TestSetDateTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	tcs := []struct {
+		t     time.Time
+		tzid  string
+		value string
+	}{
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), "", "20240101T090000Z"},
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, paris), "Europe/Paris", "20240101T090000"},
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("", 2*3600)), "", "20240101T070000Z"},
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("Custom", -3600)), "", "20240101T100000Z"},
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("UTC", 3600)), "", "20240101T080000Z"},
+	}
+	for _, tc := range tcs {
+		prop := NewProp(PropDateTimeStart)
+		prop.Params.Set(ParamTimezoneID, "Stale")
+		prop.SetDateTime(tc.t)
+		if tzid := prop.Params.Get(ParamTimezoneID); tzid != tc.tzid || prop.Value != tc.value {
+			t.Errorf("SetDateTime(%v) = %q %q, want %q %q", tc.t, tzid, prop.Value, tc.tzid, tc.value)
+		}
+		got, err := prop.DateTime(nil)
+		if err != nil || !got.Equal(tc.t) {
+			t.Errorf("DateTime() = %v, %v, want %v", got, err, tc.t)
+		}
+	}
+
+	// The cached result is reused
+	if !isLoadableLocation("Europe/Paris") || isLoadableLocation("Custom") {
+		t.Errorf("unexpected cached time zone lookups")
+	}
+
+	prop := NewProp(PropDateTimeStart)
+	local := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	prop.SetDateTime(local)
+	if prop.Params.Get(ParamTimezoneID) != "" || prop.Value != local.UTC().Format(utcDateTimeFormat) {
+		t.Errorf("SetDateTime(%v) = %v %q, want UTC form", local, prop.Params, prop.Value)
+	}
+}
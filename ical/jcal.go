@@ -0,0 +1,649 @@
+package ical
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// jCal is the JSON representation of iCalendar, defined in RFC 7265.
+
+// jcalUnknown is the jCal value type of properties with an unknown type,
+// defined in RFC 7265 section 5.
+const jcalUnknown = "unknown"
+
+// jcalMultiValueProps lists properties whose value is a comma-separated list
+// of values which maps to multiple jCal values.
+var jcalMultiValueProps = map[string]bool{
+	PropCategories:      true,
+	PropResources:       true,
+	PropExceptionDates:  true,
+	PropRecurrenceDates: true,
+	PropFreeBusy:        true,
+}
+
+// jcalRecurIntParts lists the RECUR rule parts with integer values.
+var jcalRecurIntParts = map[string]bool{
+	"COUNT":      true,
+	"INTERVAL":   true,
+	"BYSECOND":   true,
+	"BYMINUTE":   true,
+	"BYHOUR":     true,
+	"BYMONTHDAY": true,
+	"BYYEARDAY":  true,
+	"BYWEEKNO":   true,
+	"BYMONTH":    true,
+	"BYSETPOS":   true,
+}
+
+// MarshalJCal returns the jCal encoding of a VCALENDAR component.
+func MarshalJCal(cal *Calendar) ([]byte, error) {
+	if cal == nil || cal.Component == nil {
+		return nil, fmt.Errorf("ical: cannot encode a nil calendar")
+	}
+
+	v, err := jcalFromComponent(cal.Component)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalJCal parses the jCal encoding of a VCALENDAR component.
+func UnmarshalJCal(data []byte) (*Calendar, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := decodeOrderedJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("ical: invalid jCal: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("ical: invalid jCal: trailing data")
+	}
+
+	comp, err := componentFromJCal(v)
+	if err != nil {
+		return nil, err
+	}
+	if comp.Name != CompCalendar {
+		return nil, fmt.Errorf("ical: expected %v component, got %v", CompCalendar, comp.Name)
+	}
+	return &Calendar{comp}, nil
+}
+
+func jcalFromComponent(comp *Component) ([]interface{}, error) {
+	props := make([]interface{}, 0, len(comp.Props))
+	for _, prop := range comp.Props {
+		v, err := jcalFromProp(prop)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, v)
+	}
+
+	children := make([]interface{}, 0, len(comp.Children))
+	for _, child := range comp.Children {
+		v, err := jcalFromComponent(child)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, v)
+	}
+
+	return []interface{}{strings.ToLower(comp.Name), props, children}, nil
+}
+
+func jcalFromProp(prop *Prop) ([]interface{}, error) {
+	valueType := prop.ValueType()
+	typeName := strings.ToLower(string(valueType))
+
+	values, err := jcalValuesFromProp(prop, valueType)
+	params := prop.Params
+	if valueType == ValueDefault || err != nil {
+		// Unknown types and values we fail to convert are preserved verbatim,
+		// including their VALUE parameter
+		typeName = jcalUnknown
+		values = []interface{}{prop.Value}
+	} else {
+		params = make(Params, len(prop.Params))
+		for k, v := range prop.Params {
+			if k != ParamValue {
+				params[k] = v
+			}
+		}
+	}
+
+	v := []interface{}{strings.ToLower(prop.Name), jcalFromParams(params), typeName}
+	return append(v, values...), nil
+}
+
+func jcalFromParams(params Params) jsonObject {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	obj := make(jsonObject, 0, len(names))
+	for _, name := range names {
+		values := params[name]
+		var v interface{}
+		if len(values) == 1 {
+			v = values[0]
+		} else {
+			l := make([]interface{}, len(values))
+			for i, s := range values {
+				l[i] = s
+			}
+			v = l
+		}
+		obj = append(obj, jsonMember{strings.ToLower(name), v})
+	}
+	return obj
+}
+
+func jcalValuesFromProp(prop *Prop, valueType ValueType) ([]interface{}, error) {
+	switch prop.Name {
+	case PropGeo:
+		parts := strings.Split(prop.Value, ";")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ical: malformed GEO value %q", prop.Value)
+		}
+		l := make([]interface{}, len(parts))
+		for i, part := range parts {
+			v, err := jcalFromValue(part, ValueFloat)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		return []interface{}{l}, nil
+	case PropRequestStatus:
+		parts := splitUnescaped(prop.Value, ';')
+		l := make([]interface{}, len(parts))
+		for i, part := range parts {
+			l[i] = unescapeText(part)
+		}
+		return []interface{}{l}, nil
+	}
+
+	var raw []string
+	if jcalMultiValueProps[prop.Name] {
+		raw = splitUnescaped(prop.Value, ',')
+	} else {
+		raw = []string{prop.Value}
+	}
+
+	values := make([]interface{}, len(raw))
+	for i, s := range raw {
+		v, err := jcalFromValue(s, valueType)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func jcalFromValue(s string, valueType ValueType) (interface{}, error) {
+	switch valueType {
+	case ValueText:
+		return unescapeText(s), nil
+	case ValueDate:
+		return extendDate(s)
+	case ValueDateTime:
+		return extendDateTime(s)
+	case ValueTime:
+		return extendTime(s)
+	case ValueUTCOffset:
+		return extendUTCOffset(s)
+	case ValuePeriod:
+		parts := strings.SplitN(s, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ical: malformed PERIOD value %q", s)
+		}
+		start, err := extendDateTime(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end := parts[1]
+		if !strings.HasPrefix(end, "P") && !strings.HasPrefix(end, "+P") && !strings.HasPrefix(end, "-P") {
+			if end, err = extendDateTime(end); err != nil {
+				return nil, err
+			}
+		}
+		return start + "/" + end, nil
+	case ValueRecurrence:
+		return jcalFromRecur(s)
+	case ValueInt, ValueFloat:
+		n := json.Number(s)
+		if _, err := n.Float64(); err != nil || !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("ical: malformed %v value %q", valueType, s)
+		}
+		return n, nil
+	case ValueBool:
+		switch strings.ToUpper(s) {
+		case "TRUE":
+			return true, nil
+		case "FALSE":
+			return false, nil
+		}
+		return nil, fmt.Errorf("ical: malformed BOOLEAN value %q", s)
+	default:
+		return s, nil
+	}
+}
+
+func jcalFromRecur(s string) (jsonObject, error) {
+	var obj jsonObject
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("ical: malformed RECUR value %q", s)
+		}
+		key := strings.ToUpper(kv[0])
+
+		var values []interface{}
+		for _, item := range strings.Split(kv[1], ",") {
+			var (
+				v   interface{}
+				err error
+			)
+			switch {
+			case key == "UNTIL":
+				v, err = extendDateTime(item)
+			case jcalRecurIntParts[key]:
+				v, err = jcalFromValue(item, ValueInt)
+			default:
+				v = item
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+
+		var v interface{} = values
+		if len(values) == 1 {
+			v = values[0]
+		}
+		obj = append(obj, jsonMember{strings.ToLower(key), v})
+	}
+	return obj, nil
+}
+
+func componentFromJCal(v interface{}) (*Component, error) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) != 3 {
+		return nil, fmt.Errorf("ical: invalid jCal component: expected an array of 3 elements")
+	}
+	name, ok := arr[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal component: expected a string name")
+	}
+	props, ok := arr[1].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal component %v: expected an array of properties", name)
+	}
+	children, ok := arr[2].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal component %v: expected an array of components", name)
+	}
+
+	comp := NewComponent(name)
+	for _, p := range props {
+		prop, err := propFromJCal(p)
+		if err != nil {
+			return nil, err
+		}
+		comp.Props = append(comp.Props, prop)
+	}
+	for _, c := range children {
+		child, err := componentFromJCal(c)
+		if err != nil {
+			return nil, err
+		}
+		comp.Children = append(comp.Children, child)
+	}
+	return comp, nil
+}
+
+func propFromJCal(v interface{}) (*Prop, error) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) < 4 {
+		return nil, fmt.Errorf("ical: invalid jCal property: expected an array of at least 4 elements")
+	}
+	name, ok := arr[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal property: expected a string name")
+	}
+	params, ok := arr[1].(jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal property %v: expected a parameter object", name)
+	}
+	typeName, ok := arr[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("ical: invalid jCal property %v: expected a string type", name)
+	}
+
+	prop := NewProp(name)
+	for _, m := range params {
+		switch pv := m.Value.(type) {
+		case string:
+			prop.Params.Add(m.Key, pv)
+		case []interface{}:
+			for _, item := range pv {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("ical: invalid jCal parameter %v in %v", m.Key, name)
+				}
+				prop.Params.Add(m.Key, s)
+			}
+		default:
+			return nil, fmt.Errorf("ical: invalid jCal parameter %v in %v", m.Key, name)
+		}
+	}
+
+	values := arr[3:]
+	if typeName == jcalUnknown {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("ical: invalid jCal property %v: expected a single unknown value", name)
+		}
+		s, ok := values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("ical: invalid jCal property %v: expected a string value", name)
+		}
+		prop.Value = s
+		return prop, nil
+	}
+
+	valueType := ValueType(strings.ToUpper(typeName))
+	if valueType != DefaultValueType(prop.Name) {
+		prop.Params.Set(ParamValue, string(valueType))
+	}
+
+	switch prop.Name {
+	case PropGeo, PropRequestStatus:
+		if len(values) != 1 {
+			return nil, fmt.Errorf("ical: invalid jCal property %v: expected a single structured value", name)
+		}
+		parts, ok := values[0].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("ical: invalid jCal property %v: expected a structured value", name)
+		}
+		l := make([]string, len(parts))
+		for i, part := range parts {
+			partType := ValueText
+			if prop.Name == PropGeo {
+				partType = ValueFloat
+			}
+			s, err := valueFromJCal(part, partType)
+			if err != nil {
+				return nil, fmt.Errorf("ical: invalid jCal property %v: %v", name, err)
+			}
+			l[i] = s
+		}
+		prop.Value = strings.Join(l, ";")
+		return prop, nil
+	}
+
+	l := make([]string, len(values))
+	for i, value := range values {
+		s, err := valueFromJCal(value, valueType)
+		if err != nil {
+			return nil, fmt.Errorf("ical: invalid jCal property %v: %v", name, err)
+		}
+		l[i] = s
+	}
+	prop.Value = strings.Join(l, ",")
+	return prop, nil
+}
+
+func valueFromJCal(v interface{}, valueType ValueType) (string, error) {
+	switch valueType {
+	case ValueRecurrence:
+		obj, ok := v.(jsonObject)
+		if !ok {
+			return "", fmt.Errorf("expected a RECUR object")
+		}
+		return recurFromJCal(obj)
+	case ValueInt, ValueFloat:
+		n, ok := v.(json.Number)
+		if !ok {
+			return "", fmt.Errorf("expected a number")
+		}
+		return string(n), nil
+	case ValueBool:
+		b, ok := v.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected a boolean")
+		}
+		if b {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string value")
+	}
+	switch valueType {
+	case ValueText:
+		return escapeText(s), nil
+	case ValueDate, ValueDateTime, ValueTime, ValueUTCOffset:
+		return basicFormat(s), nil
+	case ValuePeriod:
+		parts := strings.SplitN(s, "/", 2)
+		for i := range parts {
+			parts[i] = basicFormat(parts[i])
+		}
+		return strings.Join(parts, "/"), nil
+	default:
+		return s, nil
+	}
+}
+
+func recurFromJCal(obj jsonObject) (string, error) {
+	parts := make([]string, 0, len(obj))
+	for _, m := range obj {
+		key := strings.ToUpper(m.Key)
+
+		var items []interface{}
+		if l, ok := m.Value.([]interface{}); ok {
+			items = l
+		} else {
+			items = []interface{}{m.Value}
+		}
+
+		values := make([]string, len(items))
+		for i, item := range items {
+			switch item := item.(type) {
+			case string:
+				if key == "UNTIL" {
+					values[i] = basicFormat(item)
+				} else {
+					values[i] = item
+				}
+			case json.Number:
+				values[i] = string(item)
+			default:
+				return "", fmt.Errorf("invalid RECUR rule part %v", m.Key)
+			}
+		}
+		parts = append(parts, key+"="+strings.Join(values, ","))
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// extendDate converts a DATE value from the basic format (20060102) to the
+// extended format (2006-01-02).
+func extendDate(s string) (string, error) {
+	if len(s) != 8 || !isDigits(s) {
+		return "", fmt.Errorf("ical: malformed DATE value %q", s)
+	}
+	return s[0:4] + "-" + s[4:6] + "-" + s[6:8], nil
+}
+
+// extendTime converts a TIME value from the basic format (150405Z) to the
+// extended format (15:04:05Z).
+func extendTime(s string) (string, error) {
+	utc := strings.HasSuffix(s, "Z")
+	digits := strings.TrimSuffix(s, "Z")
+	if len(digits) != 6 || !isDigits(digits) {
+		return "", fmt.Errorf("ical: malformed TIME value %q", s)
+	}
+	out := digits[0:2] + ":" + digits[2:4] + ":" + digits[4:6]
+	if utc {
+		out += "Z"
+	}
+	return out, nil
+}
+
+// extendDateTime converts a DATE-TIME or DATE value from the basic format to
+// the extended format.
+func extendDateTime(s string) (string, error) {
+	i := strings.IndexByte(s, 'T')
+	if i < 0 {
+		return extendDate(s)
+	}
+	date, err := extendDate(s[:i])
+	if err != nil {
+		return "", err
+	}
+	t, err := extendTime(s[i+1:])
+	if err != nil {
+		return "", err
+	}
+	return date + "T" + t, nil
+}
+
+// extendUTCOffset converts a UTC-OFFSET value from the basic format (-0500)
+// to the extended format (-05:00).
+func extendUTCOffset(s string) (string, error) {
+	if len(s) != 5 && len(s) != 7 || (s[0] != '+' && s[0] != '-') || !isDigits(s[1:]) {
+		return "", fmt.Errorf("ical: malformed UTC-OFFSET value %q", s)
+	}
+	out := s[0:3] + ":" + s[3:5]
+	if len(s) == 7 {
+		out += ":" + s[5:7]
+	}
+	return out, nil
+}
+
+// basicFormat converts a date, time or UTC offset from the extended format
+// back to the basic format used by iCalendar.
+func basicFormat(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		sign, s = s[:1], s[1:]
+	}
+	return sign + strings.NewReplacer("-", "", ":", "").Replace(s)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// jsonObject is a JSON object which preserves the order of its members.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+func (obj jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range obj {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+
+		var vbuf bytes.Buffer
+		enc := json.NewEncoder(&vbuf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(m.Value); err != nil {
+			return nil, err
+		}
+		buf.Write(bytes.TrimSuffix(vbuf.Bytes(), []byte("\n")))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrderedJSON decodes the next JSON value. Objects are decoded into
+// jsonObject to preserve the order of their members, numbers are decoded into
+// json.Number.
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			l := []interface{}{}
+			for dec.More() {
+				v, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				l = append(l, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return l, nil
+		case '{':
+			obj := jsonObject{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected an object key")
+				}
+				v, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, jsonMember{key, v})
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", tok)
+	default:
+		return tok, nil
+	}
+}
@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const exampleJCalCalendarStr = "BEGIN:VCALENDAR\r\n" +
+	"CALSCALE:GREGORIAN\r\n" +
+	"PRODID:-//Example Inc.//Example Calendar//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTAMP:20080205T191224Z\r\n" +
+	"DTSTART;VALUE=DATE:20081006\r\n" +
+	"SUMMARY:Planning meeting\\; room 2\r\n" +
+	"UID:4088E990AD89CB3DBB484909\r\n" +
+	"RRULE:FREQ=WEEKLY;UNTIL=20081231T000000Z;BYDAY=MO,WE\r\n" +
+	"EXDATE;TZID=America/New_York:20081008T090000,20081013T090000\r\n" +
+	"CATEGORIES:MEETING,work\\, internal\r\n" +
+	"GEO:37.386013;-122.082932\r\n" +
+	"PRIORITY:1\r\n" +
+	"X-CUSTOM;X-PARAM=a,b:some raw value\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER;RELATED=END:-PT15M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/New_York\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19671029T020000\r\n" +
+	"TZOFFSETFROM:-0400\r\n" +
+	"TZOFFSETTO:-0500\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestJCal_roundTrip(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(exampleJCalCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	b, err := MarshalJCal(cal)
+	if err != nil {
+		t.Fatalf("MarshalJCal() = %v", err)
+	}
+	if !json.Valid(b) {
+		t.Fatalf("MarshalJCal() returned invalid JSON: %s", b)
+	}
+
+	cal, err = UnmarshalJCal(b)
+	if err != nil {
+		t.Fatalf("UnmarshalJCal() = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(cal); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	if buf.String() != exampleJCalCalendarStr {
+		t.Errorf("output doesn't match input:\n%v\nvs.\n%v", buf.String(), exampleJCalCalendarStr)
+	}
+}
+
+func TestMarshalJCal_values(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(exampleJCalCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	b, err := MarshalJCal(cal)
+	if err != nil {
+		t.Fatalf("MarshalJCal() = %v", err)
+	}
+
+	for _, want := range []string{
+		`["dtstart",{},"date","2008-10-06"]`,
+		`["summary",{},"text","Planning meeting; room 2"]`,
+		`["rrule",{},"recur",{"freq":"WEEKLY","until":"2008-12-31T00:00:00Z","byday":["MO","WE"]}]`,
+		`["exdate",{"tzid":"America/New_York"},"date-time","2008-10-08T09:00:00","2008-10-13T09:00:00"]`,
+		`["categories",{},"text","MEETING","work, internal"]`,
+		`["geo",{},"float",[37.386013,-122.082932]]`,
+		`["priority",{},"integer",1]`,
+		`["x-custom",{"x-param":["a","b"]},"unknown","some raw value"]`,
+		`["tzoffsetfrom",{},"utc-offset","-04:00"]`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("jCal output doesn't contain %v:\n%s", want, b)
+		}
+	}
+}
+
+func TestUnmarshalJCal_invalid(t *testing.T) {
+	for _, s := range []string{
+		`{}`,
+		`["vevent",[],[]]`,
+		`["vcalendar",[["version",{},"text"]],[]]`,
+		`["vcalendar",[],[]] trailing`,
+	} {
+		if _, err := UnmarshalJCal([]byte(s)); err == nil {
+			t.Errorf("UnmarshalJCal(%q) succeeded, expected an error", s)
+		}
+	}
+}
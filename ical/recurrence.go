@@ -0,0 +1,284 @@
+package ical
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Master returns the master component of a calendar object resource: the
+// first component other than VTIMEZONE without a RECURRENCE-ID property. It
+// returns nil if there's none.
+func (cal *Calendar) Master() *Component {
+	for _, comp := range cal.Children {
+		if comp.Name != CompTimezone && comp.Props.Get(PropRecurrenceID) == nil {
+			return comp
+		}
+	}
+	return nil
+}
+
+// Override returns the component overriding the occurrence identified by
+// recurrenceID, or the master component if recurrenceID is nil. It returns
+// nil if there's no such component.
+//
+// RECURRENCE-ID values are compared as instants, so values in different
+// timezones match.
+func (cal *Calendar) Override(recurrenceID *Prop) *Component {
+	if recurrenceID == nil {
+		return cal.Master()
+	}
+	key := recurrenceIDKey(recurrenceID)
+	for _, comp := range cal.Children {
+		if comp.Name == CompTimezone {
+			continue
+		}
+		if rid := comp.Props.Get(PropRecurrenceID); rid != nil && recurrenceIDKey(rid) == key {
+			return comp
+		}
+	}
+	return nil
+}
+
+// recurrenceIDKey returns a key identifying a RECURRENCE-ID value, normalised
+// to UTC when possible.
+func recurrenceIDKey(prop *Prop) string {
+	if prop.IsDate() {
+		return strings.TrimSpace(prop.Value)
+	}
+	if t, err := prop.DateTime(time.UTC); err == nil {
+		return t.UTC().Format(utcDateTimeFormat)
+	}
+	return strings.TrimSpace(prop.Value)
+}
+
+// NewOverride creates a component overriding the occurrence of master
+// identified by recurrenceID, as defined in RFC 5545 section 3.8.4.4. The
+// returned component is a copy of master without recurrence properties,
+// starting at the RECURRENCE-ID and lasting as long as master. It isn't added
+// to the calendar.
+func NewOverride(master *Component, recurrenceID *Prop) *Component {
+	override := master.Clone()
+	for _, name := range []string{PropRecurrenceRule, PropRecurrenceDates, PropExceptionDates} {
+		override.Props.Del(name)
+	}
+
+	rid := recurrenceID.Clone()
+	rid.Name = PropRecurrenceID
+	rid.Params.Del(ParamRange)
+	override.Props.Set(rid)
+
+	start := override.Props.Get(PropDateTimeStart)
+	if start == nil {
+		return override
+	}
+	end := override.Props.Get(PropDateTimeEnd)
+	if end == nil {
+		end = override.Props.Get(PropDue)
+	}
+	if end != nil {
+		oldStart, startErr := start.DateTime(time.UTC)
+		oldEnd, endErr := end.DateTime(time.UTC)
+		newStart, ridErr := rid.DateTime(time.UTC)
+		if startErr == nil && endErr == nil && ridErr == nil {
+			end.ReplaceDateTime(newStart.Add(oldEnd.Sub(oldStart)))
+		}
+	}
+
+	newStart := rid.Clone()
+	newStart.Name = PropDateTimeStart
+	override.Props.Set(newStart)
+	return override
+}
+
+// ReplaceDateTime sets the value of a DATE or DATE-TIME property to t,
+// keeping its value type and timezone: t is converted to the property's TZID
+// or to UTC, and truncated to a date for DATE values.
+func (prop *Prop) ReplaceDateTime(t time.Time) {
+	prop.Value = formatDateTimeLike(prop, t)
+}
+
+// formatDateTimeLike formats t like the DATE or DATE-TIME value of prop.
+func formatDateTimeLike(prop *Prop, t time.Time) string {
+	switch {
+	case prop.IsDate():
+		return t.Format(dateFormat)
+	case strings.HasSuffix(strings.TrimSpace(prop.Value), "Z"):
+		return t.UTC().Format(utcDateTimeFormat)
+	default:
+		if tzid := prop.Params.Get(ParamTimezoneID); tzid != "" {
+			if loc, err := LoadLocation(tzid); err == nil {
+				t = t.In(loc)
+			}
+		}
+		return t.Format(dateTimeFormat)
+	}
+}
+
+// RecurrenceSet is the set of occurrences of a component, as defined in RFC
+// 5545 section 3.8.5.
+type RecurrenceSet struct {
+	// Start is the DTSTART of the component, always part of the set.
+	Start time.Time
+	// Rules are the RRULE values of the component.
+	Rules []*RecurrenceRule
+	// Dates are the RDATE values of the component. For PERIOD values, only
+	// the start of the period is kept.
+	Dates []time.Time
+	// ExceptionDates are the EXDATE values of the component.
+	ExceptionDates []time.Time
+}
+
+// RecurrenceSet returns the recurrence set of a component with a DTSTART
+// property. loc is used like in Prop.DateTime.
+func (comp *Component) RecurrenceSet(loc *time.Location) (*RecurrenceSet, error) {
+	start := comp.Props.Get(PropDateTimeStart)
+	if start == nil {
+		return nil, fmt.Errorf("ical: %s has no DTSTART", comp.Name)
+	}
+	startTime, err := start.DateTime(loc)
+	if err != nil {
+		return nil, err
+	}
+	set := &RecurrenceSet{Start: startTime}
+	if startTime.Location() != time.UTC {
+		// Floating values and dates of the set are in the timezone of DTSTART
+		loc = startTime.Location()
+	}
+
+	for _, prop := range comp.Props.Values(PropRecurrenceRule) {
+		rule, err := prop.RecurrenceRule()
+		if err != nil {
+			return nil, err
+		}
+		set.Rules = append(set.Rules, rule)
+	}
+	for _, prop := range comp.Props.Values(PropRecurrenceDates) {
+		if prop.ValueType() == ValuePeriod {
+			for _, v := range strings.Split(prop.Value, ",") {
+				v, _, _ = strings.Cut(v, "/")
+				t, err := parseDateTime(v, ValueDateTime, prop.Params.Get(ParamTimezoneID), loc)
+				if err != nil {
+					return nil, err
+				}
+				set.Dates = append(set.Dates, t)
+			}
+			continue
+		}
+		l, err := prop.DateTimes(loc)
+		if err != nil {
+			return nil, err
+		}
+		set.Dates = append(set.Dates, l...)
+	}
+	for _, prop := range comp.Props.Values(PropExceptionDates) {
+		l, err := prop.DateTimes(loc)
+		if err != nil {
+			return nil, err
+		}
+		set.ExceptionDates = append(set.ExceptionDates, l...)
+	}
+	sort.Slice(set.Dates, func(i, j int) bool { return set.Dates[i].Before(set.Dates[j]) })
+	return set, nil
+}
+
+// RecurrenceSet is like Component.RecurrenceSet, but resolves the TZID of
+// the DTSTART property of comp with Calendar.Location, so that timezones
+// only defined by a VTIMEZONE component of the calendar are supported.
+func (cal *Calendar) RecurrenceSet(comp *Component, loc *time.Location) (*RecurrenceSet, error) {
+	if start := comp.Props.Get(PropDateTimeStart); start != nil {
+		if tzid := start.Params.Get(ParamTimezoneID); tzid != "" {
+			tzLoc, err := cal.Location(tzid)
+			if err != nil {
+				return nil, err
+			}
+			loc = tzLoc
+		}
+	}
+	return comp.RecurrenceSet(loc)
+}
+
+// IsRecurring reports whether the set can contain other occurrences than
+// Start.
+func (set *RecurrenceSet) IsRecurring() bool {
+	return len(set.Rules) > 0 || len(set.Dates) > 0
+}
+
+// All returns the occurrences of the set in chronological order, without
+// duplicates and exception dates. The sequence may be infinite.
+func (set *RecurrenceSet) All() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		excluded := make(map[int64]bool, len(set.ExceptionDates))
+		for _, t := range set.ExceptionDates {
+			excluded[t.UnixNano()] = true
+		}
+
+		// Sources are merged by picking the earliest pending time
+		type source struct {
+			next func() (time.Time, bool)
+			head time.Time
+			ok   bool
+		}
+		var sources []*source
+		add := func(next func() (time.Time, bool)) {
+			src := &source{next: next}
+			src.head, src.ok = next()
+			sources = append(sources, src)
+		}
+		add(sliceIterator([]time.Time{set.Start}))
+		add(sliceIterator(set.Dates))
+		for _, rule := range set.Rules {
+			add(newRuleIterator(rule, set.Start).next)
+		}
+
+		var last time.Time
+		first := true
+		for {
+			var min *source
+			for _, src := range sources {
+				if src.ok && (min == nil || src.head.Before(min.head)) {
+					min = src
+				}
+			}
+			if min == nil {
+				return
+			}
+			t := min.head
+			min.head, min.ok = min.next()
+
+			if (!first && t.Equal(last)) || excluded[t.UnixNano()] {
+				continue
+			}
+			first = false
+			last = t
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+func sliceIterator(l []time.Time) func() (time.Time, bool) {
+	return func() (time.Time, bool) {
+		if len(l) == 0 {
+			return time.Time{}, false
+		}
+		t := l[0]
+		l = l[1:]
+		return t, true
+	}
+}
+
+// Contains reports whether t is an occurrence of the set.
+func (set *RecurrenceSet) Contains(t time.Time) bool {
+	for occ := range set.All() {
+		if occ.Equal(t) {
+			return true
+		} else if occ.After(t) {
+			break
+		}
+	}
+	return false
+}
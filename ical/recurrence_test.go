@@ -0,0 +1,72 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+)
+
+const recurringCalendarStr = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240102T090000\r\n" +
+	"DTEND;TZID=Europe/Paris:20240102T103000\r\n" +
+	"RRULE:FREQ=WEEKLY\r\n" +
+	"EXDATE;TZID=Europe/Paris:20240116T090000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Paris:20240109T090000\r\n" +
+	"DTSTART;TZID=Europe/Paris:20240109T100000\r\n" +
+	"DTEND;TZID=Europe/Paris:20240109T113000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestOverride(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(recurringCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	master := cal.Master()
+	if master == nil || master.Props.Get(PropRecurrenceRule) == nil {
+		t.Fatalf("Master() = %v", master)
+	}
+	if cal.Override(nil) != master {
+		t.Errorf("Override(nil) should return the master component")
+	}
+
+	// The same instant in UTC matches the existing override
+	rid := NewProp(PropRecurrenceID)
+	rid.Value = "20240109T080000Z"
+	if override := cal.Override(rid); override == nil || override == master {
+		t.Errorf("Override(%v) = %v", rid.Value, override)
+	}
+
+	rid = NewProp(PropRecurrenceID)
+	rid.Params.Set(ParamTimezoneID, "Europe/Paris")
+	rid.Value = "20240123T090000"
+	if cal.Override(rid) != nil {
+		t.Errorf("Override() returned a component for a non-overridden occurrence")
+	}
+
+	override := NewOverride(master, rid)
+	for _, name := range []string{PropRecurrenceRule, PropExceptionDates} {
+		if override.Props.Get(name) != nil {
+			t.Errorf("override has a %s property", name)
+		}
+	}
+	if got := override.Props.Get(PropRecurrenceID).Value; got != "20240123T090000" {
+		t.Errorf("RECURRENCE-ID = %q", got)
+	}
+	start, end := override.Props.Get(PropDateTimeStart), override.Props.Get(PropDateTimeEnd)
+	if start.Value != "20240123T090000" || start.Params.Get(ParamTimezoneID) != "Europe/Paris" {
+		t.Errorf("DTSTART = %+v", start)
+	}
+	if end.Value != "20240123T103000" || end.Params.Get(ParamTimezoneID) != "Europe/Paris" {
+		t.Errorf("DTEND = %+v", end)
+	}
+	if master.Props.Get(PropRecurrenceRule) == nil {
+		t.Errorf("NewOverride modified the master component")
+	}
+}
@@ -0,0 +1,707 @@
+package ical
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the frequency of a recurrence rule, as defined in RFC 5545
+// section 3.3.10.
+type Frequency string
+
+const (
+	FreqSecondly Frequency = "SECONDLY"
+	FreqMinutely Frequency = "MINUTELY"
+	FreqHourly   Frequency = "HOURLY"
+	FreqDaily    Frequency = "DAILY"
+	FreqWeekly   Frequency = "WEEKLY"
+	FreqMonthly  Frequency = "MONTHLY"
+	FreqYearly   Frequency = "YEARLY"
+)
+
+// rank orders frequencies from the finest to the coarsest.
+func (freq Frequency) rank() int {
+	switch freq {
+	case FreqSecondly:
+		return 0
+	case FreqMinutely:
+		return 1
+	case FreqHourly:
+		return 2
+	case FreqDaily:
+		return 3
+	case FreqWeekly:
+		return 4
+	case FreqMonthly:
+		return 5
+	case FreqYearly:
+		return 6
+	}
+	return -1
+}
+
+// WeekdayNum is a BYDAY value: a weekday, optionally restricted to its Nth
+// occurrence in the month or year.
+type WeekdayNum struct {
+	// N is the ordinal of the weekday, e.g. 1 for the first Monday or -1 for
+	// the last one. Zero means every such weekday.
+	N   int
+	Day time.Weekday
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for i, name := range weekdayNames {
+		if s == name {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("ical: invalid weekday %q", s)
+}
+
+func (wd WeekdayNum) String() string {
+	if wd.N == 0 {
+		return weekdayNames[wd.Day]
+	}
+	return strconv.Itoa(wd.N) + weekdayNames[wd.Day]
+}
+
+// RecurrenceRule is a RECUR value, as defined in RFC 5545 section 3.3.10.
+type RecurrenceRule struct {
+	Freq Frequency
+	// Until is the inclusive end of the recurrence, zero if unset. UTC values
+	// are in UTC. DATE values (UntilDate) and floating DATE-TIME values
+	// (UntilFloating) are stored in UTC but are interpreted in the timezone
+	// of DTSTART.
+	Until         time.Time
+	UntilDate     bool
+	UntilFloating bool
+	// Count is the number of occurrences, zero if unset.
+	Count int
+	// Interval is the number of periods between occurrences, zero meaning 1.
+	Interval   int
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByYearDay  []int
+	ByWeekNo   []int
+	ByMonth    []int
+	BySetPos   []int
+	// WeekStart is the first day of the week. ParseRecurrenceRule sets it to
+	// time.Monday, the default, when WKST is absent.
+	WeekStart time.Weekday
+}
+
+// ParseRecurrenceRule parses a RECUR value.
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	rule := &RecurrenceRule{WeekStart: time.Monday}
+	for _, part := range strings.Split(strings.TrimSpace(s), ";") {
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("ical: malformed recurrence rule part %q", part)
+		}
+		k = strings.ToUpper(k)
+		v = strings.ToUpper(v)
+
+		var err error
+		switch k {
+		case "FREQ":
+			rule.Freq = Frequency(v)
+			if rule.Freq.rank() < 0 {
+				err = fmt.Errorf("ical: invalid recurrence frequency %q", v)
+			}
+		case "UNTIL":
+			err = rule.parseUntil(v)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(v)
+			if err == nil && rule.Count <= 0 {
+				err = fmt.Errorf("ical: invalid recurrence COUNT %q", v)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(v)
+			if err == nil && rule.Interval <= 0 {
+				err = fmt.Errorf("ical: invalid recurrence INTERVAL %q", v)
+			}
+		case "BYSECOND":
+			rule.BySecond, err = parseIntList(v, 0, 60, false)
+		case "BYMINUTE":
+			rule.ByMinute, err = parseIntList(v, 0, 59, false)
+		case "BYHOUR":
+			rule.ByHour, err = parseIntList(v, 0, 23, false)
+		case "BYDAY":
+			rule.ByDay, err = parseWeekdayList(v)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(v, 1, 31, true)
+		case "BYYEARDAY":
+			rule.ByYearDay, err = parseIntList(v, 1, 366, true)
+		case "BYWEEKNO":
+			rule.ByWeekNo, err = parseIntList(v, 1, 53, true)
+		case "BYMONTH":
+			rule.ByMonth, err = parseIntList(v, 1, 12, false)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseIntList(v, 1, 366, true)
+		case "WKST":
+			rule.WeekStart, err = parseWeekday(v)
+		default:
+			// Unknown and extension parts, e.g. RFC 7529 RSCALE, are ignored
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("ical: recurrence rule %q has no FREQ", s)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("ical: recurrence rule %q has both COUNT and UNTIL", s)
+	}
+	return rule, nil
+}
+
+func (rule *RecurrenceRule) parseUntil(v string) error {
+	var err error
+	switch {
+	case len(v) == len(dateFormat):
+		rule.Until, err = time.Parse(dateFormat, v)
+		rule.UntilDate = true
+	case strings.HasSuffix(v, "Z"):
+		rule.Until, err = time.Parse(utcDateTimeFormat, v)
+	default:
+		rule.Until, err = time.Parse(dateTimeFormat, v)
+		rule.UntilFloating = true
+	}
+	if err != nil {
+		return fmt.Errorf("ical: invalid recurrence UNTIL %q: %v", v, err)
+	}
+	return nil
+}
+
+func parseIntList(s string, min, max int, allowNeg bool) ([]int, error) {
+	var l []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimPrefix(v, "+"))
+		if err != nil {
+			return nil, fmt.Errorf("ical: invalid recurrence rule value %q", v)
+		}
+		abs := n
+		if n < 0 && allowNeg {
+			abs = -n
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("ical: recurrence rule value %q out of range", v)
+		}
+		l = append(l, n)
+	}
+	return l, nil
+}
+
+func parseWeekdayList(s string) ([]WeekdayNum, error) {
+	var l []WeekdayNum
+	for _, v := range strings.Split(s, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("ical: invalid BYDAY value %q", v)
+		}
+		day, err := parseWeekday(v[len(v)-2:])
+		if err != nil {
+			return nil, err
+		}
+		wd := WeekdayNum{Day: day}
+		if ord := v[:len(v)-2]; ord != "" {
+			wd.N, err = strconv.Atoi(strings.TrimPrefix(ord, "+"))
+			if err != nil || wd.N == 0 || wd.N < -53 || wd.N > 53 {
+				return nil, fmt.Errorf("ical: invalid BYDAY value %q", v)
+			}
+		}
+		l = append(l, wd)
+	}
+	return l, nil
+}
+
+// String formats the rule as a RECUR value.
+func (rule *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(rule.Freq)}
+	if !rule.Until.IsZero() {
+		var until string
+		switch {
+		case rule.UntilDate:
+			until = rule.Until.Format(dateFormat)
+		case rule.UntilFloating:
+			until = rule.Until.Format(dateTimeFormat)
+		default:
+			until = rule.Until.UTC().Format(utcDateTimeFormat)
+		}
+		parts = append(parts, "UNTIL="+until)
+	}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+	for _, part := range []struct {
+		name   string
+		values []int
+	}{
+		{"BYSECOND", rule.BySecond},
+		{"BYMINUTE", rule.ByMinute},
+		{"BYHOUR", rule.ByHour},
+	} {
+		if len(part.values) > 0 {
+			parts = append(parts, part.name+"="+formatIntList(part.values))
+		}
+	}
+	if len(rule.ByDay) > 0 {
+		l := make([]string, len(rule.ByDay))
+		for i, wd := range rule.ByDay {
+			l[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(l, ","))
+	}
+	for _, part := range []struct {
+		name   string
+		values []int
+	}{
+		{"BYMONTHDAY", rule.ByMonthDay},
+		{"BYYEARDAY", rule.ByYearDay},
+		{"BYWEEKNO", rule.ByWeekNo},
+		{"BYMONTH", rule.ByMonth},
+		{"BYSETPOS", rule.BySetPos},
+	} {
+		if len(part.values) > 0 {
+			parts = append(parts, part.name+"="+formatIntList(part.values))
+		}
+	}
+	if rule.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[rule.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func formatIntList(l []int) string {
+	s := make([]string, len(l))
+	for i, n := range l {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// RecurrenceRule returns the RECUR value.
+func (prop *Prop) RecurrenceRule() (*RecurrenceRule, error) {
+	if t := prop.ValueType(); t != ValueRecurrence {
+		return nil, fmt.Errorf("ical: expected RECUR value for %v, got %v", prop.Name, t)
+	}
+	return ParseRecurrenceRule(prop.Value)
+}
+
+// SetRecurrenceRule sets a RECUR value.
+func (prop *Prop) SetRecurrenceRule(rule *RecurrenceRule) {
+	prop.SetValueType(ValueRecurrence)
+	prop.Value = rule.String()
+}
+
+// Occurrences returns the start times of the occurrences defined by the rule
+// for a component starting at dtstart, in chronological order. Times are in
+// the location of dtstart. The sequence may be infinite.
+//
+// As required by RFC 5545, dtstart is only returned if it matches the rule.
+// Use Component.RecurrenceSet to also take RDATE and EXDATE into account.
+func (rule *RecurrenceRule) Occurrences(dtstart time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		it := newRuleIterator(rule, dtstart)
+		for {
+			t, ok := it.next()
+			if !ok || !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// maxEmptyPeriods is the number of consecutive periods without occurrence
+// after which a rule is considered exhausted, e.g. for rules which never
+// match such as FREQ=MONTHLY;BYMONTHDAY=30;BYMONTH=2.
+const maxEmptyPeriods = 10000
+
+// ruleIterator expands a recurrence rule. Periods are computed on wall clock
+// times, represented in UTC, and converted to the location of DTSTART.
+type ruleIterator struct {
+	freq     Frequency
+	interval int
+	start    time.Time
+	loc      *time.Location
+	until    time.Time
+	count    int
+	wkst     time.Weekday
+
+	bySecond, byMinute, byHour      []int
+	byDay                           []WeekdayNum
+	byMonthDay, byYearDay, byWeekNo []int
+	byMonth, bySetPos               []int
+
+	cur     time.Time // start of the current period
+	emitted int
+	buf     []time.Time
+	empty   int
+	done    bool
+}
+
+func newRuleIterator(rule *RecurrenceRule, dtstart time.Time) *ruleIterator {
+	loc := dtstart.Location()
+	it := &ruleIterator{
+		freq:       rule.Freq,
+		interval:   rule.Interval,
+		start:      dtstart,
+		loc:        loc,
+		count:      rule.Count,
+		wkst:       rule.WeekStart,
+		bySecond:   rule.BySecond,
+		byMinute:   rule.ByMinute,
+		byHour:     rule.ByHour,
+		byDay:      rule.ByDay,
+		byMonthDay: rule.ByMonthDay,
+		byYearDay:  rule.ByYearDay,
+		byWeekNo:   rule.ByWeekNo,
+		byMonth:    rule.ByMonth,
+		bySetPos:   rule.BySetPos,
+	}
+	if it.interval <= 0 {
+		it.interval = 1
+	}
+
+	switch {
+	case rule.Until.IsZero():
+	case rule.UntilDate:
+		y, m, d := rule.Until.Date()
+		it.until = time.Date(y, m, d, 23, 59, 59, 0, loc)
+	case rule.UntilFloating:
+		y, m, d := rule.Until.Date()
+		it.until = time.Date(y, m, d, rule.Until.Hour(), rule.Until.Minute(), rule.Until.Second(), 0, loc)
+	default:
+		it.until = rule.Until
+	}
+
+	// Without any day restriction, occurrences fall on the same day as
+	// DTSTART (RFC 5545 section 3.3.10)
+	if len(it.byWeekNo) == 0 && len(it.byYearDay) == 0 && len(it.byMonthDay) == 0 && len(it.byDay) == 0 {
+		switch it.freq {
+		case FreqYearly:
+			if len(it.byMonth) == 0 {
+				it.byMonth = []int{int(dtstart.Month())}
+			}
+			it.byMonthDay = []int{dtstart.Day()}
+		case FreqMonthly:
+			it.byMonthDay = []int{dtstart.Day()}
+		case FreqWeekly:
+			it.byDay = []WeekdayNum{{Day: dtstart.Weekday()}}
+		}
+	}
+
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	switch it.freq {
+	case FreqYearly:
+		it.cur = time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	case FreqMonthly:
+		it.cur = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case FreqWeekly:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) - int(it.wkst) + 7) % 7
+		it.cur = day.AddDate(0, 0, -offset)
+	case FreqDaily:
+		it.cur = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case FreqHourly:
+		it.cur = time.Date(y, m, d, hh, 0, 0, 0, time.UTC)
+	case FreqMinutely:
+		it.cur = time.Date(y, m, d, hh, mm, 0, 0, time.UTC)
+	case FreqSecondly:
+		it.cur = time.Date(y, m, d, hh, mm, ss, 0, time.UTC)
+	default:
+		it.done = true
+	}
+	return it
+}
+
+func (it *ruleIterator) next() (time.Time, bool) {
+	for len(it.buf) == 0 {
+		if it.done || it.empty > maxEmptyPeriods || it.cur.Year() > 9999 {
+			it.done = true
+			return time.Time{}, false
+		}
+		it.expand()
+		if len(it.buf) == 0 {
+			it.empty++
+		} else {
+			it.empty = 0
+		}
+	}
+
+	t := it.buf[0]
+	it.buf = it.buf[1:]
+	if (!it.until.IsZero() && t.After(it.until)) || (it.count > 0 && it.emitted >= it.count) {
+		it.done = true
+		it.buf = nil
+		return time.Time{}, false
+	}
+	it.emitted++
+	return t, true
+}
+
+// expand fills buf with the occurrences of the current period, and moves to
+// the next period.
+func (it *ruleIterator) expand() {
+	var days []time.Time
+	switch it.freq {
+	case FreqYearly:
+		for day := it.cur; day.Year() == it.cur.Year(); day = day.AddDate(0, 0, 1) {
+			days = append(days, day)
+		}
+	case FreqMonthly:
+		for day := it.cur; day.Month() == it.cur.Month(); day = day.AddDate(0, 0, 1) {
+			days = append(days, day)
+		}
+	case FreqWeekly:
+		for i := 0; i < 7; i++ {
+			days = append(days, it.cur.AddDate(0, 0, i))
+		}
+	default:
+		y, m, d := it.cur.Date()
+		days = []time.Time{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+	}
+
+	var matching []time.Time
+	for _, day := range days {
+		if it.matchDay(day) {
+			matching = append(matching, day)
+		}
+	}
+
+	hours, hourOK := it.timeValues(FreqHourly, it.byHour, it.start.Hour(), it.cur.Hour())
+	minutes, minuteOK := it.timeValues(FreqMinutely, it.byMinute, it.start.Minute(), it.cur.Minute())
+	seconds, secondOK := it.timeValues(FreqSecondly, it.bySecond, it.start.Second(), it.cur.Second())
+
+	var set []time.Time
+	for _, day := range matching {
+		y, m, d := day.Date()
+		for _, hh := range hours {
+			for _, mm := range minutes {
+				for _, ss := range seconds {
+					set = append(set, time.Date(y, m, d, hh, mm, ss, 0, it.loc))
+				}
+			}
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].Before(set[j]) })
+	if len(it.bySetPos) > 0 {
+		set = selectPositions(set, it.bySetPos)
+	}
+	for _, t := range set {
+		if !t.Before(it.start) {
+			it.buf = append(it.buf, t)
+		}
+	}
+
+	it.advance(len(matching) > 0, hourOK, minuteOK, secondOK)
+}
+
+// timeValues returns the values of a time field in the current period. For
+// rules coarser than freq, the BYxxx values expand the period, defaulting to
+// the value of DTSTART. Otherwise, the BYxxx values limit the value of the
+// current period, and ok is false if it doesn't match.
+func (it *ruleIterator) timeValues(freq Frequency, by []int, startValue, curValue int) (values []int, ok bool) {
+	if it.freq.rank() > freq.rank() {
+		if len(by) > 0 {
+			values = append([]int(nil), by...)
+			sort.Ints(values)
+			return values, true
+		}
+		return []int{startValue}, true
+	}
+	if len(by) > 0 && !containsInt(by, curValue) {
+		return nil, false
+	}
+	return []int{curValue}, true
+}
+
+// advance moves to the next period. For rules finer than a day, periods in a
+// day, hour or minute which can't match are skipped.
+func (it *ruleIterator) advance(dayOK, hourOK, minuteOK, secondOK bool) {
+	var step time.Duration
+	switch it.freq {
+	case FreqYearly:
+		it.cur = it.cur.AddDate(it.interval, 0, 0)
+		return
+	case FreqMonthly:
+		it.cur = it.cur.AddDate(0, it.interval, 0)
+		return
+	case FreqWeekly:
+		it.cur = it.cur.AddDate(0, 0, 7*it.interval)
+		return
+	case FreqDaily:
+		it.cur = it.cur.AddDate(0, 0, it.interval)
+		return
+	case FreqHourly:
+		step = time.Hour
+	case FreqMinutely:
+		step = time.Minute
+	case FreqSecondly:
+		step = time.Second
+	}
+	step *= time.Duration(it.interval)
+
+	var boundary time.Time
+	switch {
+	case !dayOK:
+		boundary = it.cur.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	case !hourOK:
+		boundary = it.cur.Truncate(time.Hour).Add(time.Hour)
+	case !minuteOK:
+		boundary = it.cur.Truncate(time.Minute).Add(time.Minute)
+	}
+	n := time.Duration(1)
+	if !boundary.IsZero() {
+		n = (boundary.Sub(it.cur) + step - 1) / step
+	}
+	it.cur = it.cur.Add(n * step)
+}
+
+func (it *ruleIterator) matchDay(day time.Time) bool {
+	y, m, d := day.Date()
+	if len(it.byMonth) > 0 && !containsInt(it.byMonth, int(m)) {
+		return false
+	}
+	if len(it.byWeekNo) > 0 {
+		week, weeks := weekNumber(day, it.wkst)
+		if !matchOrdinal(it.byWeekNo, week, weeks) {
+			return false
+		}
+	}
+	yearDays := time.Date(y, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	if len(it.byYearDay) > 0 && !matchOrdinal(it.byYearDay, day.YearDay(), yearDays) {
+		return false
+	}
+	monthDays := daysIn(m, y)
+	if len(it.byMonthDay) > 0 && !matchOrdinal(it.byMonthDay, d, monthDays) {
+		return false
+	}
+	if len(it.byDay) > 0 {
+		matched := false
+		for _, wd := range it.byDay {
+			if wd.Day != day.Weekday() {
+				continue
+			}
+			switch {
+			case wd.N == 0 || len(it.byWeekNo) > 0:
+				matched = true
+			case it.freq == FreqMonthly || (it.freq == FreqYearly && len(it.byMonth) > 0):
+				matched = matchNth(wd.N, d, monthDays)
+			case it.freq == FreqYearly:
+				matched = matchNth(wd.N, day.YearDay(), yearDays)
+			default:
+				matched = true
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOrdinal reports whether the position pos in a set of n elements
+// matches one of the values, negative values counting from the end.
+func matchOrdinal(values []int, pos, n int) bool {
+	for _, v := range values {
+		if v == pos || v == pos-n-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNth reports whether the weekday on day pos of a month or year of n days
+// is its nth occurrence, negative values counting from the end.
+func matchNth(nth, pos, n int) bool {
+	if nth > 0 {
+		return (pos-1)/7+1 == nth
+	}
+	return -((n-pos)/7 + 1) == nth
+}
+
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// weekNumber returns the week number of day, and the number of weeks in its
+// week-numbering year. Week 1 is the first week with at least four days in
+// the year, weeks starting on wkst.
+func weekNumber(day time.Time, wkst time.Weekday) (week, weeks int) {
+	y := day.Year()
+	start := firstWeekStart(y, wkst)
+	if day.Before(start) {
+		y--
+		start = firstWeekStart(y, wkst)
+	} else if next := firstWeekStart(y+1, wkst); !day.Before(next) {
+		y++
+		start = next
+	}
+	days := func(a, b time.Time) int { return int(b.Sub(a).Hours()+12) / 24 }
+	week = days(start, day)/7 + 1
+	weeks = days(start, firstWeekStart(y+1, wkst)) / 7
+	return week, weeks
+}
+
+func firstWeekStart(year int, wkst time.Weekday) time.Time {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan1.Weekday()) - int(wkst) + 7) % 7
+	if offset <= 3 {
+		return jan1.AddDate(0, 0, -offset)
+	}
+	return jan1.AddDate(0, 0, 7-offset)
+}
+
+// selectPositions returns the elements of the sorted set at the BYSETPOS
+// positions, negative positions counting from the end.
+func selectPositions(set []time.Time, positions []int) []time.Time {
+	var l []time.Time
+	for _, pos := range positions {
+		i := pos - 1
+		if pos < 0 {
+			i = len(set) + pos
+		}
+		if i < 0 || i >= len(set) {
+			continue
+		}
+		l = append(l, set[i])
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Before(l[j]) })
+	out := l[:0]
+	for i, t := range l {
+		if i == 0 || !t.Equal(l[i-1]) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func containsInt(l []int, v int) bool {
+	for _, n := range l {
+		if n == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tcs := []string{
+		"FREQ=DAILY;COUNT=10",
+		"FREQ=WEEKLY;UNTIL=19971224T000000Z;INTERVAL=2;BYDAY=MO,WE,FR;WKST=SU",
+		"FREQ=MONTHLY;BYDAY=-2MO,1FR;BYSETPOS=-1",
+		"FREQ=YEARLY;UNTIL=20000131;BYDAY=SU;BYMONTH=1",
+		"FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10,11",
+	}
+	for _, s := range tcs {
+		rule, err := ParseRecurrenceRule(s)
+		if err != nil {
+			t.Errorf("ParseRecurrenceRule(%q) = %v", s, err)
+			continue
+		}
+		if got := rule.String(); got != s {
+			t.Errorf("ParseRecurrenceRule(%q).String() = %q", s, got)
+		}
+	}
+
+	for _, s := range []string{"COUNT=2", "FREQ=FORTNIGHTLY", "FREQ=DAILY;COUNT=2;UNTIL=20240101", "FREQ=DAILY;BYDAY=XX", "FREQ=YEARLY;BYMONTH=13"} {
+		if _, err := ParseRecurrenceRule(s); err == nil {
+			t.Errorf("ParseRecurrenceRule(%q) succeeded, expected an error", s)
+		}
+	}
+}
+
+// TestRecurrenceRuleOccurrences checks examples from RFC 5545 section
+// 3.8.5.3.
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	tcs := []struct {
+		name    string
+		rule    string
+		dtstart string
+		// want lists the first occurrences, as dates and times in loc
+		want []string
+		// all reports whether want is the whole set
+		all bool
+	}{
+		{
+			name:    "daily count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: "19970902T090000",
+			want:    []string{"19970902T090000", "19970903T090000", "19970904T090000"},
+			all:     true,
+		},
+		{
+			name:    "every other day",
+			rule:    "FREQ=DAILY;INTERVAL=2",
+			dtstart: "19970902T090000",
+			want:    []string{"19970902T090000", "19970904T090000", "19970906T090000"},
+		},
+		{
+			name:    "daily in january",
+			rule:    "FREQ=DAILY;UNTIL=20000131T140000Z;BYMONTH=1",
+			dtstart: "19980101T090000",
+			want:    []string{"19980101T090000", "19980102T090000"},
+		},
+		{
+			name:    "weekly until across DST",
+			rule:    "FREQ=WEEKLY;UNTIL=19971028T000000Z",
+			dtstart: "19971014T090000",
+			want:    []string{"19971014T090000", "19971021T090000"},
+			all:     true,
+		},
+		{
+			name:    "every other week on tuesday and thursday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,TH;WKST=SU",
+			dtstart: "19970902T090000",
+			want:    []string{"19970902T090000", "19970904T090000", "19970916T090000", "19970918T090000"},
+			all:     true,
+		},
+		{
+			name:    "first friday",
+			rule:    "FREQ=MONTHLY;COUNT=3;BYDAY=1FR",
+			dtstart: "19970905T090000",
+			want:    []string{"19970905T090000", "19971003T090000", "19971107T090000"},
+			all:     true,
+		},
+		{
+			name:    "second-to-last monday",
+			rule:    "FREQ=MONTHLY;COUNT=3;BYDAY=-2MO",
+			dtstart: "19970922T090000",
+			want:    []string{"19970922T090000", "19971020T090000", "19971117T090000"},
+			all:     true,
+		},
+		{
+			name:    "third-to-last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-3",
+			dtstart: "19970928T090000",
+			want:    []string{"19970928T090000", "19971029T090000", "19971128T090000", "19971229T090000", "19980129T090000", "19980226T090000"},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: "19970131T090000",
+			want:    []string{"19970131T090000", "19970331T090000", "19970531T090000"},
+			all:     true,
+		},
+		{
+			name:    "friday the 13th",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			dtstart: "19970902T090000",
+			want:    []string{"19980213T090000", "19980313T090000", "19981113T090000", "19990813T090000"},
+		},
+		{
+			name:    "yearly in june and july",
+			rule:    "FREQ=YEARLY;COUNT=4;BYMONTH=6,7",
+			dtstart: "19970610T090000",
+			want:    []string{"19970610T090000", "19970710T090000", "19980610T090000", "19980710T090000"},
+			all:     true,
+		},
+		{
+			name:    "20th monday of the year",
+			rule:    "FREQ=YEARLY;BYDAY=20MO",
+			dtstart: "19970519T090000",
+			want:    []string{"19970519T090000", "19980518T090000", "19990517T090000"},
+		},
+		{
+			name:    "monday of week 20",
+			rule:    "FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO",
+			dtstart: "19970512T090000",
+			want:    []string{"19970512T090000", "19980511T090000", "19990517T090000"},
+		},
+		{
+			name:    "yearly on day 1, 100 and 200",
+			rule:    "FREQ=YEARLY;INTERVAL=3;COUNT=4;BYYEARDAY=1,100,200",
+			dtstart: "19970101T090000",
+			want:    []string{"19970101T090000", "19970410T090000", "19970719T090000", "20000101T090000"},
+			all:     true,
+		},
+		{
+			name:    "leap day",
+			rule:    "FREQ=YEARLY;COUNT=3",
+			dtstart: "20000229T090000",
+			want:    []string{"20000229T090000", "20040229T090000", "20080229T090000"},
+			all:     true,
+		},
+		{
+			name:    "last work day of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			dtstart: "19970930T090000",
+			want:    []string{"19970930T090000", "19971031T090000", "19971128T090000", "19971231T090000"},
+		},
+		{
+			name:    "every 20 minutes from 9 to 11",
+			rule:    "FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10",
+			dtstart: "19970902T090000",
+			want:    []string{"19970902T090000", "19970902T092000", "19970902T094000", "19970902T100000", "19970902T102000", "19970902T104000", "19970903T090000"},
+		},
+		{
+			name:    "every 3 hours until 5pm",
+			rule:    "FREQ=HOURLY;INTERVAL=3;UNTIL=19970902T210000Z",
+			dtstart: "19970902T090000",
+			want:    []string{"19970902T090000", "19970902T120000", "19970902T150000"},
+			all:     true,
+		},
+		{
+			name:    "never matches",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=30;BYMONTH=2",
+			dtstart: "19970902T090000",
+			want:    nil,
+			all:     true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tc.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule() = %v", err)
+			}
+			dtstart, err := time.ParseInLocation(dateTimeFormat, tc.dtstart, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for occ := range rule.Occurrences(dtstart) {
+				if occ.Location() != loc {
+					t.Errorf("occurrence %v isn't in the location of DTSTART", occ)
+				}
+				got = append(got, occ.Format(dateTimeFormat))
+				if !tc.all && len(got) == len(tc.want) {
+					break
+				}
+				if len(got) > len(tc.want)+10 {
+					break
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("got occurrences:\n%v\nwant:\n%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceSet(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(recurringCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	master := cal.Master()
+	master.Props.Add(&Prop{Name: PropRecurrenceDates, Params: Params{ParamTimezoneID: {"Europe/Paris"}}, Value: "20240104T150000"})
+
+	set, err := master.RecurrenceSet(nil)
+	if err != nil {
+		t.Fatalf("RecurrenceSet() = %v", err)
+	}
+	if !set.IsRecurring() {
+		t.Errorf("IsRecurring() = false")
+	}
+
+	var got []string
+	for occ := range set.All() {
+		got = append(got, occ.Format(dateTimeFormat))
+		if len(got) == 5 {
+			break
+		}
+	}
+	want := []string{"20240102T090000", "20240104T150000", "20240109T090000", "20240123T090000", "20240130T090000"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got occurrences %v, want %v", got, want)
+	}
+
+	excluded, _ := time.ParseInLocation(dateTimeFormat, "20240116T090000", set.Start.Location())
+	if set.Contains(excluded) || !set.Contains(set.Start.AddDate(0, 0, 7)) {
+		t.Errorf("Contains() doesn't take EXDATE into account")
+	}
+}
@@ -0,0 +1,279 @@
+package ical
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PropLicLocation is the X-LIC-LOCATION property of VTIMEZONE components,
+// added by libical-based clients such as Thunderbird with the IANA name of
+// the timezone.
+const PropLicLocation = "X-LIC-LOCATION"
+
+// WindowsTimezones maps common Windows timezone names, used by Exchange and
+// Outlook as TZIDs, to IANA names, following the CLDR windowsZones mapping.
+var WindowsTimezones = map[string]string{
+
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time":          "America/Denver",
+	"Central Standard Time":           "America/Chicago",
+	"Central America Standard Time":   "America/Guatemala",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Eastern Standard Time":           "America/New_York",
+	"US Eastern Standard Time":        "America/Indiana/Indianapolis",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"Argentina Standard Time":         "America/Argentina/Buenos_Aires",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"FLE Standard Time":               "Europe/Kiev",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Russian Standard Time":           "Europe/Moscow",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"India Standard Time":             "Asia/Kolkata",
+	"Nepal Standard Time":             "Asia/Kathmandu",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+}
+
+// LoadLocation returns the location for a TZID. Besides IANA names, it
+// understands the Windows names of WindowsTimezones and IANA names with a
+// vendor prefix, such as "/mozilla.org/20050126_1/Europe/Berlin".
+func LoadLocation(tzid string) (*time.Location, error) {
+	if tzid == "" {
+		return nil, fmt.Errorf("ical: empty TZID")
+	}
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc, nil
+	}
+	if name, ok := WindowsTimezones[tzid]; ok {
+		return time.LoadLocation(name)
+	}
+	if strings.HasPrefix(tzid, "/") {
+		segments := strings.Split(strings.TrimPrefix(tzid, "/"), "/")
+		for i := 1; i < len(segments); i++ {
+			if loc, err := time.LoadLocation(strings.Join(segments[i:], "/")); err == nil {
+				return loc, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("ical: unknown TZID %q", tzid)
+}
+
+// Location returns the location for a TZID used in the calendar. Names known
+// to LoadLocation are resolved directly, other ones are resolved with the
+// VTIMEZONE component of the calendar with the same TZID.
+func (cal *Calendar) Location(tzid string) (*time.Location, error) {
+	if loc, err := LoadLocation(tzid); err == nil {
+		return loc, nil
+	}
+	for _, comp := range cal.Children {
+		if comp.Name != CompTimezone {
+			continue
+		}
+		if id, _ := comp.Props.Text(PropTimezoneID); id != tzid {
+			continue
+		}
+		if name, _ := comp.Props.Text(PropLicLocation); name != "" {
+			if loc, err := LoadLocation(name); err == nil {
+				return loc, nil
+			}
+		}
+		return timezoneLocation(tzid, comp)
+	}
+	return nil, fmt.Errorf("ical: unknown TZID %q", tzid)
+}
+
+// propLocation returns the location to use for prop: the location of its
+// TZID if it can be resolved with the calendar, loc otherwise.
+func (cal *Calendar) propLocation(prop *Prop, loc *time.Location) *time.Location {
+	if prop == nil {
+		return loc
+	}
+	if tzid := prop.Params.Get(ParamTimezoneID); tzid != "" {
+		if tzLoc, err := cal.Location(tzid); err == nil {
+			return tzLoc
+		}
+	}
+	return loc
+}
+
+// timezoneEndYear bounds the expansion of recurring VTIMEZONE observances.
+const timezoneEndYear = 2100
+
+type timezoneTransition struct {
+	at     int64
+	offset int
+	isDST  bool
+	name   string
+}
+
+// timezoneLocation builds a location from the STANDARD and DAYLIGHT
+// observances of a VTIMEZONE component.
+func timezoneLocation(tzid string, tz *Component) (*time.Location, error) {
+	end := time.Date(timezoneEndYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var transitions []timezoneTransition
+	for _, obs := range tz.Children {
+		if obs.Name != CompTimezoneStandard && obs.Name != CompTimezoneDaylight {
+			continue
+		}
+		from, err := obs.Props.Get(PropTimezoneOffsetFrom).utcOffset()
+		if err != nil {
+			return nil, err
+		}
+		to, err := obs.Props.Get(PropTimezoneOffsetTo).utcOffset()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := obs.Props.Text(PropTimezoneName)
+
+		// Onsets are local times in the offset before the transition:
+		// expand them as UTC times and shift them afterwards
+		set, err := obs.RecurrenceSet(time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		for onset := range set.All() {
+			if !onset.Before(end) {
+				break
+			}
+			transitions = append(transitions, timezoneTransition{
+				at:     onset.Unix() - int64(from),
+				offset: to,
+				isDST:  obs.Name == CompTimezoneDaylight,
+				name:   name,
+			})
+		}
+	}
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("ical: VTIMEZONE %q has no observance", tzid)
+	}
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].at < transitions[j].at })
+
+	return time.LoadLocationFromTZData(tzid, encodeTZif(transitions))
+}
+
+// encodeTZif encodes transitions in the version 2 TZif format defined in RFC
+// 8536, understood by time.LoadLocationFromTZData.
+func encodeTZif(transitions []timezoneTransition) []byte {
+	type zone struct {
+		offset int
+		isDST  bool
+		name   string
+	}
+	var (
+		zones   []zone
+		indices []byte
+		names   []byte
+		nameIdx = make(map[string]int)
+	)
+	for _, tr := range transitions {
+		z := zone{tr.offset, tr.isDST, tr.name}
+		i := 0
+		for i < len(zones) && zones[i] != z {
+			i++
+		}
+		if i == len(zones) {
+			zones = append(zones, z)
+			if _, ok := nameIdx[z.name]; !ok {
+				nameIdx[z.name] = len(names)
+				names = append(names, z.name...)
+				names = append(names, 0)
+			}
+		}
+		indices = append(indices, byte(i))
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(timeCount, zoneCount, nameCount int) {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		for _, n := range []int{0, 0, 0, timeCount, zoneCount, nameCount} {
+			binary.Write(&buf, binary.BigEndian, uint32(n))
+		}
+	}
+
+	// Version 1 data, only read by old readers: a single UTC zone
+	writeHeader(0, 1, 1)
+	buf.Write(make([]byte, 6+1))
+
+	writeHeader(len(transitions), len(zones), len(names))
+	for _, tr := range transitions {
+		binary.Write(&buf, binary.BigEndian, tr.at)
+	}
+	buf.Write(indices)
+	for _, z := range zones {
+		binary.Write(&buf, binary.BigEndian, int32(z.offset))
+		if z.isDST {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		buf.WriteByte(byte(nameIdx[z.name]))
+	}
+	buf.Write(names)
+	buf.WriteString("\n\n")
+	return buf.Bytes()
+}
+
+// utcOffset parses a UTC-OFFSET value, e.g. "-0500" or "+013045", and
+// returns it in seconds.
+func (prop *Prop) utcOffset() (int, error) {
+	if prop == nil {
+		return 0, fmt.Errorf("ical: missing UTC offset")
+	}
+	v := strings.TrimSpace(prop.Value)
+	if (len(v) != 5 && len(v) != 7) || (v[0] != '+' && v[0] != '-') {
+		return 0, fmt.Errorf("ical: malformed UTC offset %q", prop.Value)
+	}
+	var parts [3]int
+	for i := 0; 1+2*i < len(v); i++ {
+		n, err := strconv.Atoi(v[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, fmt.Errorf("ical: malformed UTC offset %q", prop.Value)
+		}
+		parts[i] = n
+	}
+	offset := parts[0]*3600 + parts[1]*60 + parts[2]
+	if v[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
@@ -0,0 +1,127 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadLocation(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	for _, tzid := range []string{
+		"Europe/Berlin",
+		"W. Europe Standard Time",
+		"/mozilla.org/20050126_1/Europe/Berlin",
+		"/citadel.org/20190914_1/Europe/Berlin",
+	} {
+		loc, err := LoadLocation(tzid)
+		if err != nil {
+			t.Errorf("LoadLocation(%q) = %v", tzid, err)
+		} else if loc.String() != "Europe/Berlin" {
+			t.Errorf("LoadLocation(%q) = %v, want Europe/Berlin", tzid, loc)
+		}
+	}
+
+	for _, tzid := range []string{"", "Mars Standard Time", "/example.org/Nowhere"} {
+		if _, err := LoadLocation(tzid); err == nil {
+			t.Errorf("LoadLocation(%q) succeeded, expected an error", tzid)
+		}
+	}
+}
+
+const customTimezoneCalendarStr = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VTIMEZONE
+TZID:Customized Time Zone
+BEGIN:STANDARD
+DTSTART:16010101T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+TZNAME:CEST
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:Thunderbird
+X-LIC-LOCATION:Asia/Tokyo
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0900
+TZOFFSETTO:+0900
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:custom
+DTSTAMP:20240101T000000Z
+DTSTART;TZID=Customized Time Zone:20240329T090000
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestCalendarLocation(t *testing.T) {
+	cal, err := NewDecoder(strings.NewReader(customTimezoneCalendarStr)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	loc, err := cal.Location("Customized Time Zone")
+	if err != nil {
+		t.Fatalf("Location() = %v", err)
+	}
+	for _, tc := range []struct {
+		t      time.Time
+		name   string
+		offset int
+	}{
+		{time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), "CET", 3600},
+		{time.Date(2024, 3, 31, 0, 59, 0, 0, time.UTC), "CET", 3600},
+		{time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC), "CEST", 7200},
+		{time.Date(2024, 10, 27, 0, 59, 0, 0, time.UTC), "CEST", 7200},
+		{time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC), "CET", 3600},
+		{time.Date(2090, 7, 1, 0, 0, 0, 0, time.UTC), "CEST", 7200},
+	} {
+		name, offset := tc.t.In(loc).Zone()
+		if name != tc.name || offset != tc.offset {
+			t.Errorf("zone at %v = %v %v, want %v %v", tc.t, name, offset, tc.name, tc.offset)
+		}
+	}
+
+	if _, err := time.LoadLocation("Asia/Tokyo"); err == nil {
+		if loc, err := cal.Location("Thunderbird"); err != nil || loc.String() != "Asia/Tokyo" {
+			t.Errorf("Location() = %v, %v, want Asia/Tokyo", loc, err)
+		}
+	}
+
+	if _, err := cal.Location("Unknown"); err == nil {
+		t.Errorf("Location() succeeded for an unknown TZID")
+	}
+
+	set, err := cal.RecurrenceSet(cal.Events()[0].Component, nil)
+	if err != nil {
+		t.Fatalf("RecurrenceSet() = %v", err)
+	}
+	var got []string
+	for occ := range set.All() {
+		got = append(got, occ.UTC().Format(utcDateTimeFormat))
+	}
+	// Daylight saving time starts on 2024-03-31
+	want := []string{"20240329T080000Z", "20240330T080000Z", "20240331T070000Z"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got occurrences %v, want %v", got, want)
+	}
+	if _, err := cal.Events()[0].RecurrenceSet(nil); err == nil {
+		t.Errorf("Component.RecurrenceSet() resolved a VTIMEZONE TZID")
+	}
+}
@@ -0,0 +1,449 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValueType is the type of a property value, as defined in RFC 5545 section
+// 3.3.
+type ValueType string
+
+const (
+	ValueDefault         ValueType = ""
+	ValueBinary          ValueType = "BINARY"
+	ValueBool            ValueType = "BOOLEAN"
+	ValueCalendarAddress ValueType = "CAL-ADDRESS"
+	ValueDate            ValueType = "DATE"
+	ValueDateTime        ValueType = "DATE-TIME"
+	ValueDuration        ValueType = "DURATION"
+	ValueFloat           ValueType = "FLOAT"
+	ValueInt             ValueType = "INTEGER"
+	ValuePeriod          ValueType = "PERIOD"
+	ValueRecurrence      ValueType = "RECUR"
+	ValueText            ValueType = "TEXT"
+	ValueTime            ValueType = "TIME"
+	ValueURI             ValueType = "URI"
+	ValueUTCOffset       ValueType = "UTC-OFFSET"
+)
+
+var defaultValueTypes = map[string]ValueType{
+	PropCalendarScale:      ValueText,
+	PropMethod:             ValueText,
+	PropProductID:          ValueText,
+	PropVersion:            ValueText,
+	PropAttach:             ValueURI,
+	PropCategories:         ValueText,
+	PropClass:              ValueText,
+	PropComment:            ValueText,
+	PropDescription:        ValueText,
+	PropGeo:                ValueFloat,
+	PropLocation:           ValueText,
+	PropPercentComplete:    ValueInt,
+	PropPriority:           ValueInt,
+	PropResources:          ValueText,
+	PropStatus:             ValueText,
+	PropSummary:            ValueText,
+	PropCompleted:          ValueDateTime,
+	PropDateTimeEnd:        ValueDateTime,
+	PropDue:                ValueDateTime,
+	PropDateTimeStart:      ValueDateTime,
+	PropDuration:           ValueDuration,
+	PropFreeBusy:           ValuePeriod,
+	PropTransparency:       ValueText,
+	PropTimezoneID:         ValueText,
+	PropTimezoneName:       ValueText,
+	PropTimezoneOffsetFrom: ValueUTCOffset,
+	PropTimezoneOffsetTo:   ValueUTCOffset,
+	PropTimezoneURL:        ValueURI,
+	PropAttendee:           ValueCalendarAddress,
+	PropContact:            ValueText,
+	PropOrganizer:          ValueCalendarAddress,
+	PropRecurrenceID:       ValueDateTime,
+	PropRelatedTo:          ValueText,
+	PropURL:                ValueURI,
+	PropUID:                ValueText,
+	PropExceptionDates:     ValueDateTime,
+	PropRecurrenceDates:    ValueDateTime,
+	PropRecurrenceRule:     ValueRecurrence,
+	PropAction:             ValueText,
+	PropRepeat:             ValueInt,
+	PropTrigger:            ValueDuration,
+	PropCreated:            ValueDateTime,
+	PropDateTimeStamp:      ValueDateTime,
+	PropLastModified:       ValueDateTime,
+	PropSequence:           ValueInt,
+	PropRequestStatus:      ValueText,
+
+	// RFC 7986
+	"NAME":             ValueText,
+	"REFRESH-INTERVAL": ValueDuration,
+	"SOURCE":           ValueURI,
+	"COLOR":            ValueText,
+	"IMAGE":            ValueURI,
+	"CONFERENCE":       ValueURI,
+
+	// RFC 9074
+	"ACKNOWLEDGED": ValueDateTime,
+	"PROXIMITY":    ValueText,
+}
+
+// DefaultValueType returns the default value type of the property with the
+// specified name, or ValueDefault if the property is unknown.
+func DefaultValueType(name string) ValueType {
+	return defaultValueTypes[strings.ToUpper(name)]
+}
+
+// ValueType returns the type of the property value. The VALUE parameter takes
+// precedence over the property's default value type.
+func (prop *Prop) ValueType() ValueType {
+	if t := prop.Params.Get(ParamValue); t != "" {
+		return ValueType(strings.ToUpper(t))
+	}
+	return DefaultValueType(prop.Name)
+}
+
+// SetValueType sets the VALUE parameter. The parameter is omitted when the
+// type matches the property's default value type.
+func (prop *Prop) SetValueType(t ValueType) {
+	if t == ValueDefault || t == DefaultValueType(prop.Name) {
+		prop.Params.Del(ParamValue)
+	} else {
+		prop.Params.Set(ParamValue, string(t))
+	}
+}
+
+// Text returns the unescaped TEXT value.
+func (prop *Prop) Text() (string, error) {
+	if t := prop.ValueType(); t != ValueText && t != ValueDefault {
+		return "", fmt.Errorf("ical: expected TEXT value for %v, got %v", prop.Name, t)
+	}
+	return unescapeText(prop.Value), nil
+}
+
+// SetText sets a TEXT value.
+func (prop *Prop) SetText(text string) {
+	prop.Params.Del(ParamValue)
+	prop.Value = escapeText(text)
+}
+
+// TextList returns the unescaped values of a comma-separated TEXT list.
+func (prop *Prop) TextList() ([]string, error) {
+	if t := prop.ValueType(); t != ValueText && t != ValueDefault {
+		return nil, fmt.Errorf("ical: expected TEXT value for %v, got %v", prop.Name, t)
+	}
+	l := splitUnescaped(prop.Value, ',')
+	for i, s := range l {
+		l[i] = unescapeText(s)
+	}
+	return l, nil
+}
+
+// SetTextList sets a comma-separated TEXT list.
+func (prop *Prop) SetTextList(l []string) {
+	prop.Params.Del(ParamValue)
+	escaped := make([]string, len(l))
+	for i, s := range l {
+		escaped[i] = escapeText(s)
+	}
+	prop.Value = strings.Join(escaped, ",")
+}
+
+// Int returns the INTEGER value.
+func (prop *Prop) Int() (int, error) {
+	return strconv.Atoi(strings.TrimSpace(prop.Value))
+}
+
+// SetInt sets an INTEGER value.
+func (prop *Prop) SetInt(v int) {
+	prop.SetValueType(ValueInt)
+	prop.Value = strconv.Itoa(v)
+}
+
+const (
+	dateFormat        = "20060102"
+	dateTimeFormat    = "20060102T150405"
+	utcDateTimeFormat = "20060102T150405Z"
+)
+
+// DateTime returns the DATE or DATE-TIME value.
+//
+// UTC values are returned in UTC. Values with a TZID parameter are returned in
+// that location, see LoadLocation. Floating values, dates and values with an
+// unknown TZID are interpreted in loc; if loc is nil, time.Local is used for
+// floating values and an error is returned for unknown TZIDs. Calendar.Location
+// resolves TZIDs defined by the VTIMEZONE components of a calendar.
+func (prop *Prop) DateTime(loc *time.Location) (time.Time, error) {
+	return parseDateTime(prop.Value, prop.ValueType(), prop.Params.Get(ParamTimezoneID), loc)
+}
+
+// DateTimes returns the values of a comma-separated DATE or DATE-TIME list,
+// such as EXDATE and RDATE.
+func (prop *Prop) DateTimes(loc *time.Location) ([]time.Time, error) {
+	var l []time.Time
+	for _, v := range strings.Split(prop.Value, ",") {
+		t, err := parseDateTime(v, prop.ValueType(), prop.Params.Get(ParamTimezoneID), loc)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, t)
+	}
+	return l, nil
+}
+
+func parseDateTime(value string, valueType ValueType, tzid string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if tzid != "" {
+		if tzLoc, err := LoadLocation(tzid); err == nil {
+			loc = tzLoc
+		} else if loc == nil {
+			return time.Time{}, err
+		}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if valueType == ValueDate || (valueType != ValueDateTime && len(value) == len(dateFormat)) {
+		return time.ParseInLocation(dateFormat, value, loc)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.ParseInLocation(utcDateTimeFormat, value, time.UTC)
+	}
+	return time.ParseInLocation(dateTimeFormat, value, loc)
+}
+
+// SetDateTime sets a DATE-TIME value. Times in a location which can be loaded
+// with time.LoadLocation carry a TZID parameter with its name, other times,
+// including local and fixed-offset ones, are written in UTC form.
+func (prop *Prop) SetDateTime(t time.Time) {
+	prop.SetValueType(ValueDateTime)
+	if name := t.Location().String(); isLoadableLocation(name) {
+		prop.Params.Set(ParamTimezoneID, name)
+		prop.Value = t.Format(dateTimeFormat)
+		return
+	}
+	prop.Params.Del(ParamTimezoneID)
+	prop.Value = t.UTC().Format(utcDateTimeFormat)
+}
+
+// loadableLocations caches the results of isLoadableLocation, since
+// time.LoadLocation reads the time zone database each time it's called.
+var loadableLocations sync.Map // string -> bool
+
+// isLoadableLocation reports whether time.LoadLocation resolves name to a
+// time zone other than UTC or Local.
+func isLoadableLocation(name string) bool {
+	if ok, cached := loadableLocations.Load(name); cached {
+		return ok.(bool)
+	}
+	// time.LoadLocation returns UTC and Local for the empty name and for
+	// "UTC" and "Local", which would lose a fixed offset
+	loc, err := time.LoadLocation(name)
+	ok := err == nil && loc != time.UTC && loc != time.Local
+	loadableLocations.Store(name, ok)
+	return ok
+}
+
+// SetDate sets a DATE value.
+func (prop *Prop) SetDate(t time.Time) {
+	prop.Params.Del(ParamTimezoneID)
+	prop.SetValueType(ValueDate)
+	prop.Value = t.Format(dateFormat)
+}
+
+// IsDate reports whether the property holds a DATE rather than a DATE-TIME.
+func (prop *Prop) IsDate() bool {
+	if prop.ValueType() == ValueDate {
+		return true
+	}
+	return len(strings.TrimSpace(prop.Value)) == len(dateFormat)
+}
+
+// Duration returns the DURATION value.
+func (prop *Prop) Duration() (time.Duration, error) {
+	return ParseDuration(prop.Value)
+}
+
+// SetDuration sets a DURATION value.
+func (prop *Prop) SetDuration(d time.Duration) {
+	prop.SetValueType(ValueDuration)
+	prop.Value = FormatDuration(d)
+}
+
+// ParseDuration parses a DURATION value, as defined in RFC 5545 section
+// 3.3.6.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	orig := s
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	seen := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime = true
+			s = s[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("ical: invalid duration %q", orig)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("ical: invalid duration %q: %v", orig, err)
+		}
+
+		var unit time.Duration
+		switch c := s[i]; {
+		case c == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case c == 'H' && inTime:
+			unit = time.Hour
+		case c == 'M' && inTime:
+			unit = time.Minute
+		case c == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("ical: invalid duration %q", orig)
+		}
+		d += time.Duration(n) * unit
+		seen = true
+		s = s[i+1:]
+	}
+	if !seen {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// FormatDuration formats a DURATION value, as defined in RFC 5545 section
+// 3.3.6. Sub-second precision is dropped.
+func FormatDuration(d time.Duration) string {
+	var sb strings.Builder
+	if d < 0 {
+		sb.WriteByte('-')
+		d = -d
+	}
+	sb.WriteByte('P')
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	if days > 0 && days%7 == 0 && hours == 0 && minutes == 0 && seconds == 0 {
+		fmt.Fprintf(&sb, "%dW", days/7)
+		return sb.String()
+	}
+	if days > 0 {
+		fmt.Fprintf(&sb, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		sb.WriteByte('T')
+		if hours > 0 {
+			fmt.Fprintf(&sb, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&sb, "%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			fmt.Fprintf(&sb, "%dS", seconds)
+		}
+	}
+	return sb.String()
+}
+
+func escapeText(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case ';':
+			sb.WriteString(`\;`)
+		case ',':
+			sb.WriteString(`\,`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			// dropped, line breaks are encoded as \n
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// splitUnescaped splits s around each instance of sep that isn't escaped
+// with a backslash.
+func splitUnescaped(s string, sep byte) []string {
+	var (
+		l     []string
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			l = append(l, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(l, s[start:])
+}
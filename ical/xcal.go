@@ -0,0 +1,457 @@
+package ical
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xCal is the XML representation of iCalendar, defined in RFC 6321. It's
+// implemented on top of the jCal conversion: both formats share the same
+// value formats and only differ in their structure.
+
+// XCalNamespace is the XML namespace of xCal elements.
+const XCalNamespace = "urn:ietf:params:xml:ns:icalendar-2.0"
+
+// xcalParamTypes lists the value types of parameters which aren't TEXT, as
+// defined in RFC 6321 section 3.5.
+var xcalParamTypes = map[string]string{
+	ParamAltRep:        "uri",
+	ParamDir:           "uri",
+	ParamDelegatedFrom: "cal-address",
+	ParamDelegatedTo:   "cal-address",
+	ParamMember:        "cal-address",
+	ParamSentBy:        "cal-address",
+	ParamRSVP:          "boolean",
+}
+
+// xcalRequestStatusParts lists the elements of a REQUEST-STATUS value.
+var xcalRequestStatusParts = []string{"code", "description", "data"}
+
+// MarshalXCal returns the xCal encoding of a VCALENDAR component.
+func MarshalXCal(cal *Calendar) ([]byte, error) {
+	if cal == nil || cal.Component == nil {
+		return nil, fmt.Errorf("ical: cannot encode a nil calendar")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	root := xml.StartElement{
+		Name: xml.Name{Local: "icalendar"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: XCalNamespace}},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return nil, err
+	}
+	if err := encodeXCalComponent(enc, cal.Component); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXCalComponent(enc *xml.Encoder, comp *Component) error {
+	start := xcalStart(strings.ToLower(comp.Name))
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if len(comp.Props) > 0 {
+		props := xcalStart("properties")
+		if err := enc.EncodeToken(props); err != nil {
+			return err
+		}
+		for _, prop := range comp.Props {
+			v, err := jcalFromProp(prop)
+			if err != nil {
+				return err
+			}
+			if err := encodeXCalProp(enc, v); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(props.End()); err != nil {
+			return err
+		}
+	}
+
+	if len(comp.Children) > 0 {
+		comps := xcalStart("components")
+		if err := enc.EncodeToken(comps); err != nil {
+			return err
+		}
+		for _, child := range comp.Children {
+			if err := encodeXCalComponent(enc, child); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(comps.End()); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// encodeXCalProp writes a property from its jCal representation.
+func encodeXCalProp(enc *xml.Encoder, v []interface{}) error {
+	name := v[0].(string)
+	params := v[1].(jsonObject)
+	typeName := v[2].(string)
+	values := v[3:]
+
+	start := xcalStart(name)
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if len(params) > 0 {
+		paramsStart := xcalStart("parameters")
+		if err := enc.EncodeToken(paramsStart); err != nil {
+			return err
+		}
+		for _, m := range params {
+			paramType := xcalParamTypes[strings.ToUpper(m.Key)]
+			if paramType == "" {
+				paramType = "text"
+			}
+
+			var l []interface{}
+			if values, ok := m.Value.([]interface{}); ok {
+				l = values
+			} else {
+				l = []interface{}{m.Value}
+			}
+
+			paramStart := xcalStart(m.Key)
+			if err := enc.EncodeToken(paramStart); err != nil {
+				return err
+			}
+			for _, pv := range l {
+				s := pv.(string)
+				if paramType == "boolean" {
+					s = strings.ToLower(s)
+				}
+				if err := encodeXCalText(enc, paramType, s); err != nil {
+					return err
+				}
+			}
+			if err := enc.EncodeToken(paramStart.End()); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(paramsStart.End()); err != nil {
+			return err
+		}
+	}
+
+	for _, value := range values {
+		if err := encodeXCalValue(enc, name, typeName, value); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+func encodeXCalValue(enc *xml.Encoder, propName, typeName string, value interface{}) error {
+	switch value := value.(type) {
+	case []interface{}:
+		// Structured values, see RFC 6321 section 3.4.1.2 and 3.4.1.3
+		var parts []string
+		switch propName {
+		case "geo":
+			parts = []string{"latitude", "longitude"}
+		case "request-status":
+			parts = xcalRequestStatusParts
+		default:
+			return fmt.Errorf("ical: unexpected structured value in %v", propName)
+		}
+		for i, part := range value {
+			if i >= len(parts) {
+				return fmt.Errorf("ical: too many structured value parts in %v", propName)
+			}
+			if err := encodeXCalText(enc, parts[i], fmt.Sprint(part)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case jsonObject:
+		recur := xcalStart(typeName)
+		if err := enc.EncodeToken(recur); err != nil {
+			return err
+		}
+		for _, m := range value {
+			var l []interface{}
+			if values, ok := m.Value.([]interface{}); ok {
+				l = values
+			} else {
+				l = []interface{}{m.Value}
+			}
+			for _, item := range l {
+				if err := encodeXCalText(enc, m.Key, fmt.Sprint(item)); err != nil {
+					return err
+				}
+			}
+		}
+		return enc.EncodeToken(recur.End())
+	case bool:
+		return encodeXCalText(enc, typeName, fmt.Sprint(value))
+	case json.Number:
+		return encodeXCalText(enc, typeName, string(value))
+	case string:
+		if typeName != "period" {
+			return encodeXCalText(enc, typeName, value)
+		}
+		parts := strings.SplitN(value, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("ical: malformed PERIOD value %q", value)
+		}
+		endName := "end"
+		if strings.Contains(parts[1], "P") {
+			endName = "duration"
+		}
+		period := xcalStart("period")
+		if err := enc.EncodeToken(period); err != nil {
+			return err
+		}
+		if err := encodeXCalText(enc, "start", parts[0]); err != nil {
+			return err
+		}
+		if err := encodeXCalText(enc, endName, parts[1]); err != nil {
+			return err
+		}
+		return enc.EncodeToken(period.End())
+	default:
+		return fmt.Errorf("ical: unexpected %T value in %v", value, propName)
+	}
+}
+
+func encodeXCalText(enc *xml.Encoder, name, text string) error {
+	start := xcalStart(name)
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func xcalStart(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
+
+// xcalNode is a generic xCal element.
+type xcalNode struct {
+	XMLName  xml.Name
+	Children []xcalNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func (node *xcalNode) child(name string) *xcalNode {
+	for i := range node.Children {
+		if node.Children[i].XMLName.Local == name {
+			return &node.Children[i]
+		}
+	}
+	return nil
+}
+
+// UnmarshalXCal parses the xCal encoding of a VCALENDAR component.
+func UnmarshalXCal(data []byte) (*Calendar, error) {
+	var root xcalNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("ical: invalid xCal: %v", err)
+	}
+	if root.XMLName.Space != XCalNamespace || root.XMLName.Local != "icalendar" {
+		return nil, fmt.Errorf("ical: invalid xCal: unexpected root element <%v %v>", root.XMLName.Space, root.XMLName.Local)
+	}
+	if len(root.Children) != 1 {
+		return nil, fmt.Errorf("ical: invalid xCal: expected a single component, got %v", len(root.Children))
+	}
+
+	comp, err := componentFromXCal(&root.Children[0])
+	if err != nil {
+		return nil, err
+	}
+	if comp.Name != CompCalendar {
+		return nil, fmt.Errorf("ical: expected %v component, got %v", CompCalendar, comp.Name)
+	}
+	return &Calendar{comp}, nil
+}
+
+func componentFromXCal(node *xcalNode) (*Component, error) {
+	comp := NewComponent(node.XMLName.Local)
+	for i := range node.Children {
+		child := &node.Children[i]
+		switch child.XMLName.Local {
+		case "properties":
+			for j := range child.Children {
+				v, err := jcalFromXCalProp(&child.Children[j])
+				if err != nil {
+					return nil, err
+				}
+				prop, err := propFromJCal(v)
+				if err != nil {
+					return nil, err
+				}
+				comp.Props = append(comp.Props, prop)
+			}
+		case "components":
+			for j := range child.Children {
+				sub, err := componentFromXCal(&child.Children[j])
+				if err != nil {
+					return nil, err
+				}
+				comp.Children = append(comp.Children, sub)
+			}
+		default:
+			return nil, fmt.Errorf("ical: invalid xCal component %v: unexpected element %v", node.XMLName.Local, child.XMLName.Local)
+		}
+	}
+	return comp, nil
+}
+
+// jcalFromXCalProp converts an xCal property into its jCal representation.
+func jcalFromXCalProp(node *xcalNode) ([]interface{}, error) {
+	name := node.XMLName.Local
+	params := jsonObject{}
+	var (
+		typeName string
+		values   []interface{}
+	)
+
+	for i := range node.Children {
+		child := &node.Children[i]
+		if child.XMLName.Local != "parameters" {
+			continue
+		}
+		for j := range child.Children {
+			param := &child.Children[j]
+			isBool := xcalParamTypes[strings.ToUpper(param.XMLName.Local)] == "boolean"
+			l := make([]interface{}, len(param.Children))
+			for k := range param.Children {
+				s := param.Children[k].Text
+				if isBool {
+					s = strings.ToUpper(s)
+				}
+				l[k] = s
+			}
+			var v interface{} = l
+			if len(l) == 1 {
+				v = l[0]
+			}
+			params = append(params, jsonMember{param.XMLName.Local, v})
+		}
+	}
+
+	switch name {
+	case "geo", "request-status":
+		// Structured values, see RFC 6321 section 3.4.1.2 and 3.4.1.3
+		parts := []string{"latitude", "longitude"}
+		typeName = "float"
+		if name == "request-status" {
+			parts = xcalRequestStatusParts
+			typeName = "text"
+		}
+
+		var l []interface{}
+		for _, part := range parts {
+			child := node.child(part)
+			if child == nil {
+				continue
+			}
+			if typeName == "float" {
+				l = append(l, json.Number(strings.TrimSpace(child.Text)))
+			} else {
+				l = append(l, child.Text)
+			}
+		}
+		values = append(values, l)
+	default:
+		for i := range node.Children {
+			child := &node.Children[i]
+			childName := child.XMLName.Local
+			if childName == "parameters" {
+				continue
+			}
+			if typeName != "" && childName != typeName {
+				return nil, fmt.Errorf("ical: invalid xCal property %v: mixed value types", name)
+			}
+			typeName = childName
+
+			v, err := jcalFromXCalValue(child)
+			if err != nil {
+				return nil, fmt.Errorf("ical: invalid xCal property %v: %v", name, err)
+			}
+			values = append(values, v)
+		}
+	}
+
+	if typeName == "" {
+		return nil, fmt.Errorf("ical: invalid xCal property %v: missing value", name)
+	}
+
+	v := []interface{}{name, params, typeName}
+	return append(v, values...), nil
+}
+
+func jcalFromXCalValue(node *xcalNode) (interface{}, error) {
+	switch node.XMLName.Local {
+	case "recur":
+		var obj jsonObject
+		index := make(map[string]int)
+		for i := range node.Children {
+			part := &node.Children[i]
+			key := part.XMLName.Local
+
+			var v interface{} = part.Text
+			if jcalRecurIntParts[strings.ToUpper(key)] {
+				v = json.Number(strings.TrimSpace(part.Text))
+			}
+
+			if j, ok := index[key]; ok {
+				if l, ok := obj[j].Value.([]interface{}); ok {
+					obj[j].Value = append(l, v)
+				} else {
+					obj[j].Value = []interface{}{obj[j].Value, v}
+				}
+				continue
+			}
+			index[key] = len(obj)
+			obj = append(obj, jsonMember{key, v})
+		}
+		return obj, nil
+	case "period":
+		start := node.child("start")
+		end := node.child("end")
+		if end == nil {
+			end = node.child("duration")
+		}
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("malformed period value")
+		}
+		return start.Text + "/" + end.Text, nil
+	case "integer", "float":
+		return json.Number(strings.TrimSpace(node.Text)), nil
+	case "boolean":
+		switch strings.ToLower(strings.TrimSpace(node.Text)) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("malformed boolean value %q", node.Text)
+	default:
+		return node.Text, nil
+	}
+}
@@ -0,0 +1,97 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestXCal_roundTrip(t *testing.T) {
+	for _, data := range []string{exampleCalendarStr, exampleJCalCalendarStr} {
+		cal, err := NewDecoder(strings.NewReader(data)).Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+
+		b, err := MarshalXCal(cal)
+		if err != nil {
+			t.Fatalf("MarshalXCal() = %v", err)
+		}
+
+		cal, err = UnmarshalXCal(b)
+		if err != nil {
+			t.Fatalf("UnmarshalXCal() = %v\n%s", err, b)
+		}
+
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf).Encode(cal); err != nil {
+			t.Fatalf("Encode() = %v", err)
+		}
+		if buf.String() != data {
+			t.Errorf("output doesn't match input:\n%v\nvs.\n%v", buf.String(), data)
+		}
+	}
+}
+
+// https://tools.ietf.org/html/rfc6321#appendix-B.1
+const exampleXCalStr = `<?xml version="1.0" encoding="utf-8"?>
+<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+  <vcalendar>
+    <properties>
+      <calscale><text>GREGORIAN</text></calscale>
+      <prodid><text>-//Example Inc.//Example Calendar//EN</text></prodid>
+      <version><text>2.0</text></version>
+    </properties>
+    <components>
+      <vevent>
+        <properties>
+          <dtstamp><date-time>2008-02-05T19:12:24Z</date-time></dtstamp>
+          <dtstart><date>2008-10-06</date></dtstart>
+          <summary><text>Planning meeting</text></summary>
+          <uid><text>4088E990AD89CB3DBB484909</text></uid>
+          <attendee>
+            <parameters>
+              <rsvp><boolean>true</boolean></rsvp>
+            </parameters>
+            <cal-address>mailto:jane@example.com</cal-address>
+          </attendee>
+          <rrule>
+            <recur>
+              <freq>YEARLY</freq>
+              <count>5</count>
+              <byday>-1SU</byday>
+              <bymonth>10</bymonth>
+              <bymonth>11</bymonth>
+            </recur>
+          </rrule>
+        </properties>
+      </vevent>
+    </components>
+  </vcalendar>
+</icalendar>`
+
+func TestUnmarshalXCal(t *testing.T) {
+	cal, err := UnmarshalXCal([]byte(exampleXCalStr))
+	if err != nil {
+		t.Fatalf("UnmarshalXCal() = %v", err)
+	}
+
+	events := cal.Events()
+	if len(events) != 1 {
+		t.Fatalf("len(Events()) = %v, want 1", len(events))
+	}
+	props := events[0].Props
+
+	if prop := props.Get(PropDateTimeStart); prop.Value != "20081006" || prop.ValueType() != ValueDate {
+		t.Errorf("DTSTART = %+v", prop)
+	}
+	if prop := props.Get(PropRecurrenceRule); prop.Value != "FREQ=YEARLY;COUNT=5;BYDAY=-1SU;BYMONTH=10,11" {
+		t.Errorf("RRULE = %q", prop.Value)
+	}
+	if rsvp := props.Get(PropAttendee).Params.Get(ParamRSVP); rsvp != "TRUE" {
+		t.Errorf("RSVP = %q", rsvp)
+	}
+	if summary, _ := props.Text(PropSummary); summary != "Planning meeting" {
+		t.Errorf("SUMMARY = %q", summary)
+	}
+}
@@ -0,0 +1,458 @@
+// Package imip implements the iCalendar Message-Based Interoperability
+// Protocol (iMIP), used to exchange iTIP messages by email.
+//
+// iMIP is defined in RFC 6047.
+package imip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/itip"
+)
+
+// MIMEType is the media type of the iCalendar part of iMIP messages.
+const MIMEType = "text/calendar"
+
+var (
+	// ErrNoCalendar is returned by Decode when the email doesn't contain an
+	// iCalendar part.
+	ErrNoCalendar = errors.New("imip: no text/calendar part")
+	// ErrSenderMismatch is returned by VerifySender when the sender of an
+	// email isn't allowed to send the iTIP message it contains.
+	ErrSenderMismatch = errors.New("imip: sender doesn't match the iTIP message")
+)
+
+// Mail is an email carrying an iTIP message.
+type Mail struct {
+	From *mail.Address
+	To   []*mail.Address
+	// Subject defaults to a summary of the message, e.g. "Invitation: Lunch".
+	Subject string
+	// Text is the plain text version of the message shown by email clients
+	// which don't support iMIP. It defaults to a summary of the message.
+	Text      string
+	MessageID string
+	Date      time.Time
+	Message   *itip.Message
+}
+
+// Encode writes m as a MIME email to w.
+//
+// The email is a multipart/alternative message with a text/plain part and a
+// text/calendar part carrying the iTIP message, with the method parameter
+// required by RFC 6047 section 2.4.
+func Encode(w io.Writer, m *Mail) error {
+	if m.From == nil {
+		return fmt.Errorf("imip: missing sender")
+	}
+	if len(m.To) == 0 {
+		return fmt.Errorf("imip: missing recipients")
+	}
+	if m.Message == nil {
+		return fmt.Errorf("imip: missing iTIP message")
+	}
+	method := m.Message.Method()
+	if method == "" {
+		return fmt.Errorf("imip: iTIP message has no METHOD")
+	}
+
+	var cal bytes.Buffer
+	if err := ical.NewEncoder(&cal).Encode(m.Message.Calendar); err != nil {
+		return err
+	}
+
+	subject := m.Subject
+	if subject == "" {
+		subject = DefaultSubject(m.Message)
+	}
+	text := m.Text
+	if text == "" {
+		text = defaultText(m.Message)
+	}
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	messageID := m.MessageID
+	if messageID == "" {
+		messageID = newMessageID(m.From.Address)
+	}
+
+	to := make([]string, len(m.To))
+	for i, addr := range m.To {
+		to[i] = addr.String()
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	header := []struct{ key, value string }{
+		{"From", m.From.String()},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + messageID + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})},
+	}
+	for _, field := range header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", field.key, field.value)
+	}
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		data        []byte
+	}{
+		{mime.FormatMediaType("text/plain", map[string]string{"charset": "utf-8"}), []byte(text)},
+		{mime.FormatMediaType(MIMEType, map[string]string{"method": string(method), "charset": "utf-8"}), cal.Bytes()},
+	}
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write(part.data); err != nil {
+			return err
+		}
+		if err := qw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func newMessageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	return ical.NewUID() + "@" + domain
+}
+
+// Decode parses an email and extracts the iTIP message it carries.
+//
+// The first text/calendar part with a method parameter is used. If there's
+// none, the first text/calendar or application/ics part is used instead, since
+// some clients only attach the message as a file.
+func Decode(r io.Reader) (*Mail, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("imip: failed to parse email: %w", err)
+	}
+
+	m := new(Mail)
+	if from := msg.Header.Get("From"); from != "" {
+		if m.From, err = mail.ParseAddress(from); err != nil {
+			return nil, fmt.Errorf("imip: invalid From header: %w", err)
+		}
+	}
+	if msg.Header.Get("To") != "" {
+		if m.To, err = msg.Header.AddressList("To"); err != nil {
+			return nil, fmt.Errorf("imip: invalid To header: %w", err)
+		}
+	}
+	dec := new(mime.WordDecoder)
+	if m.Subject, err = dec.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		m.Subject = msg.Header.Get("Subject")
+	}
+	m.MessageID = strings.Trim(msg.Header.Get("Message-ID"), "<> ")
+	if date, err := msg.Header.Date(); err == nil {
+		m.Date = date
+	}
+
+	var found partSet
+	if err := found.walk(textproto.MIMEHeader(msg.Header), msg.Body); err != nil {
+		return nil, err
+	}
+	data := found.calendar
+	if data == nil {
+		data = found.fallback
+	}
+	if data == nil {
+		return nil, ErrNoCalendar
+	}
+	m.Text = found.text
+
+	cal, err := ical.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("imip: failed to parse calendar part: %w", err)
+	}
+	if m.Message, err = itip.ParseMessage(cal); err != nil {
+		return nil, err
+	}
+	if found.method != "" && !strings.EqualFold(found.method, string(m.Message.Method())) {
+		return nil, fmt.Errorf("imip: method parameter %q doesn't match METHOD %q", found.method, m.Message.Method())
+	}
+	return m, nil
+}
+
+// partSet collects the interesting parts of an email.
+type partSet struct {
+	calendar []byte
+	method   string
+	fallback []byte
+	text     string
+}
+
+func (ps *partSet) walk(header textproto.MIMEHeader, body io.Reader) error {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	t, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("imip: invalid Content-Type: %w", err)
+	}
+
+	if strings.HasPrefix(t, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("imip: failed to read MIME part: %w", err)
+			}
+			if err := ps.walk(p.Header, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	isCalendar := t == MIMEType || t == "application/ics"
+	if !isCalendar && t != "text/plain" {
+		return nil
+	}
+	// Only failures to decode the calendar part are fatal, other parts are
+	// skipped
+	if charset := strings.ToLower(params["charset"]); charset != "" && charset != "utf-8" && charset != "us-ascii" {
+		if isCalendar {
+			return fmt.Errorf("imip: unsupported charset %q", charset)
+		}
+		return nil
+	}
+	data, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		if isCalendar {
+			return fmt.Errorf("imip: failed to decode MIME part: %w", err)
+		}
+		return nil
+	}
+
+	switch {
+	case t == MIMEType && params["method"] != "" && ps.calendar == nil:
+		ps.calendar = data
+		ps.method = params["method"]
+	case isCalendar && ps.fallback == nil:
+		ps.fallback = data
+	case t == "text/plain" && ps.text == "":
+		ps.text = string(data)
+	}
+	return nil
+}
+
+func decodeTransferEncoding(enc string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(enc)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// newlineStripper removes line breaks from base64 data.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (ns *newlineStripper) Read(p []byte) (int, error) {
+	n, err := ns.r.Read(p)
+	j := 0
+	for _, c := range p[:n] {
+		if c != '\r' && c != '\n' && c != ' ' && c != '\t' {
+			p[j] = c
+			j++
+		}
+	}
+	return j, err
+}
+
+// Recipients returns the calendar user addresses an iTIP message must be sent
+// to: the organizer for messages sent by attendees (REPLY, COUNTER and
+// REFRESH), the attendees otherwise. Only "mailto:" addresses are returned,
+// and the sender is excluded.
+func Recipients(msg *itip.Message) []*mail.Address {
+	var props []*ical.Prop
+	switch msg.Method() {
+	case itip.MethodReply, itip.MethodCounter, itip.MethodRefresh:
+		for _, comp := range msg.Components() {
+			if prop := comp.Props.Get(ical.PropOrganizer); prop != nil {
+				props = append(props, prop)
+				break
+			}
+		}
+	default:
+		organizer := msg.Organizer()
+		for _, comp := range msg.Components() {
+			for _, prop := range comp.Props.Values(ical.PropAttendee) {
+				if !itip.SameAddress(prop.Value, organizer) {
+					props = append(props, prop)
+				}
+			}
+		}
+	}
+
+	var l []*mail.Address
+	seen := make(map[string]bool)
+	for _, prop := range props {
+		addr, ok := mailtoAddress(prop.Value)
+		if !ok || seen[strings.ToLower(addr)] {
+			continue
+		}
+		seen[strings.ToLower(addr)] = true
+		l = append(l, &mail.Address{Name: prop.Params.Get(ical.ParamCommonName), Address: addr})
+	}
+	return l
+}
+
+// mailtoAddress returns the email address of a "mailto:" calendar user
+// address.
+func mailtoAddress(calAddress string) (string, bool) {
+	calAddress = strings.TrimSpace(calAddress)
+	if len(calAddress) < len("mailto:") || !strings.EqualFold(calAddress[:len("mailto:")], "mailto:") {
+		return "", false
+	}
+	return calAddress[len("mailto:"):], true
+}
+
+// VerifySender checks that the sender of an email is allowed to send the iTIP
+// message it carries, as recommended by RFC 6047 section 3: the organizer
+// (or its SENT-BY delegate) for messages sent by organizers, an attendee for
+// REPLY, COUNTER and REFRESH.
+//
+// Email headers can be forged, so this check is only meaningful if the email
+// was authenticated, e.g. with DKIM.
+func VerifySender(m *Mail) error {
+	if m.From == nil {
+		return fmt.Errorf("%w: missing sender", ErrSenderMismatch)
+	}
+	from := "mailto:" + m.From.Address
+
+	var props []*ical.Prop
+	for _, comp := range m.Message.Components() {
+		switch m.Message.Method() {
+		case itip.MethodReply, itip.MethodCounter, itip.MethodRefresh:
+			props = append(props, comp.Props.Values(ical.PropAttendee)...)
+		default:
+			props = append(props, comp.Props.Values(ical.PropOrganizer)...)
+		}
+	}
+	for _, prop := range props {
+		if itip.SameAddress(prop.Value, from) {
+			return nil
+		}
+		sentBy := strings.Trim(prop.Params.Get(ical.ParamSentBy), `"`)
+		if sentBy != "" && itip.SameAddress(sentBy, from) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSenderMismatch, m.From.Address)
+}
+
+// DefaultSubject returns a subject line summarising an iTIP message, e.g.
+// "Invitation: Lunch" or "Accepted: Lunch".
+func DefaultSubject(msg *itip.Message) string {
+	var summary string
+	var comp *ical.Component
+	if comps := msg.Components(); len(comps) > 0 {
+		comp = comps[0]
+		summary, _ = comp.Props.Text(ical.PropSummary)
+	}
+	if summary == "" {
+		summary = "(no title)"
+	}
+
+	var prefix string
+	switch msg.Method() {
+	case itip.MethodRequest:
+		prefix = "Invitation"
+		if comp != nil && itip.Sequence(comp) > 0 {
+			prefix = "Updated invitation"
+		}
+	case itip.MethodReply:
+		prefix = "Reply"
+		if comp != nil {
+			if att := comp.Props.Get(ical.PropAttendee); att != nil {
+				switch itip.PartStat(strings.ToUpper(att.Params.Get(ical.ParamParticipationStatus))) {
+				case itip.PartStatAccepted:
+					prefix = "Accepted"
+				case itip.PartStatDeclined:
+					prefix = "Declined"
+				case itip.PartStatTentative:
+					prefix = "Tentatively accepted"
+				case itip.PartStatDelegated:
+					prefix = "Delegated"
+				}
+			}
+		}
+	case itip.MethodCancel:
+		prefix = "Cancelled"
+	case itip.MethodCounter:
+		prefix = "New time proposed"
+	case itip.MethodDeclineCounter:
+		prefix = "Proposal declined"
+	case itip.MethodRefresh:
+		prefix = "Update requested"
+	default:
+		prefix = "Event"
+	}
+	return prefix + ": " + summary
+}
+
+func defaultText(msg *itip.Message) string {
+	var sb strings.Builder
+	sb.WriteString(DefaultSubject(msg))
+	sb.WriteString("\r\n")
+	if comps := msg.Components(); len(comps) > 0 {
+		comp := comps[0]
+		if start := comp.Props.Get(ical.PropDateTimeStart); start != nil {
+			if t, err := start.DateTime(time.UTC); err == nil {
+				if start.IsDate() {
+					fmt.Fprintf(&sb, "\r\nWhen: %s\r\n", t.Format("Mon Jan 2, 2006"))
+				} else {
+					fmt.Fprintf(&sb, "\r\nWhen: %s\r\n", t.Format("Mon Jan 2, 2006 15:04 MST"))
+				}
+			}
+		}
+		if location, _ := comp.Props.Text(ical.PropLocation); location != "" {
+			fmt.Fprintf(&sb, "Where: %s\r\n", location)
+		}
+		if organizer := msg.Organizer(); organizer != "" {
+			fmt.Fprintf(&sb, "Organizer: %s\r\n", strings.TrimPrefix(organizer, "mailto:"))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package imip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+	"github.com/yinjun1991/caldav-client-go/itip"
+)
+
+const invitation = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+METHOD:REQUEST
+BEGIN:VEVENT
+UID:meeting-1
+DTSTAMP:20240101T000000Z
+DTSTART:20240102T100000Z
+DTEND:20240102T110000Z
+SUMMARY:Café planning
+LOCATION:Room 1
+ORGANIZER;CN=Alice:mailto:alice@example.com
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:alice@example.com
+ATTENDEE;CN=Bob;RSVP=TRUE:mailto:bob@example.com
+ATTENDEE;CUTYPE=ROOM:urn:uuid:room-1
+END:VEVENT
+END:VCALENDAR
+`
+
+func parseMessage(t *testing.T, s string) *itip.Message {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(s)).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	msg, err := itip.ParseMessage(cal)
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+	return msg
+}
+
+func TestEncodeDecode(t *testing.T) {
+	msg := parseMessage(t, invitation)
+
+	to := Recipients(msg)
+	if len(to) != 1 || to[0].Address != "bob@example.com" || to[0].Name != "Bob" {
+		t.Fatalf("unexpected recipients: %v", to)
+	}
+
+	var buf bytes.Buffer
+	err := Encode(&buf, &Mail{
+		From:    &mail.Address{Name: "Alice", Address: "alice@example.com"},
+		To:      to,
+		Message: msg,
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !strings.Contains(buf.String(), `Content-Type: text/calendar; charset=utf-8; method=REQUEST`) {
+		t.Errorf("missing text/calendar part with method parameter:\n%s", buf.String())
+	}
+
+	m, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if m.Subject != "Invitation: Café planning" {
+		t.Errorf("Subject = %q", m.Subject)
+	}
+	if m.From.Address != "alice@example.com" || len(m.To) != 1 || m.To[0].Address != "bob@example.com" {
+		t.Errorf("unexpected addresses: %v %v", m.From, m.To)
+	}
+	if !strings.Contains(m.Text, "Where: Room 1") {
+		t.Errorf("unexpected text: %q", m.Text)
+	}
+	if m.Message.Method() != itip.MethodRequest || m.Message.UID() != "meeting-1" {
+		t.Errorf("unexpected message: %v %v", m.Message.Method(), m.Message.UID())
+	}
+	if !m.Message.Equal(msg.Component) {
+		t.Errorf("message didn't round-trip")
+	}
+	if err := VerifySender(m); err != nil {
+		t.Errorf("VerifySender: %v", err)
+	}
+
+	m.From = &mail.Address{Address: "mallory@example.com"}
+	if err := VerifySender(m); !errors.Is(err, ErrSenderMismatch) {
+		t.Errorf("expected ErrSenderMismatch, got %v", err)
+	}
+}
+
+func TestDecodeAttachment(t *testing.T) {
+	// Some clients send the message as a base64 attachment in a
+	// multipart/mixed email
+	raw := "From: bob@example.com\r\n" +
+		"To: alice@example.com\r\n" +
+		"Subject: =?utf-8?q?Accepted=3A_Lunch?=\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Bob has accepted.\r\n" +
+		"--outer\r\n" +
+		"Content-Type: application/ics; name=invite.ics\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"QkVHSU46VkNBTEVOREFSDQpWRVJTSU9OOjIuMA0KTUVUSE9EOlJFUExZDQpCRUdJTjpWRVZFTlQN\r\n" +
+		"ClVJRDptZWV0aW5nLTENCkRUU1RBTVA6MjAyNDAxMDFUMDAwMDAwWg0KT1JHQU5JWkVSOm1haWx0\r\n" +
+		"bzphbGljZUBleGFtcGxlLmNvbQ0KQVRURU5ERUU7UEFSVFNUQVQ9QUNDRVBURUQ6bWFpbHRvOmJv\r\n" +
+		"YkBleGFtcGxlLmNvbQ0KRU5EOlZFVkVOVA0KRU5EOlZDQUxFTkRBUg0K\r\n" +
+		"--outer--\r\n"
+
+	m, err := Decode(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if m.Subject != "Accepted: Lunch" || m.Text != "Bob has accepted." {
+		t.Errorf("unexpected email: %q %q", m.Subject, m.Text)
+	}
+	if m.Message.Method() != itip.MethodReply {
+		t.Errorf("Method = %v, want REPLY", m.Message.Method())
+	}
+	if err := VerifySender(m); err != nil {
+		t.Errorf("VerifySender: %v", err)
+	}
+	if to := Recipients(m.Message); len(to) != 1 || to[0].Address != "alice@example.com" {
+		t.Errorf("unexpected reply recipients: %v", to)
+	}
+
+	// Text parts in other charsets are ignored
+	latin1 := strings.Replace(raw, "Content-Type: text/plain\r\n", "Content-Type: text/plain; charset=iso-8859-1\r\n", 1)
+	if m, err := Decode(strings.NewReader(latin1)); err != nil {
+		t.Errorf("Decode with an iso-8859-1 text part: %v", err)
+	} else if m.Text != "" || m.Message.Method() != itip.MethodReply {
+		t.Errorf("unexpected email: %q %v", m.Text, m.Message.Method())
+	}
+	latin1Cal := strings.Replace(raw, "Content-Type: application/ics;", "Content-Type: application/ics; charset=iso-8859-1;", 1)
+	if _, err := Decode(strings.NewReader(latin1Cal)); err == nil {
+		t.Errorf("expected Decode to fail with an iso-8859-1 calendar part")
+	}
+
+	noCal := "From: bob@example.com\r\nContent-Type: text/plain\r\n\r\nHello\r\n"
+	if _, err := Decode(strings.NewReader(noCal)); !errors.Is(err, ErrNoCalendar) {
+		t.Errorf("expected ErrNoCalendar, got %v", err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var buf bytes.Buffer
+	sender := &WriterSender{W: &buf}
+	err := Send(context.Background(), sender, &Mail{
+		From:    &mail.Address{Address: "alice@example.com"},
+		Message: parseMessage(t, invitation),
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	m, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(m.To) != 1 || m.To[0].Address != "bob@example.com" {
+		t.Errorf("unexpected recipients: %v", m.To)
+	}
+}
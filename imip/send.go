@@ -0,0 +1,140 @@
+package imip
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"sync"
+)
+
+// Sender delivers emails.
+type Sender interface {
+	// Send delivers the encoded email data from the envelope sender from to
+	// the envelope recipients to.
+	Send(ctx context.Context, from string, to []string, data []byte) error
+}
+
+// Send encodes m and delivers it with s. If m.To is empty, the recipients are
+// computed with Recipients.
+func Send(ctx context.Context, s Sender, m *Mail) error {
+	if m.Message == nil {
+		return fmt.Errorf("imip: missing iTIP message")
+	}
+	if len(m.To) == 0 {
+		mm := *m
+		mm.To = Recipients(m.Message)
+		m = &mm
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, m); err != nil {
+		return err
+	}
+	to := make([]string, len(m.To))
+	for i, addr := range m.To {
+		to[i] = addr.Address
+	}
+	return s.Send(ctx, m.From.Address, to, buf.Bytes())
+}
+
+// SMTPSender sends emails to an SMTP server. STARTTLS is used if the server
+// supports it.
+type SMTPSender struct {
+	// Addr is the address of the server, in the "host:port" form.
+	Addr string
+	// Auth is used to authenticate if non-nil, e.g. smtp.PlainAuth.
+	Auth smtp.Auth
+	// TLSConfig is used for STARTTLS. If nil, the host name of Addr is
+	// verified.
+	TLSConfig *tls.Config
+}
+
+var _ Sender = (*SMTPSender)(nil)
+
+// Send implements Sender.
+func (s *SMTPSender) Send(ctx context.Context, from string, to []string, data []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("imip: invalid SMTP server address: %w", err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		tlsConfig := s.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: host}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// WriterSender writes emails to an io.Writer instead of sending them, e.g. to
+// pipe them to sendmail or to inspect them in tests. Emails are separated by
+// an empty line.
+type WriterSender struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+var _ Sender = (*WriterSender)(nil)
+
+// Send implements Sender.
+func (s *WriterSender) Send(ctx context.Context, from string, to []string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.W.Write(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(s.W, "\r\n")
+	return err
+}
@@ -176,7 +176,7 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 				wrappedErr = fmt.Errorf("%v", s)
 			}
 		}
-		return nil, &HTTPError{Code: resp.StatusCode, Err: wrappedErr}
+		return nil, &HTTPError{Code: resp.StatusCode, Err: wrappedErr, Header: resp.Header}
 	}
 	return resp, nil
 }
@@ -23,6 +23,9 @@ var (
 	CurrentUserPrincipalName    = xml.Name{Namespace, "current-user-principal"}
 	SyncTokenName               = xml.Name{Namespace, "sync-token"}
 	CurrentUserPrivilegeSetName = xml.Name{Namespace, "current-user-privilege-set"}
+	LockDiscoveryName           = xml.Name{Namespace, "lockdiscovery"}
+	QuotaAvailableBytesName     = xml.Name{Namespace, "quota-available-bytes"}
+	QuotaUsedBytesName          = xml.Name{Namespace, "quota-used-bytes"}
 )
 
 type Status struct {
@@ -386,6 +389,16 @@ func (etag *ETag) UnmarshalText(b []byte) error {
 	return nil
 }
 
+// ParseETagHeader parses the ETag header h, ignoring the weak validator
+// prefix. It returns an empty string if the header is missing or malformed.
+func ParseETagHeader(h http.Header) string {
+	var etag ETag
+	if err := etag.UnmarshalText([]byte(strings.TrimPrefix(h.Get("ETag"), "W/"))); err != nil {
+		return ""
+	}
+	return string(etag)
+}
+
 func (etag ETag) MarshalText() ([]byte, error) {
 	return []byte(etag.String()), nil
 }
@@ -405,6 +418,30 @@ func (err *Error) Error() string {
 	return string(b)
 }
 
+// Conditions returns the names of the precondition and postcondition elements
+// contained in the error.
+func (err *Error) Conditions() []xml.Name {
+	var names []xml.Name
+	for i := range err.Raw {
+		if name, ok := err.Raw[i].XMLName(); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Get returns the precondition or postcondition element with the specified
+// name, or nil if the error doesn't contain it.
+func (err *Error) Get(name xml.Name) *RawXMLValue {
+	for i := range err.Raw {
+		raw := &err.Raw[i]
+		if n, ok := raw.XMLName(); ok && name == n {
+			return raw
+		}
+	}
+	return nil
+}
+
 // https://tools.ietf.org/html/rfc4918#section-15.2
 type DisplayName struct {
 	XMLName xml.Name `xml:"DAV: displayname"`
@@ -463,3 +500,104 @@ type Privilege struct {
 	XMLName xml.Name      `xml:"DAV: privilege"`
 	Raw     []RawXMLValue `xml:",any"`
 }
+
+// https://tools.ietf.org/html/rfc4918#section-14.11
+type LockInfo struct {
+	XMLName   xml.Name  `xml:"DAV: lockinfo"`
+	LockScope LockScope `xml:"lockscope"`
+	LockType  LockType  `xml:"locktype"`
+	Owner     *Owner    `xml:"owner,omitempty"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.13
+type LockScope struct {
+	XMLName   xml.Name  `xml:"DAV: lockscope"`
+	Exclusive *struct{} `xml:"exclusive,omitempty"`
+	Shared    *struct{} `xml:"shared,omitempty"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.15
+type LockType struct {
+	XMLName xml.Name  `xml:"DAV: locktype"`
+	Write   *struct{} `xml:"write,omitempty"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.17
+type Owner struct {
+	XMLName xml.Name `xml:"DAV: owner"`
+	Href    string   `xml:"href,omitempty"`
+	Text    string   `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-15.8
+type LockDiscovery struct {
+	XMLName     xml.Name     `xml:"DAV: lockdiscovery"`
+	ActiveLocks []ActiveLock `xml:"activelock"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.1
+type ActiveLock struct {
+	XMLName   xml.Name   `xml:"DAV: activelock"`
+	LockScope LockScope  `xml:"lockscope"`
+	LockType  LockType   `xml:"locktype"`
+	Depth     string     `xml:"depth"`
+	Owner     *Owner     `xml:"owner,omitempty"`
+	Timeout   string     `xml:"timeout,omitempty"`
+	LockToken *LockToken `xml:"locktoken,omitempty"`
+	LockRoot  *LockRoot  `xml:"lockroot,omitempty"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.14
+type LockToken struct {
+	XMLName xml.Name `xml:"DAV: locktoken"`
+	Href    string   `xml:"href"`
+}
+
+// https://tools.ietf.org/html/rfc4918#section-14.12
+type LockRoot struct {
+	XMLName xml.Name `xml:"DAV: lockroot"`
+	Href    Href     `xml:"href"`
+}
+
+// https://tools.ietf.org/html/rfc4331#section-3
+type QuotaAvailableBytes struct {
+	XMLName xml.Name `xml:"DAV: quota-available-bytes"`
+	Value   string   `xml:",chardata"`
+}
+
+// https://tools.ietf.org/html/rfc4331#section-4
+type QuotaUsedBytes struct {
+	XMLName xml.Name `xml:"DAV: quota-used-bytes"`
+	Value   string   `xml:",chardata"`
+}
+
+// DecodeQuota decodes the quota properties of a response. Values which are
+// missing, forbidden or invalid are returned as -1. ok is false if the
+// response contains neither of them.
+func (resp *Response) DecodeQuota() (used, available int64, ok bool) {
+	used, available = -1, -1
+
+	var usedBytes QuotaUsedBytes
+	if err := resp.DecodeProp(&usedBytes); err == nil {
+		ok = true
+		used = parseQuotaBytes(usedBytes.Value)
+	}
+
+	var availableBytes QuotaAvailableBytes
+	if err := resp.DecodeProp(&availableBytes); err == nil {
+		ok = true
+		available = parseQuotaBytes(availableBytes.Value)
+	}
+
+	return used, available, ok
+}
+
+// parseQuotaBytes parses a quota value. Some servers return empty or negative
+// values when the quota is unknown or unlimited.
+func parseQuotaBytes(s string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
@@ -68,9 +68,14 @@ func FormatOverwrite(overwrite bool) string {
 	}
 }
 
+// HTTPError is an error associated with an HTTP status code. Err is the
+// underlying cause, e.g. the DAV:error element returned by the server.
 type HTTPError struct {
 	Code int
 	Err  error
+	// Header contains the header of the response which caused the error, if
+	// any.
+	Header http.Header
 }
 
 func HTTPErrorFromError(err error) *HTTPError {
@@ -80,7 +85,7 @@ func HTTPErrorFromError(err error) *HTTPError {
 	if httpErr, ok := err.(*HTTPError); ok {
 		return httpErr
 	} else {
-		return &HTTPError{http.StatusInternalServerError, err}
+		return &HTTPError{Code: http.StatusInternalServerError, Err: err}
 	}
 }
 
@@ -93,7 +98,7 @@ func IsNotFound(err error) bool {
 }
 
 func HTTPErrorf(code int, format string, a ...interface{}) *HTTPError {
-	return &HTTPError{code, fmt.Errorf(format, a...)}
+	return &HTTPError{Code: code, Err: fmt.Errorf(format, a...)}
 }
 
 func (err *HTTPError) Error() string {
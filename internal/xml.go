@@ -25,6 +25,11 @@ func NewRawXMLElement(name xml.Name, attr []xml.Attr, children []RawXMLValue) *R
 	return &RawXMLValue{tok: xml.StartElement{name, attr}, children: children}
 }
 
+// NewRawXMLCharData creates a new RawXMLValue for character data.
+func NewRawXMLCharData(s string) *RawXMLValue {
+	return &RawXMLValue{tok: xml.CharData(s)}
+}
+
 // EncodeRawXMLElement encodes a value into a new RawXMLValue. The XML value
 // can only be used for marshalling.
 func EncodeRawXMLElement(v interface{}) (*RawXMLValue, error) {
@@ -0,0 +1,213 @@
+// Package itip implements the iCalendar Transport-Independent
+// Interoperability Protocol (iTIP), used to schedule events between calendar
+// users.
+//
+// iTIP is defined in RFC 5546.
+package itip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// Method is an iTIP method, as defined in RFC 5546 section 1.4.
+type Method string
+
+const (
+	MethodPublish        Method = "PUBLISH"
+	MethodRequest        Method = "REQUEST"
+	MethodReply          Method = "REPLY"
+	MethodAdd            Method = "ADD"
+	MethodCancel         Method = "CANCEL"
+	MethodRefresh        Method = "REFRESH"
+	MethodCounter        Method = "COUNTER"
+	MethodDeclineCounter Method = "DECLINECOUNTER"
+)
+
+// PartStat is the participation status of an attendee, as defined in RFC 5545
+// section 3.2.12.
+type PartStat string
+
+const (
+	PartStatNeedsAction PartStat = "NEEDS-ACTION"
+	PartStatAccepted    PartStat = "ACCEPTED"
+	PartStatDeclined    PartStat = "DECLINED"
+	PartStatTentative   PartStat = "TENTATIVE"
+	PartStatDelegated   PartStat = "DELEGATED"
+)
+
+// ParamReplyTimestamp is the ATTENDEE parameter recording the DTSTAMP of the
+// last REPLY applied for the attendee, so that replies received out of order
+// can be detected. It's the parameter used by CalendarServer.
+const ParamReplyTimestamp = "X-CALENDARSERVER-DTSTAMP"
+
+const productID = "-//caldav-client-go//itip//EN"
+
+var (
+	// ErrOutdated is returned when a message applies to an older version of
+	// the calendar object than the stored one.
+	ErrOutdated = errors.New("itip: outdated message")
+	// ErrUnknownAttendee is returned when a REPLY comes from a calendar user
+	// who isn't an attendee of the event.
+	ErrUnknownAttendee = errors.New("itip: unknown attendee")
+)
+
+// Message is an iTIP message: a VCALENDAR component with a METHOD property.
+type Message struct {
+	*ical.Calendar
+}
+
+// ParseMessage checks that cal is an iTIP message: it has a METHOD property,
+// and all its components other than VTIMEZONE have the same type and UID.
+func ParseMessage(cal *ical.Calendar) (*Message, error) {
+	msg := &Message{cal}
+	if msg.Method() == "" {
+		return nil, fmt.Errorf("itip: missing METHOD property")
+	}
+	comps := msg.Components()
+	if len(comps) == 0 {
+		return nil, fmt.Errorf("itip: message has no component")
+	}
+	uid, _ := comps[0].Props.Text(ical.PropUID)
+	if uid == "" {
+		return nil, fmt.Errorf("itip: missing UID property")
+	}
+	for _, comp := range comps[1:] {
+		if comp.Name != comps[0].Name {
+			return nil, fmt.Errorf("itip: message can't mix %s and %s components", comps[0].Name, comp.Name)
+		}
+		if compUID, _ := comp.Props.Text(ical.PropUID); compUID != uid {
+			return nil, fmt.Errorf("itip: message can't contain several UIDs")
+		}
+	}
+	return msg, nil
+}
+
+// Method returns the message's method.
+func (msg *Message) Method() Method {
+	method, _ := msg.Props.Text(ical.PropMethod)
+	return Method(strings.ToUpper(strings.TrimSpace(method)))
+}
+
+// UID returns the UID of the message's components.
+func (msg *Message) UID() string {
+	comps := msg.Components()
+	if len(comps) == 0 {
+		return ""
+	}
+	uid, _ := comps[0].Props.Text(ical.PropUID)
+	return uid
+}
+
+// Components returns the scheduled components of the message, i.e. all
+// components except VTIMEZONE.
+func (msg *Message) Components() []*ical.Component {
+	return scheduledComponents(msg.Calendar)
+}
+
+// Organizer returns the calendar user address of the organizer.
+func (msg *Message) Organizer() string {
+	for _, comp := range msg.Components() {
+		if prop := comp.Props.Get(ical.PropOrganizer); prop != nil {
+			return strings.TrimSpace(prop.Value)
+		}
+	}
+	return ""
+}
+
+func scheduledComponents(cal *ical.Calendar) []*ical.Component {
+	var l []*ical.Component
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompTimezone {
+			l = append(l, comp)
+		}
+	}
+	return l
+}
+
+// SameAddress reports whether two calendar user addresses are the same.
+// Addresses are compared case-insensitively, and the "mailto:" scheme is
+// optional.
+func SameAddress(a, b string) bool {
+	return normalizeAddress(a) == normalizeAddress(b)
+}
+
+func normalizeAddress(addr string) string {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	if !strings.Contains(addr, ":") {
+		addr = "mailto:" + addr
+	}
+	return addr
+}
+
+// FindAttendee returns the ATTENDEE property of comp with the specified
+// calendar user address, or nil.
+func FindAttendee(comp *ical.Component, address string) *ical.Prop {
+	for _, prop := range comp.Props.Values(ical.PropAttendee) {
+		if SameAddress(prop.Value, address) {
+			return prop
+		}
+	}
+	return nil
+}
+
+// SetPartStat sets the participation status of an attendee of comp.
+func SetPartStat(comp *ical.Component, attendee string, partstat PartStat) error {
+	att := FindAttendee(comp, attendee)
+	if att == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownAttendee, attendee)
+	}
+	att.Params.Set(ical.ParamParticipationStatus, string(partstat))
+	return nil
+}
+
+// Sequence returns the SEQUENCE of a component, zero if unset.
+func Sequence(comp *ical.Component) int {
+	prop := comp.Props.Get(ical.PropSequence)
+	if prop == nil {
+		return 0
+	}
+	seq, err := prop.Int()
+	if err != nil {
+		return 0
+	}
+	return seq
+}
+
+// IncrementSequence increments the SEQUENCE of a component. Organizers must
+// call it before sending a REQUEST or CANCEL for a significant change, such
+// as a new time or a cancellation (RFC 5546 section 2.1.4).
+func IncrementSequence(comp *ical.Component) {
+	prop := ical.NewProp(ical.PropSequence)
+	prop.SetInt(Sequence(comp) + 1)
+	comp.Props.Set(prop)
+}
+
+func timestamp(comp *ical.Component) time.Time {
+	prop := comp.Props.Get(ical.PropDateTimeStamp)
+	if prop == nil {
+		return time.Time{}
+	}
+	t, err := prop.DateTime(time.UTC)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Compare orders two versions of a component following RFC 5546 section
+// 2.1.5: by SEQUENCE, then by DTSTAMP. It returns -1 if a is older than b, 1
+// if a is newer than b and 0 if they can't be told apart.
+func Compare(a, b *ical.Component) int {
+	if seqA, seqB := Sequence(a), Sequence(b); seqA != seqB {
+		if seqA < seqB {
+			return -1
+		}
+		return 1
+	}
+	return timestamp(a).Compare(timestamp(b))
+}
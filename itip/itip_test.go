@@ -0,0 +1,199 @@
+package itip
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+const organizerCopy = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:meeting-1
+DTSTAMP:20240101T000000Z
+SEQUENCE:1
+DTSTART:20240102T100000Z
+DTEND:20240102T110000Z
+RRULE:FREQ=DAILY;COUNT=5
+SUMMARY:Standup
+ORGANIZER:mailto:alice@example.com
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:alice@example.com
+ATTENDEE;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:bob@example.com
+END:VEVENT
+END:VCALENDAR
+`
+
+func decodeCalendar(t *testing.T, s string) *ical.Calendar {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(s)).Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return cal
+}
+
+func partStat(t *testing.T, comp *ical.Component, attendee string) string {
+	t.Helper()
+	att := FindAttendee(comp, attendee)
+	if att == nil {
+		t.Fatalf("attendee %s not found", attendee)
+	}
+	return att.Params.Get(ical.ParamParticipationStatus)
+}
+
+func TestNewMessages(t *testing.T) {
+	cal := decodeCalendar(t, organizerCopy)
+
+	req, err := NewRequest(cal)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if req.Method() != MethodRequest || req.UID() != "meeting-1" || req.Organizer() != "mailto:alice@example.com" {
+		t.Errorf("unexpected request: %v %v %v", req.Method(), req.UID(), req.Organizer())
+	}
+	if stamp := req.Components()[0].Props.Get(ical.PropDateTimeStamp); stamp.Value == "20240101T000000Z" {
+		t.Errorf("DTSTAMP wasn't updated")
+	}
+	if cal.Props.Get(ical.PropMethod) != nil {
+		t.Errorf("NewRequest modified its argument")
+	}
+
+	if err := SetPartStat(cal.Children[0], "BOB@example.com", PartStatTentative); err != nil {
+		t.Fatalf("SetPartStat: %v", err)
+	}
+	reply, err := NewReply(cal, "mailto:bob@example.com", &ReplyOptions{Comment: "Might be late"})
+	if err != nil {
+		t.Fatalf("NewReply: %v", err)
+	}
+	comp := reply.Components()[0]
+	if atts := comp.Props.Values(ical.PropAttendee); len(atts) != 1 || atts[0].Params.Get(ical.ParamParticipationStatus) != "TENTATIVE" || atts[0].Params.Get(ical.ParamRSVP) != "" {
+		t.Errorf("unexpected reply attendees: %+v", atts)
+	}
+	if Sequence(comp) != 1 || comp.Props.Get(ical.PropRecurrenceRule) != nil {
+		t.Errorf("unexpected reply component: %+v", comp.Props)
+	}
+	if _, err := NewReply(cal, "mailto:carol@example.com", nil); !errors.Is(err, ErrUnknownAttendee) {
+		t.Errorf("NewReply for unknown attendee: got %v", err)
+	}
+
+	rid := ical.NewProp(ical.PropRecurrenceID)
+	rid.Value = "20240104T100000Z"
+	cancel, err := NewCancel(cal, &CancelOptions{RecurrenceIDs: []*ical.Prop{rid}})
+	if err != nil {
+		t.Fatalf("NewCancel: %v", err)
+	}
+	comp = cancel.Components()[0]
+	if cancel.Method() != MethodCancel || comp.Props.Get(ical.PropRecurrenceID).Value != rid.Value || len(comp.Props.Values(ical.PropAttendee)) != 2 {
+		t.Errorf("unexpected cancel component: %+v", comp.Props)
+	}
+	if status, _ := comp.Props.Text(ical.PropStatus); status != "CANCELLED" {
+		t.Errorf("unexpected cancel status %q", status)
+	}
+
+	refresh, err := NewRefresh(cal, "mailto:bob@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewRefresh: %v", err)
+	}
+	if refresh.Method() != MethodRefresh || len(refresh.Components()[0].Props.Values(ical.PropAttendee)) != 1 {
+		t.Errorf("unexpected refresh: %+v", refresh.Components()[0].Props)
+	}
+
+	counter, err := NewCounter(cal, "mailto:bob@example.com")
+	if err != nil {
+		t.Fatalf("NewCounter: %v", err)
+	}
+	if _, err := ParseMessage(counter.Calendar); err != nil {
+		t.Errorf("ParseMessage: %v", err)
+	}
+}
+
+func TestApplyReply(t *testing.T) {
+	reply := func(seq, stamp, rid, partstat string) *Message {
+		s := "BEGIN:VCALENDAR\nVERSION:2.0\nMETHOD:REPLY\nBEGIN:VEVENT\nUID:meeting-1\n" +
+			"SEQUENCE:" + seq + "\nDTSTAMP:" + stamp + "\n"
+		if rid != "" {
+			s += "RECURRENCE-ID:" + rid + "\n"
+		}
+		s += "ORGANIZER:mailto:alice@example.com\n" +
+			"ATTENDEE;PARTSTAT=" + partstat + ":mailto:Bob@example.com\n" +
+			"END:VEVENT\nEND:VCALENDAR\n"
+		msg, err := ParseMessage(decodeCalendar(t, s))
+		if err != nil {
+			t.Fatalf("ParseMessage: %v", err)
+		}
+		return msg
+	}
+
+	stored := decodeCalendar(t, organizerCopy)
+	if err := ApplyReply(stored, reply("1", "20240101T100000Z", "", "ACCEPTED")); err != nil {
+		t.Fatalf("ApplyReply: %v", err)
+	}
+	master := stored.Children[0]
+	if got := partStat(t, master, "mailto:bob@example.com"); got != "ACCEPTED" {
+		t.Errorf("PARTSTAT = %q, want ACCEPTED", got)
+	}
+	if got := FindAttendee(master, "mailto:bob@example.com").Params.Get(ParamReplyTimestamp); got != "20240101T100000Z" {
+		t.Errorf("reply timestamp = %q", got)
+	}
+
+	// A reply sent before the one already applied is ignored
+	if err := ApplyReply(stored, reply("1", "20240101T090000Z", "", "DECLINED")); !errors.Is(err, ErrOutdated) {
+		t.Errorf("expected ErrOutdated for an older DTSTAMP, got %v", err)
+	}
+	// A reply to an older version of the event is ignored
+	if err := ApplyReply(stored, reply("0", "20240101T110000Z", "", "DECLINED")); !errors.Is(err, ErrOutdated) {
+		t.Errorf("expected ErrOutdated for an older SEQUENCE, got %v", err)
+	}
+	if got := partStat(t, master, "mailto:bob@example.com"); got != "ACCEPTED" {
+		t.Errorf("outdated replies changed PARTSTAT to %q", got)
+	}
+
+	// A reply to a single occurrence creates an override
+	if err := ApplyReply(stored, reply("1", "20240101T120000Z", "20240103T100000Z", "DECLINED")); err != nil {
+		t.Fatalf("ApplyReply: %v", err)
+	}
+	if len(stored.Children) != 2 {
+		t.Fatalf("expected an override to be created, got %d components", len(stored.Children))
+	}
+	override := stored.Children[1]
+	if got := partStat(t, override, "mailto:bob@example.com"); got != "DECLINED" {
+		t.Errorf("override PARTSTAT = %q, want DECLINED", got)
+	}
+	if got := partStat(t, master, "mailto:bob@example.com"); got != "ACCEPTED" {
+		t.Errorf("master PARTSTAT = %q, want ACCEPTED", got)
+	}
+	if override.Props.Get(ical.PropRecurrenceRule) != nil ||
+		override.Props.Get(ical.PropDateTimeStart).Value != "20240103T100000Z" ||
+		override.Props.Get(ical.PropDateTimeEnd).Value != "20240103T110000Z" {
+		t.Errorf("unexpected override: %+v", override.Props)
+	}
+
+	unknown := reply("1", "20240101T130000Z", "", "ACCEPTED")
+	unknown.Children[0].Props.Get(ical.PropAttendee).Value = "mailto:mallory@example.com"
+	before := stored.Clone()
+	if err := ApplyReply(stored, unknown); !errors.Is(err, ErrUnknownAttendee) {
+		t.Errorf("expected ErrUnknownAttendee, got %v", err)
+	}
+	if !stored.Equal(before) {
+		t.Errorf("failed ApplyReply modified the calendar")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := decodeCalendar(t, organizerCopy).Children[0]
+	b := a.Clone()
+	if Compare(a, b) != 0 {
+		t.Errorf("expected equal components")
+	}
+	b.Props.Get(ical.PropDateTimeStamp).Value = "20240102T000000Z"
+	if Compare(a, b) != -1 || Compare(b, a) != 1 {
+		t.Errorf("DTSTAMP ordering is wrong")
+	}
+	IncrementSequence(a)
+	if Sequence(a) != 2 || Compare(a, b) != 1 {
+		t.Errorf("SEQUENCE should take precedence over DTSTAMP")
+	}
+}
@@ -0,0 +1,258 @@
+package itip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// newMessage creates an empty message with the specified method. The
+// VTIMEZONE components of cal are copied, since the message's components may
+// refer to them.
+func newMessage(method Method, cal *ical.Calendar) *Message {
+	msg := &Message{ical.NewCalendar()}
+	msg.Props.SetText(ical.PropProductID, productID)
+	msg.Props.SetText(ical.PropVersion, "2.0")
+	if calScale := cal.Props.Get(ical.PropCalendarScale); calScale != nil {
+		msg.Props.Set(calScale.Clone())
+	}
+	msg.Props.SetText(ical.PropMethod, string(method))
+	for _, tz := range cal.ChildrenByName(ical.CompTimezone) {
+		msg.Children = append(msg.Children, tz.Clone())
+	}
+	return msg
+}
+
+func setTimestamp(comp *ical.Component, now time.Time) {
+	prop := ical.NewProp(ical.PropDateTimeStamp)
+	prop.SetDateTime(now.UTC())
+	comp.Props.Set(prop)
+}
+
+// copyProps copies the properties with the specified names from src to dst.
+func copyProps(dst, src *ical.Component, names ...string) {
+	for _, name := range names {
+		for _, prop := range src.Props.Values(name) {
+			dst.Props.Add(prop.Clone())
+		}
+	}
+}
+
+// NewRequest builds a REQUEST message from the organizer's copy of a calendar
+// object, to invite the attendees or send them an update.
+//
+// All components must have an ORGANIZER and at least one ATTENDEE. The
+// DTSTAMP of the components is set to the current time. Use
+// IncrementSequence on the stored object first if the update is significant.
+func NewRequest(cal *ical.Calendar) (*Message, error) {
+	comps := scheduledComponents(cal)
+	if len(comps) == 0 {
+		return nil, fmt.Errorf("itip: calendar has no component to schedule")
+	}
+
+	msg := newMessage(MethodRequest, cal)
+	now := time.Now()
+	for _, comp := range comps {
+		if comp.Props.Get(ical.PropOrganizer) == nil {
+			return nil, fmt.Errorf("itip: %s has no ORGANIZER", comp.Name)
+		}
+		if comp.Props.Get(ical.PropAttendee) == nil {
+			return nil, fmt.Errorf("itip: %s has no ATTENDEE", comp.Name)
+		}
+		comp = comp.Clone()
+		setTimestamp(comp, now)
+		msg.Children = append(msg.Children, comp)
+	}
+	return msg, nil
+}
+
+// CancelOptions contains options for NewCancel.
+type CancelOptions struct {
+	// RecurrenceIDs restricts the cancellation to some occurrences of a
+	// recurring event. The values must have the same format as DTSTART.
+	RecurrenceIDs []*ical.Prop
+	// Attendees restricts the cancellation to some attendees, e.g. to notify
+	// attendees removed from the event. The message then only lists these
+	// attendees.
+	Attendees []string
+}
+
+// NewCancel builds a CANCEL message from the organizer's copy of a calendar
+// object, to cancel the event or some of its occurrences.
+//
+// Cancelling is a significant change: the organizer should increment the
+// SEQUENCE of the stored object with IncrementSequence beforehand.
+func NewCancel(cal *ical.Calendar, opts *CancelOptions) (*Message, error) {
+	if opts == nil {
+		opts = new(CancelOptions)
+	}
+
+	comps := scheduledComponents(cal)
+	if len(comps) == 0 {
+		return nil, fmt.Errorf("itip: calendar has no component to cancel")
+	}
+	master := cal.Master()
+
+	var targets []*ical.Component
+	if len(opts.RecurrenceIDs) == 0 {
+		targets = comps
+	} else {
+		for _, rid := range opts.RecurrenceIDs {
+			target := cal.Override(rid)
+			if target == nil {
+				if master == nil {
+					return nil, fmt.Errorf("itip: no occurrence with RECURRENCE-ID %s", rid.Value)
+				}
+				rid = rid.Clone()
+				rid.Name = ical.PropRecurrenceID
+				target = master.Clone()
+				target.Props.Set(rid)
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	msg := newMessage(MethodCancel, cal)
+	now := time.Now()
+	for _, target := range targets {
+		if target.Props.Get(ical.PropOrganizer) == nil {
+			return nil, fmt.Errorf("itip: %s has no ORGANIZER", target.Name)
+		}
+
+		comp := ical.NewComponent(target.Name)
+		copyProps(comp, target, ical.PropUID, ical.PropRecurrenceID, ical.PropSequence, ical.PropOrganizer, ical.PropSummary)
+		if len(opts.Attendees) == 0 {
+			copyProps(comp, target, ical.PropAttendee)
+		} else {
+			for _, addr := range opts.Attendees {
+				if att := FindAttendee(target, addr); att != nil {
+					comp.Props.Add(att.Clone())
+				} else {
+					return nil, fmt.Errorf("%w: %s", ErrUnknownAttendee, addr)
+				}
+			}
+		}
+		setTimestamp(comp, now)
+		comp.Props.SetText(ical.PropStatus, "CANCELLED")
+		msg.Children = append(msg.Children, comp)
+	}
+	return msg, nil
+}
+
+// ReplyOptions contains options for NewReply.
+type ReplyOptions struct {
+	// RecurrenceIDs restricts the reply to some overridden occurrences,
+	// identified by their RECURRENCE-ID. A nil value stands for the master
+	// component.
+	RecurrenceIDs []*ical.Prop
+	// Comment is an optional note to the organizer.
+	Comment string
+}
+
+// NewReply builds a REPLY message from an attendee's copy of a calendar
+// object, reporting the attendee's current participation status to the
+// organizer. Update the PARTSTAT of the attendee with SetPartStat first.
+//
+// By default, the reply covers all components the attendee is invited to.
+func NewReply(cal *ical.Calendar, attendee string, opts *ReplyOptions) (*Message, error) {
+	if opts == nil {
+		opts = new(ReplyOptions)
+	}
+
+	var targets []*ical.Component
+	if len(opts.RecurrenceIDs) == 0 {
+		for _, comp := range scheduledComponents(cal) {
+			if FindAttendee(comp, attendee) != nil {
+				targets = append(targets, comp)
+			}
+		}
+	} else {
+		for _, rid := range opts.RecurrenceIDs {
+			target := cal.Override(rid)
+			if target == nil {
+				return nil, fmt.Errorf("itip: no component with RECURRENCE-ID %s", ridString(rid))
+			}
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAttendee, attendee)
+	}
+
+	msg := newMessage(MethodReply, cal)
+	now := time.Now()
+	for _, target := range targets {
+		att := FindAttendee(target, attendee)
+		if att == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttendee, attendee)
+		}
+		if target.Props.Get(ical.PropOrganizer) == nil {
+			return nil, fmt.Errorf("itip: %s has no ORGANIZER", target.Name)
+		}
+
+		comp := ical.NewComponent(target.Name)
+		copyProps(comp, target, ical.PropUID, ical.PropRecurrenceID, ical.PropSequence, ical.PropOrganizer,
+			ical.PropDateTimeStart, ical.PropDateTimeEnd, ical.PropDuration, ical.PropDue, ical.PropSummary)
+		att = att.Clone()
+		att.Params.Del(ical.ParamRSVP)
+		comp.Props.Add(att)
+		setTimestamp(comp, now)
+		if opts.Comment != "" {
+			comp.Props.SetText(ical.PropComment, opts.Comment)
+		}
+		msg.Children = append(msg.Children, comp)
+	}
+	return msg, nil
+}
+
+func ridString(rid *ical.Prop) string {
+	if rid == nil {
+		return "(master)"
+	}
+	return rid.Value
+}
+
+// NewCounter builds a COUNTER message, with which an attendee proposes
+// changes to an event, e.g. a new time. proposed is the attendee's copy of the
+// calendar object with the proposed changes applied.
+func NewCounter(proposed *ical.Calendar, attendee string) (*Message, error) {
+	comps := scheduledComponents(proposed)
+	if len(comps) == 0 {
+		return nil, fmt.Errorf("itip: calendar has no component to counter")
+	}
+
+	msg := newMessage(MethodCounter, proposed)
+	now := time.Now()
+	for _, comp := range comps {
+		if FindAttendee(comp, attendee) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttendee, attendee)
+		}
+		comp = comp.Clone()
+		setTimestamp(comp, now)
+		msg.Children = append(msg.Children, comp)
+	}
+	return msg, nil
+}
+
+// NewRefresh builds a REFRESH message, with which an attendee asks the
+// organizer for the latest version of an event. rid is the RECURRENCE-ID of
+// an overridden occurrence, or nil for the whole event.
+func NewRefresh(cal *ical.Calendar, attendee string, rid *ical.Prop) (*Message, error) {
+	target := cal.Override(rid)
+	if target == nil {
+		return nil, fmt.Errorf("itip: no component with RECURRENCE-ID %s", ridString(rid))
+	}
+	att := FindAttendee(target, attendee)
+	if att == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAttendee, attendee)
+	}
+
+	msg := newMessage(MethodRefresh, cal)
+	comp := ical.NewComponent(target.Name)
+	copyProps(comp, target, ical.PropUID, ical.PropRecurrenceID, ical.PropOrganizer)
+	comp.Props.Add(att.Clone())
+	setTimestamp(comp, time.Now())
+	msg.Children = append(msg.Children, comp)
+	return msg, nil
+}
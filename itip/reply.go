@@ -0,0 +1,126 @@
+package itip
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yinjun1991/caldav-client-go/ical"
+)
+
+// ApplyReply applies a REPLY message to the organizer's copy of a calendar
+// object, updating the participation status of the replying attendees. It
+// can be used as the mutation of caldav.Client.UpdateCalendarObject, which
+// writes the object back with If-Match.
+//
+// Replies are ordered following RFC 5546 section 2.1.5: a reply with a lower
+// SEQUENCE than the stored component refers to an outdated version of the
+// event, and a reply with an older DTSTAMP than the last reply applied for
+// the attendee was superseded. Such replies are ignored, and ErrOutdated is
+// returned if no part of the message could be applied. The DTSTAMP of the
+// applied replies is recorded in the ParamReplyTimestamp parameter.
+//
+// A reply for an occurrence of a recurring event which isn't overridden yet
+// creates an overridden component from the master component. A reply for
+// the whole event only updates the master component. stored isn't modified
+// if an error is returned.
+func ApplyReply(stored *ical.Calendar, reply *Message) error {
+	if method := reply.Method(); method != MethodReply {
+		return fmt.Errorf("itip: expected a %s message, got %s", MethodReply, method)
+	}
+	uid := reply.UID()
+	if comps := scheduledComponents(stored); len(comps) == 0 {
+		return fmt.Errorf("itip: calendar object has no component")
+	} else if storedUID, _ := comps[0].Props.Text(ical.PropUID); storedUID != uid {
+		return fmt.Errorf("itip: reply is for UID %q, calendar object has UID %q", uid, storedUID)
+	}
+
+	updated := &ical.Calendar{Component: stored.Clone()}
+	applied := false
+	for _, comp := range reply.Components() {
+		rid := comp.Props.Get(ical.PropRecurrenceID)
+		target := updated.Override(rid)
+		if target == nil && rid != nil {
+			master := updated.Master()
+			if master == nil {
+				return fmt.Errorf("itip: no occurrence with RECURRENCE-ID %s", rid.Value)
+			}
+			target = ical.NewOverride(master, rid)
+			updated.Children = append(updated.Children, target)
+		} else if target == nil {
+			return fmt.Errorf("itip: calendar object has no master component")
+		}
+
+		if Sequence(comp) < Sequence(target) {
+			continue
+		}
+
+		ok, err := applyReplyComponent(target, comp)
+		if err != nil {
+			return err
+		}
+		applied = applied || ok
+	}
+	if !applied {
+		return ErrOutdated
+	}
+
+	stored.Props = updated.Props
+	stored.Children = updated.Children
+	return nil
+}
+
+// applyReplyComponent applies the attendees of a reply component to the
+// stored component. It returns false if all attendees were superseded.
+func applyReplyComponent(target, comp *ical.Component) (bool, error) {
+	attendees := comp.Props.Values(ical.PropAttendee)
+	if len(attendees) == 0 {
+		return false, fmt.Errorf("itip: reply has no ATTENDEE")
+	}
+
+	stamp := comp.Props.Get(ical.PropDateTimeStamp)
+	var stampTime time.Time
+	if stamp != nil {
+		stampTime, _ = stamp.DateTime(time.UTC)
+	}
+
+	applied := false
+	for _, att := range attendees {
+		existing := FindAttendee(target, att.Value)
+		if existing == nil {
+			// Delegates are added by the replies of the delegators
+			if len(att.Params.Values(ical.ParamDelegatedFrom)) == 0 {
+				return false, fmt.Errorf("%w: %s", ErrUnknownAttendee, att.Value)
+			}
+			existing = ical.NewProp(ical.PropAttendee)
+			existing.Value = att.Value
+			target.Props.Add(existing)
+		}
+
+		if last := existing.Params.Get(ParamReplyTimestamp); last != "" && !stampTime.IsZero() {
+			lastProp := &ical.Prop{Name: ical.PropDateTimeStamp, Params: make(ical.Params), Value: last}
+			if lastTime, err := lastProp.DateTime(time.UTC); err == nil && !stampTime.After(lastTime) {
+				continue
+			}
+		}
+
+		partstat := strings.ToUpper(att.Params.Get(ical.ParamParticipationStatus))
+		if partstat == "" {
+			partstat = string(PartStatNeedsAction)
+		}
+		existing.Params.Set(ical.ParamParticipationStatus, partstat)
+		for _, name := range []string{ical.ParamDelegatedTo, ical.ParamDelegatedFrom} {
+			if values := att.Params.Values(name); len(values) > 0 {
+				existing.Params[name] = append([]string(nil), values...)
+			}
+		}
+		if cn := att.Params.Get(ical.ParamCommonName); cn != "" && existing.Params.Get(ical.ParamCommonName) == "" {
+			existing.Params.Set(ical.ParamCommonName, cn)
+		}
+		if stamp != nil {
+			existing.Params.Set(ParamReplyTimestamp, strings.TrimSpace(stamp.Value))
+		}
+		applied = true
+	}
+	return applied, nil
+}
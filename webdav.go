@@ -4,6 +4,10 @@
 package webdav
 
 import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/yinjun1991/caldav-client-go/internal"
@@ -17,25 +21,88 @@ type FileInfo struct {
 	IsDir    bool
 	MIMEType string
 	ETag     string
+	// Quota is nil if the server doesn't report quota for the file, or if it
+	// wasn't requested, see StatOptions and ReadDirOptions.
+	Quota *Quota
+}
+
+// QuotaUnknown is the value of a Quota field the server didn't report.
+const QuotaUnknown int64 = -1
+
+// Quota describes the storage quota of a collection, as defined in RFC 4331.
+type Quota struct {
+	// Used is the number of bytes used by the collection, or QuotaUnknown.
+	Used int64
+	// Available is the number of bytes still available, or QuotaUnknown if
+	// the server didn't report it, e.g. because storage is unlimited.
+	Available int64
+}
+
+// Total returns the total storage size, or QuotaUnknown if the server didn't
+// report both the used and available bytes.
+func (q *Quota) Total() int64 {
+	if q.Used == QuotaUnknown || q.Available == QuotaUnknown {
+		return QuotaUnknown
+	}
+	return q.Used + q.Available
+}
+
+// StatOptions contains options for StatWithOptions
+type StatOptions struct {
+	// Quota requests the quota of the file, reported in FileInfo.Quota. Some
+	// servers are slow to compute it.
+	Quota bool
+}
+
+// ReadDirOptions contains options for ReadDirWithOptions
+type ReadDirOptions struct {
+	// Quota requests the quota of the files, reported in FileInfo.Quota. Some
+	// servers are slow to compute it.
+	Quota bool
 }
 
 type CreateOptions struct {
 	IfMatch     ConditionalMatch
 	IfNoneMatch ConditionalMatch
+	// If submits lock tokens and other state conditions, see IfHeader.
+	If IfHeader
 }
 
 type RemoveAllOptions struct {
 	IfMatch     ConditionalMatch
 	IfNoneMatch ConditionalMatch
+	// If submits lock tokens and other state conditions, see IfHeader.
+	If IfHeader
+}
+
+type MkdirOptions struct {
+	IfMatch     ConditionalMatch
+	IfNoneMatch ConditionalMatch
+	// If submits lock tokens and other state conditions, see IfHeader.
+	If IfHeader
 }
 
 type CopyOptions struct {
 	NoRecursive bool
 	NoOverwrite bool
+
+	// IfMatch and IfNoneMatch are evaluated against the source file.
+	IfMatch     ConditionalMatch
+	IfNoneMatch ConditionalMatch
+	// If submits lock tokens and other state conditions, see IfHeader. The
+	// lock tokens for the destination must be submitted in a tagged list.
+	If IfHeader
 }
 
 type MoveOptions struct {
 	NoOverwrite bool
+
+	// IfMatch and IfNoneMatch are evaluated against the source file.
+	IfMatch     ConditionalMatch
+	IfNoneMatch ConditionalMatch
+	// If submits lock tokens and other state conditions, see IfHeader. The
+	// lock tokens for the destination must be submitted in a tagged list.
+	If IfHeader
 }
 
 // ConditionalMatch represents the value of a conditional header
@@ -43,6 +110,16 @@ type MoveOptions struct {
 // The (optional) value can either be a wildcard or an ETag.
 type ConditionalMatch string
 
+// MatchAny is a ConditionalMatch matching any existing file. When used as
+// IfNoneMatch, it prevents overwriting an existing file.
+const MatchAny ConditionalMatch = "*"
+
+// ETagMatch returns a ConditionalMatch matching the specified ETag, as
+// returned in FileInfo.ETag.
+func ETagMatch(etag string) ConditionalMatch {
+	return ConditionalMatch(internal.ETag(etag).String())
+}
+
 func (val ConditionalMatch) IsSet() bool {
 	return val != ""
 }
@@ -69,3 +146,258 @@ func (val ConditionalMatch) MatchETag(etag string) (bool, error) {
 	t, err := val.ETag()
 	return t == etag, err
 }
+
+// LockScope is the scope of a lock, defined in RFC 4918 section 6.1.
+type LockScope int
+
+const (
+	// LockExclusive prevents other principals from locking the resource.
+	LockExclusive LockScope = iota
+	// LockShared allows other principals to hold shared locks on the
+	// resource.
+	LockShared
+)
+
+// InfiniteLockTimeout requests a lock which doesn't expire.
+const InfiniteLockTimeout time.Duration = -1
+
+// LockToken is a lock token URI, such as "opaquelocktoken:…" or "urn:uuid:…".
+type LockToken string
+
+// Lock describes an active write lock on a resource.
+type Lock struct {
+	Token LockToken
+	// Root is the path of the resource the lock was taken on.
+	Root  string
+	Scope LockScope
+	// Recursive is true if the lock applies to all descendants of a
+	// collection.
+	Recursive bool
+	// Timeout is the remaining lifetime of the lock, InfiniteLockTimeout if
+	// the lock doesn't expire or zero if the server didn't report it.
+	Timeout time.Duration
+	Owner   string
+}
+
+// IfList returns a list submitting the lock token, tagged with the lock root.
+func (l *Lock) IfList() IfList {
+	return IfList{
+		Resource:   l.Root,
+		Conditions: []IfCondition{{Token: l.Token}},
+	}
+}
+
+type LockOptions struct {
+	Scope LockScope
+	// NoRecursive locks only a collection itself, instead of the collection
+	// and all of its descendants.
+	NoRecursive bool
+	// Timeout is the requested lifetime of the lock. Zero lets the server
+	// pick the timeout. Servers may grant a shorter timeout.
+	Timeout time.Duration
+	// Owner describes the principal taking the lock, e.g. a URL or a name.
+	Owner string
+	// If submits other lock tokens, e.g. the lock token of a locked parent
+	// collection when creating a lock-null resource.
+	If IfHeader
+}
+
+// IfCondition is a condition of an If header list, defined in RFC 4918
+// section 10.4. Exactly one of Token and ETag must be set.
+type IfCondition struct {
+	// Not negates the condition.
+	Not   bool
+	Token LockToken
+	// ETag is an unquoted entity tag, as in FileInfo.ETag.
+	ETag string
+}
+
+func (cond IfCondition) String() string {
+	var s string
+	if cond.Token != "" {
+		s = "<" + string(cond.Token) + ">"
+	} else {
+		s = "[" + internal.ETag(cond.ETag).String() + "]"
+	}
+	if cond.Not {
+		s = "Not " + s
+	}
+	return s
+}
+
+// IfList is a list of conditions in an If header, which is met if all of its
+// conditions are met.
+type IfList struct {
+	// Resource is the absolute path or URL of the resource the list applies
+	// to, for a tagged list. It's empty for an untagged list, which applies
+	// to the request URL.
+	Resource   string
+	Conditions []IfCondition
+}
+
+// IfHeader is an If header, defined in RFC 4918 section 10.4. It's met if any
+// of its lists is met, and can be used to submit lock tokens of resources
+// affected by a request.
+//
+// A header can't mix tagged and untagged lists.
+type IfHeader []IfList
+
+// String formats the header value. It returns an empty string if the header
+// is invalid, see Format.
+func (h IfHeader) String() string {
+	s, _ := h.Format()
+	return s
+}
+
+// Format formats the header value. It fails if the header mixes tagged and
+// untagged lists.
+func (h IfHeader) Format() (string, error) {
+	for i := 1; i < len(h); i++ {
+		if (h[i].Resource == "") != (h[0].Resource == "") {
+			return "", errors.New("webdav: If header can't mix tagged and untagged lists")
+		}
+	}
+
+	var sb strings.Builder
+	var resource string
+	for i, l := range h {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		if l.Resource != "" && (i == 0 || l.Resource != resource) {
+			sb.WriteString("<" + l.Resource + "> ")
+		}
+		resource = l.Resource
+
+		sb.WriteString("(")
+		for j, cond := range l.Conditions {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(cond.String())
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), nil
+}
+
+// Depth indicates whether a request applies to a collection's members. It's
+// defined in RFC 4918 section 10.2.
+type Depth = internal.Depth
+
+const (
+	// DepthZero indicates that the request applies only to the resource.
+	DepthZero = internal.DepthZero
+	// DepthOne indicates that the request applies to the resource and its
+	// internal members only.
+	DepthOne = internal.DepthOne
+	// DepthInfinity indicates that the request applies to the resource and
+	// all of its members.
+	DepthInfinity = internal.DepthInfinity
+)
+
+// RawXMLValue is a raw XML property value. It can be decoded into a struct
+// with its Decode method.
+type RawXMLValue = internal.RawXMLValue
+
+// NewRawXMLElement creates a new RawXMLValue for an element.
+func NewRawXMLElement(name xml.Name, attr []xml.Attr, children []RawXMLValue) *RawXMLValue {
+	return internal.NewRawXMLElement(name, attr, children)
+}
+
+// NewTextProperty creates a new property value containing text.
+func NewTextProperty(name xml.Name, text string) *RawXMLValue {
+	return internal.NewRawXMLElement(name, nil, []RawXMLValue{*internal.NewRawXMLCharData(text)})
+}
+
+// EncodeProperty creates a new property value by encoding v, which must be
+// a struct with an XMLName field.
+func EncodeProperty(v interface{}) (*RawXMLValue, error) {
+	return internal.EncodeRawXMLElement(v)
+}
+
+// Property is a property of a resource, along with the status the server
+// reported for it.
+type Property struct {
+	Name xml.Name
+	// Status is the HTTP status code reported for the property, e.g. 200 if
+	// it was found, 404 if the resource doesn't have it or 403 if the user
+	// isn't allowed to access or modify it.
+	Status int
+	// Value is the property value. It's only set for properties returned by
+	// PropFind with a successful status.
+	Value *RawXMLValue
+}
+
+// Err returns an HTTPError if the server reported a failure for the
+// property.
+func (p *Property) Err() error {
+	if p.Status/100 == 2 {
+		return nil
+	}
+	return &HTTPError{Code: p.Status}
+}
+
+// Text returns the text content of the property value.
+func (p *Property) Text() (string, error) {
+	if err := p.Err(); err != nil {
+		return "", err
+	}
+	if p.Value == nil {
+		return "", nil
+	}
+	var v struct {
+		Text string `xml:",chardata"`
+	}
+	if err := p.Value.Decode(&v); err != nil {
+		return "", err
+	}
+	return v.Text, nil
+}
+
+// PropFindResponse contains the properties of a single resource returned by
+// PropFind.
+type PropFindResponse struct {
+	Path string
+	// Props contains the requested properties, in the order returned by the
+	// server.
+	Props []Property
+	// Err is set if the server reported a failure for the whole resource.
+	Err error
+}
+
+// Get returns the property with the specified name, or nil if the server
+// didn't return it.
+func (resp *PropFindResponse) Get(name xml.Name) *Property {
+	for i := range resp.Props {
+		if resp.Props[i].Name == name {
+			return &resp.Props[i]
+		}
+	}
+	return nil
+}
+
+// propertiesFromPropStats flattens property statuses. When withValues is
+// false, property values are omitted.
+func propertiesFromPropStats(propstats []internal.PropStat, withValues bool) []Property {
+	var props []Property
+	for _, propstat := range propstats {
+		code := propstat.Status.Code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		for i := range propstat.Prop.Raw {
+			raw := &propstat.Prop.Raw[i]
+			name, ok := raw.XMLName()
+			if !ok {
+				continue
+			}
+			prop := Property{Name: name, Status: code}
+			if withValues && code/100 == 2 {
+				prop.Value = raw
+			}
+			props = append(props, prop)
+		}
+	}
+	return props
+}